@@ -0,0 +1,146 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNoNumbers is returned by Avg, Min and Max when there are no numbers to
+// aggregate.
+var ErrNoNumbers = errors.New("no numbers to aggregate")
+
+// Count returns the number of elements of the array v that have a non-null
+// value at the given JSON Pointer, of any type.
+func Count(v Value, path string) (int, error) {
+	n := 0
+	err := eachAt(v, path, func(e interface{}) {
+		if e != nil {
+			n++
+		}
+	})
+	return n, err
+}
+
+// Sum returns the sum of the numbers at the given JSON Pointer within each
+// element of the array v. Elements missing the value, or where it isn't a
+// number, are ignored, as are the rest of the aggregations. The sum of no
+// numbers is zero.
+func Sum(v Value, path string) (float64, error) {
+	sum := 0.0
+	err := eachNumberAt(v, path, func(f float64) {
+		sum += f
+	})
+	return sum, err
+}
+
+// Avg returns the mean of the numbers at the given JSON Pointer within each
+// element of the array v.
+func Avg(v Value, path string) (float64, error) {
+	sum, n := 0.0, 0
+	err := eachNumberAt(v, path, func(f float64) {
+		sum += f
+		n++
+	})
+	if err == nil && n == 0 {
+		err = ErrNoNumbers
+	}
+	if err != nil {
+		return 0, err
+	}
+	return sum / float64(n), nil
+}
+
+// Min returns the least of the numbers at the given JSON Pointer within each
+// element of the array v.
+func Min(v Value, path string) (float64, error) {
+	return extremeAt(v, path, func(a, b float64) bool { return a < b })
+}
+
+// Max returns the greatest of the numbers at the given JSON Pointer within
+// each element of the array v.
+func Max(v Value, path string) (float64, error) {
+	return extremeAt(v, path, func(a, b float64) bool { return a > b })
+}
+
+func extremeAt(v Value, path string, better func(a, b float64) bool) (float64, error) {
+	var best float64
+	found := false
+	err := eachNumberAt(v, path, func(f float64) {
+		if !found || better(f, best) {
+			best = f
+			found = true
+		}
+	})
+	if err == nil && !found {
+		err = ErrNoNumbers
+	}
+	if err != nil {
+		return 0, err
+	}
+	return best, nil
+}
+
+// eachAt calls fn with the value at path within each element of the array v
+// that has one.
+func eachAt(v Value, path string, fn func(interface{})) error {
+	arr, ok := v.V.([]interface{})
+	if !ok {
+		return ErrNotArray
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range arr {
+		if ev, ok := resolvePointer(e, tokens); ok {
+			fn(ev)
+		}
+	}
+	return nil
+}
+
+// eachNumberAt calls fn with the value at path within each element of the
+// array v that has a number there.
+func eachNumberAt(v Value, path string, fn func(float64)) error {
+	return eachAt(v, path, func(e interface{}) {
+		if f, ok := toFloat64(e); ok {
+			fn(f)
+		}
+	})
+}
+
+// toFloat64 converts any of the number types produced by decoding to a
+// float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case *big.Int:
+		if v == nil {
+			return 0, false
+		}
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *big.Float:
+		if v == nil {
+			return 0, false
+		}
+		f, _ := v.Float64()
+		return f, true
+	case decimal.Decimal:
+		f, _ := v.Float64()
+		return f, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,61 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregations(tt *testing.T) {
+	v := MustNewValueFromJSON(`[
+		{"item":"a","price":{"amount":4}},
+		{"item":"b","price":{"amount":1.5}},
+		{"item":"c","price":{"amount":"free"}},
+		{"item":"d","price":null},
+		{"item":"e","price":{"amount":-0.5}}
+	]`)
+
+	tt.Run("numbers", func(t *testing.T) {
+		require := require.New(t)
+		n, err := Count(v, "/price/amount")
+		require.NoError(err)
+		require.Equal(4, n)
+		n, err = Count(v, "/price")
+		require.NoError(err)
+		require.Equal(4, n)
+
+		f, err := Sum(v, "/price/amount")
+		require.NoError(err)
+		require.Equal(5.0, f)
+		f, err = Avg(v, "/price/amount")
+		require.NoError(err)
+		require.InDelta(5.0/3, f, 1e-9)
+		f, err = Min(v, "/price/amount")
+		require.NoError(err)
+		require.Equal(-0.5, f)
+		f, err = Max(v, "/price/amount")
+		require.NoError(err)
+		require.Equal(4.0, f)
+	})
+
+	tt.Run("no numbers", func(t *testing.T) {
+		require := require.New(t)
+		f, err := Sum(v, "/item")
+		require.NoError(err)
+		require.Equal(0.0, f)
+		for _, agg := range []func(Value, string) (float64, error){Avg, Min, Max} {
+			_, err := agg(v, "/missing")
+			require.Equal(ErrNoNumbers, err)
+		}
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		_, err := Sum(MustNewValueFromJSON(`{}`), "/a")
+		require.Equal(ErrNotArray, err)
+		_, err = Max(v, "a")
+		require.Error(err)
+		_, err = Count(MustNewValueFromJSON(`1`), "")
+		require.Error(err)
+	})
+}
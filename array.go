@@ -0,0 +1,400 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNotArray is returned when a value that should be an array is not.
+var ErrNotArray = errors.New("value is not an array")
+
+// SortArray sorts the elements of the array v in place using less. The sort
+// is stable.
+func SortArray(v Value, less func(a, b interface{}) bool) error {
+	arr, ok := v.V.([]interface{})
+	if !ok {
+		return ErrNotArray
+	}
+	sort.SliceStable(arr, func(i, j int) bool {
+		return less(arr[i], arr[j])
+	})
+	return nil
+}
+
+// SortArrayBy sorts the elements of the array v in place by the value at the
+// given JSON Pointer within each element, using the ordering of
+// CompareValues. Elements missing the value sort first. The sort is stable.
+func SortArrayBy(v Value, path string) error {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return err
+	}
+	return SortArray(v, func(a, b interface{}) bool {
+		av, aok := resolvePointer(a, tokens)
+		bv, bok := resolvePointer(b, tokens)
+		if !aok || !bok {
+			return !aok && bok
+		}
+		return CompareValues(av, bv) < 0
+	})
+}
+
+// SortKey is a sort key for SortArrayByKeys.
+type SortKey struct {
+	// Path is the JSON Pointer of the value within each element to sort by.
+	Path string
+	// Descending sorts by the value in descending order.
+	Descending bool
+}
+
+// SortArrayByKeys sorts the elements of the array v in place by several
+// keys: by the first key, then by the second key among elements that are
+// equal by the first, and so on. Values are ordered as by SortArrayBy, with
+// elements missing a value sorting first, or last when descending. The sort
+// is stable.
+func SortArrayByKeys(v Value, keys ...SortKey) error {
+	paths := make([][]string, len(keys))
+	for i, k := range keys {
+		tokens, err := parsePointer(k.Path)
+		if err != nil {
+			return err
+		}
+		paths[i] = tokens
+	}
+	return SortArray(v, func(a, b interface{}) bool {
+		for i, tokens := range paths {
+			av, aok := resolvePointer(a, tokens)
+			bv, bok := resolvePointer(b, tokens)
+			c := 0
+			switch {
+			case !aok || !bok:
+				c = boolCompare(aok, bok)
+			default:
+				c = CompareValues(av, bv)
+			}
+			if keys[i].Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// boolCompare orders false before true.
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// GroupBy groups the elements of the array v by the value at the given JSON
+// Pointer within each element. It returns an Object with a key for each
+// group, in the order the groups first appear, whose value is an array of the
+// group's elements in their original order. Groups are keyed by the
+// Canonicalize encoding of their value, such as `"a"`, "1", "true" or
+// "null", so that values of different types, such as the string "1" and the
+// number 1, are never grouped together; elements missing the value are
+// grouped with null. The elements are shared with v, not copied.
+func GroupBy(v Value, path string) (*Object, error) {
+	arr, ok := v.V.([]interface{})
+	if !ok {
+		return nil, ErrNotArray
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	groups := NewObject()
+	for _, e := range arr {
+		gv, _ := resolvePointer(e, tokens)
+		b, err := Canonicalize(gv)
+		if err != nil {
+			return nil, err
+		}
+		k := string(b)
+		gv, _ = groups.Get(k)
+		group, _ := gv.([]interface{})
+		groups.Set(k, append(group, e))
+	}
+	return groups, nil
+}
+
+// CompareValues returns an integer comparing two decoded JSON values: 0 if
+// a == b, -1 if a < b, and +1 if a > b. Values of different types are
+// ordered null < false < true < numbers < strings < arrays < objects.
+// Numbers compare by their values, whatever their types, such as float64,
+// int64, json.Number, *big.Int or decimal.Decimal, so that int64(1) equals
+// 1.0. Strings, including RawStrings, compare by their decoded values.
+// Arrays compare element by element, and objects compare by their
+// Canonicalize encoding.
+func CompareValues(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	switch ra {
+	case rankNumber:
+		return compareNumbers(a, b)
+	case rankString:
+		a, b := stringValue(a), stringValue(b)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := CompareValues(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		switch {
+		case len(a) < len(b):
+			return -1
+		case len(a) > len(b):
+			return 1
+		}
+		return 0
+	default:
+		ca, _ := Canonicalize(a)
+		cb, _ := Canonicalize(b)
+		return bytes.Compare(ca, cb)
+	}
+}
+
+// rankNumber and rankString are the typeRanks of numbers and strings.
+const (
+	rankNumber = 3
+	rankString = 4
+)
+
+func typeRank(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 2
+		}
+		return 1
+	case float64, int64, int, json.Number, *big.Int, *big.Float, decimal.Decimal, *decimal.Decimal:
+		return rankNumber
+	case string, RawString:
+		return rankString
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// stringValue returns the decoded value of a string or RawString.
+func stringValue(v interface{}) string {
+	if s, ok := v.(RawString); ok {
+		return s.String()
+	}
+	return v.(string)
+}
+
+// compareNumbers is CompareValues for two numbers, of any types.
+func compareNumbers(a, b interface{}) int {
+	// Compare the common cases without allocating.
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return compareFloats(a, b)
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	// Otherwise compare exactly, as decimals. A float64 is taken to be the
+	// shortest decimal that parses back to it, which is how it is encoded, so
+	// that 0.1 equals json.Number("0.1").
+	da, okA := numberDecimal(a)
+	db, okB := numberDecimal(b)
+	if !okA || !okB {
+		// Infinities and malformed json.Numbers can't be decimals.
+		return compareFloats(numberFloat(a), numberFloat(b))
+	}
+	sa, sb := da.Sign(), db.Sign()
+	if sa != sb || sa == 0 {
+		return compareInts(sa, sb)
+	}
+	// Compare orders of magnitude first, so that Cmp, which rescales both to
+	// the smaller exponent, never needs to build a huge number for something
+	// like 1e-1000000000 and 1e1000000000.
+	ma := int64(da.Exponent()) + int64(da.NumDigits())
+	mb := int64(db.Exponent()) + int64(db.NumDigits())
+	if ma != mb {
+		if ma < mb {
+			return -sa
+		}
+		return sa
+	}
+	return da.Cmp(db)
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// numberDecimal returns the number v as a decimal, if it is finite.
+func numberDecimal(v interface{}) (decimal.Decimal, bool) {
+	switch v := v.(type) {
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return decimal.Decimal{}, false
+		}
+		return decimal.NewFromFloat(v), true
+	case int64:
+		return decimal.New(v, 0), true
+	case int:
+		return decimal.New(int64(v), 0), true
+	case json.Number:
+		d, err := decimal.NewFromString(string(v))
+		return d, err == nil
+	case *big.Int:
+		if v == nil {
+			return decimal.Decimal{}, false
+		}
+		return decimal.NewFromBigInt(v, 0), true
+	case *big.Float:
+		if v == nil || v.IsInf() {
+			return decimal.Decimal{}, false
+		}
+		d, err := decimal.NewFromString(v.Text('g', -1))
+		return d, err == nil
+	case decimal.Decimal:
+		return v, true
+	case *decimal.Decimal:
+		if v == nil {
+			return decimal.Decimal{}, false
+		}
+		return *v, true
+	}
+	return decimal.Decimal{}, false
+}
+
+// numberFloat returns the number v as the nearest float64, or NaN if it
+// isn't a number.
+func numberFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return math.NaN()
+		}
+		return f
+	case *big.Int:
+		if v != nil {
+			f, _ := new(big.Float).SetInt(v).Float64()
+			return f
+		}
+	case *big.Float:
+		if v != nil {
+			f, _ := v.Float64()
+			return f
+		}
+	case decimal.Decimal:
+		f, _ := v.Float64()
+		return f
+	case *decimal.Decimal:
+		if v != nil {
+			f, _ := v.Float64()
+			return f
+		}
+	}
+	return math.NaN()
+}
+
+// DedupeArray returns a copy of the array v with every element that is equal
+// to an earlier element removed. Elements are equal if their Canonicalize
+// encodings are, so objects with the same members in a different order, and
+// numbers with the same value, such as 1 and json.Number("1.0"), are
+// duplicates.
+func DedupeArray(v Value) (Value, error) {
+	arr, ok := v.V.([]interface{})
+	if !ok {
+		return Value{}, ErrNotArray
+	}
+	seen := make(map[string]struct{}, len(arr))
+	out := make([]interface{}, 0, len(arr))
+	for _, e := range arr {
+		c, err := Canonicalize(e)
+		if err != nil {
+			return Value{}, err
+		}
+		if _, ok := seen[string(c)]; ok {
+			continue
+		}
+		seen[string(c)] = struct{}{}
+		out = append(out, e)
+	}
+	return Value{V: out}, nil
+}
+
+// ReverseArray reverses the elements of the array v in place.
+func ReverseArray(v Value) error {
+	arr, ok := v.V.([]interface{})
+	if !ok {
+		return ErrNotArray
+	}
+	for i, j := 0, len(arr)-1; i < j; i, j = i+1, j-1 {
+		arr[i], arr[j] = arr[j], arr[i]
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package ojson
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayUtilities(tt *testing.T) {
+	marshal := func(t *testing.T, v Value) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	tt.Run("sort with comparator", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[3,1,2]`)
+		require.NoError(SortArray(v, func(a, b interface{}) bool {
+			return a.(float64) > b.(float64)
+		}))
+		require.Equal(`[3,2,1]`, marshal(t, v))
+	})
+
+	tt.Run("sort by path", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[
+			{"id":"c","meta":{"rank":2}},
+			{"id":"a","meta":{"rank":1}},
+			{"id":"x"},
+			{"id":"b","meta":{"rank":1}}
+		]`)
+		require.NoError(SortArrayBy(v, "/meta/rank"))
+		require.Equal(`[{"id":"x"},{"id":"a","meta":{"rank":1}},{"id":"b","meta":{"rank":1}},{"id":"c","meta":{"rank":2}}]`, marshal(t, v))
+	})
+
+	tt.Run("sort by keys", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[
+			{"id":1,"team":"b","score":5},
+			{"id":2,"team":"a","score":3},
+			{"id":3,"team":"b","score":9},
+			{"id":4,"score":1},
+			{"id":5,"team":"a","score":3}
+		]`)
+		require.NoError(SortArrayByKeys(v, SortKey{Path: "/team"}, SortKey{Path: "/score", Descending: true}))
+		require.Equal(`[{"id":4,"score":1},{"id":2,"team":"a","score":3},{"id":5,"team":"a","score":3},{"id":3,"team":"b","score":9},{"id":1,"team":"b","score":5}]`, marshal(t, v))
+
+		require.NoError(SortArrayByKeys(v, SortKey{Path: "/team", Descending: true}))
+		require.Equal(`[{"id":3,"team":"b","score":9},{"id":1,"team":"b","score":5},{"id":2,"team":"a","score":3},{"id":5,"team":"a","score":3},{"id":4,"score":1}]`, marshal(t, v))
+	})
+
+	tt.Run("group by", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[
+			{"id":1,"team":"b"},
+			{"id":2,"team":"a"},
+			{"id":3,"team":"b"},
+			{"id":4},
+			{"id":5,"team":1},
+			{"id":6,"team":null},
+			{"id":7,"team":"1"},
+			{"id":8,"team":"null"},
+			{"id":9,"team":true},
+			{"id":10,"team":"true"}
+		]`)
+		groups, err := GroupBy(v, "/team")
+		require.NoError(err)
+		require.Equal([]string{`"b"`, `"a"`, `null`, `1`, `"1"`, `"null"`, `true`, `"true"`}, groups.KeyOrder())
+		b, err := json.Marshal(groups)
+		require.NoError(err)
+		require.Equal(`{"\"b\"":[{"id":1,"team":"b"},{"id":3,"team":"b"}],"\"a\"":[{"id":2,"team":"a"}],"null":[{"id":4},{"id":6,"team":null}],"1":[{"id":5,"team":1}],"\"1\"":[{"id":7,"team":"1"}],"\"null\"":[{"id":8,"team":"null"}],"true":[{"id":9,"team":true}],"\"true\"":[{"id":10,"team":"true"}]}`, string(b))
+	})
+
+	tt.Run("compare values", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[{"a":1},"b",[1],2,true,null,false,"a",[0,5],1]`)
+		require.NoError(SortArray(v, func(a, b interface{}) bool {
+			return CompareValues(a, b) < 0
+		}))
+		require.Equal(`[null,false,true,1,2,"a","b",[0,5],[1],{"a":1}]`, marshal(t, v))
+
+		v = Value{V: []interface{}{NewObject().SetAndReturn("a", 1.0), RawString{Raw: `"\u0062"`}, "c", "a"}}
+		require.NoError(SortArray(v, func(a, b interface{}) bool {
+			return CompareValues(a, b) < 0
+		}))
+		require.Equal(`["a","\u0062","c",{"a":1}]`, marshal(t, v))
+		require.Equal(0, CompareValues(RawString{Raw: `"\u0062"`}, "b"))
+	})
+
+	tt.Run("compare mixed numbers", func(t *testing.T) {
+		require := require.New(t)
+		big1e30, _ := new(big.Int).SetString("1000000000000000000000000000000", 10)
+		for _, test := range []struct {
+			a, b interface{}
+			cmp  int
+		}{
+			{a: int64(1), b: 1.0, cmp: 0},
+			{a: int64(2), b: 1.5, cmp: 1},
+			{a: json.Number("0.1"), b: 0.1, cmp: 0},
+			{a: json.Number("1e2"), b: int64(100), cmp: 0},
+			{a: decimal.RequireFromString("-2.50"), b: -2.5, cmp: 0},
+			{a: big1e30, b: 1e29, cmp: 1},
+			{a: big1e30, b: json.Number("1e30"), cmp: 0},
+			{a: int64(9007199254740993), b: 9007199254740992.0, cmp: 1},
+			{a: json.Number("1e-1000000000"), b: json.Number("1e1000000000"), cmp: -1},
+			{a: json.Number("-1e1000000000"), b: int64(-1), cmp: -1},
+			{a: big.NewFloat(0.5), b: int64(0), cmp: 1},
+			{a: int64(0), b: "0", cmp: -1},
+		} {
+			require.Equal(test.cmp, CompareValues(test.a, test.b), "%v <=> %v", test.a, test.b)
+			require.Equal(-test.cmp, CompareValues(test.b, test.a), "%v <=> %v", test.b, test.a)
+		}
+
+		v, err := DecodeOptions{Integers: true}.Unmarshal([]byte(`[10,9,2.5,2]`))
+		require.NoError(err)
+		require.NoError(SortArrayBy(v, ""))
+		require.Equal(`[2,2.5,9,10]`, marshal(t, v))
+
+		doc, err := DecodeOptions{Integers: true}.Unmarshal([]byte(`{"a":1}`))
+		require.NoError(err)
+		_, err = ApplyPatch(doc, Patch{{Op: "test", Path: "/a", Value: 1.0}})
+		require.NoError(err)
+		require.Empty(ChangeLog(doc, MustNewValueFromJSON(`{"a":1}`)))
+	})
+
+	tt.Run("dedupe", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[{"a":1,"b":2},1,"1",{"b":2,"a":1},1,[1,{}],[1,{}]]`)
+		deduped, err := DedupeArray(v)
+		require.NoError(err)
+		require.Equal(`[{"a":1,"b":2},1,"1",[1,{}]]`, marshal(t, deduped))
+
+		deduped, err = DedupeArray(Value{V: []interface{}{1.0, json.Number("1.0"), int64(1), json.Number("2")}})
+		require.NoError(err)
+		require.Equal(`[1,2]`, marshal(t, deduped))
+
+		groups, err := GroupBy(Value{V: []interface{}{
+			NewObject().SetAndReturn("n", 1.0),
+			NewObject().SetAndReturn("n", json.Number("1.0")),
+		}}, "/n")
+		require.NoError(err)
+		require.Equal([]string{"1"}, groups.KeyOrder())
+	})
+
+	tt.Run("reverse", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[1,"two",{"three":3}]`)
+		require.NoError(ReverseArray(v))
+		require.Equal(`[{"three":3},"two",1]`, marshal(t, v))
+	})
+
+	tt.Run("not an array", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{}`)
+		require.Error(SortArrayBy(v, "/a"))
+		require.Error(ReverseArray(v))
+		_, err := DedupeArray(v)
+		require.Error(err)
+		require.Error(SortArrayByKeys(v, SortKey{Path: "/a"}))
+		_, err = GroupBy(v, "/a")
+		require.Error(err)
+	})
+}
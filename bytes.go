@@ -0,0 +1,39 @@
+package ojson
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// GetBytes returns the value of k decoded from a base64 string, following
+// encoding/json's convention for []byte. A []byte value is returned as-is.
+func (o *Object) GetBytes(k string) ([]byte, error) {
+	v, ok := o.Get(k)
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", k)
+	}
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("decoding value of key %q as base64: %w", k, err)
+		}
+		return b, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("value of key %q is a %T, not a base64 string", k, v)
+	}
+}
+
+// SetBytes sets k to b encoded as a base64 string, following encoding/json's
+// convention for []byte. A nil b is set as null.
+func (o *Object) SetBytes(k string, b []byte) {
+	if b == nil {
+		o.Set(k, nil)
+		return
+	}
+	o.Set(k, base64.StdEncoding.EncodeToString(b))
+}
@@ -0,0 +1,49 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSetBytes(tt *testing.T) {
+	tt.Run("round trip", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		o.SetBytes("data", []byte("hello\x00"))
+		o.SetBytes("none", nil)
+		b, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"data":"aGVsbG8A","none":null}`, string(b))
+
+		data, err := o.GetBytes("data")
+		require.NoError(err)
+		require.Equal([]byte("hello\x00"), data)
+		none, err := o.GetBytes("none")
+		require.NoError(err)
+		require.Nil(none)
+	})
+
+	tt.Run("raw bytes", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject().SetAndReturn("data", []byte{1, 2})
+		data, err := o.GetBytes("data")
+		require.NoError(err)
+		require.Equal([]byte{1, 2}, data)
+
+		// []byte values marshal like encoding/json.
+		b, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"data":"AQI="}`, string(b))
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject().SetAndReturn("s", "not base64!").SetAndReturn("n", 1.0)
+		for _, k := range []string{"s", "n", "missing"} {
+			_, err := o.GetBytes(k)
+			require.Error(err, k)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package ojson
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// Canonicalize encodes v in the form defined by the JSON Canonicalization
+// Scheme (JCS, RFC 8785): object keys are sorted by their UTF-16 code units,
+// numbers are formatted as in ECMAScript, strings use minimal escaping, and
+// there is no insignificant whitespace. Two values that differ only in key
+// order or formatting have the same canonical form, which makes it suitable
+// for hashing and signing.
+func Canonicalize(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeJCS(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeJCS(b *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		if v {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case float64:
+		f, err := FloatFormat{ECMAScript: true}.format(v)
+		if err != nil {
+			return err
+		}
+		b.Write(f)
+	case string:
+		writeJCSString(b, v)
+	case RawString:
+		writeJCSString(b, v.String())
+	case []interface{}:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJCS(b, e); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case *Object:
+		if v == nil {
+			b.WriteString("null")
+			return nil
+		}
+		entries := v.Entries()
+		sort.Slice(entries, func(i, j int) bool {
+			return lessUTF16(entries[i].Key, entries[j].Key)
+		})
+		b.WriteByte('{')
+		for i, e := range entries {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJCSString(b, e.Key)
+			b.WriteByte(':')
+			if err := writeJCS(b, e.Value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case Value:
+		return writeJCS(b, v.V)
+	default:
+		// Convert anything else, such as structs, maps, and other number
+		// types, through its regular JSON encoding.
+		e := newEncodeState()
+		if err := e.marshal(v); err != nil {
+			return err
+		}
+		decoded, err := NewValueFromJSON(e.String())
+		if err != nil {
+			return fmt.Errorf("canonicalizing %T: %w", v, err)
+		}
+		return writeJCS(b, decoded.V)
+	}
+	return nil
+}
+
+func writeJCSString(b *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[r>>4])
+				b.WriteByte(hex[r&0xF])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// lessUTF16 reports whether a sorts before b when compared by UTF-16 code
+// units, as JCS requires.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,47 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(tt *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "sorted keys",
+			in:   `{"b":[1,{"d":1,"c":2}],"a":null}`,
+			out:  `{"a":null,"b":[1,{"c":2,"d":1}]}`,
+		},
+		{
+			// From RFC 8785, section 3.2.3.
+			name: "utf-16 key order",
+			in:   `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`,
+			out:  `{"\r":"Carriage Return","1":"One","":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","דּ":"Hebrew Letter Dalet With Dagesh"}`,
+		},
+		{
+			// From RFC 8785, section 3.2.2.
+			name: "numbers and strings",
+			in:   `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`,
+			out:  `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			b, err := Canonicalize(MustNewValueFromJSON(test.in))
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("go values", func(t *testing.T) {
+		require := require.New(t)
+		b, err := Canonicalize(map[string]interface{}{"b": 1, "a": struct{ X int }{2}})
+		require.NoError(err)
+		require.Equal(`{"a":{"X":2},"b":1}`, string(b))
+	})
+}
@@ -0,0 +1,95 @@
+package ojson
+
+import "strconv"
+
+// Change is an entry of a ChangeLog: a single value that was added, removed
+// or replaced.
+type Change struct {
+	// Path is the JSON Pointer of the value.
+	Path string `json:"path"`
+	// Op is "add", "remove" or "replace".
+	Op string `json:"op"`
+	// Old is the previous value, or nil for "add".
+	Old interface{} `json:"old"`
+	// New is the new value, or nil for "remove".
+	New interface{} `json:"new"`
+}
+
+// ChangeLog compares old and new and returns a flat list of the values that
+// differ, in document order, for audit logging. Objects and arrays present in
+// both are compared member by member and element by element, so only the
+// innermost changes are listed; arrays are compared by index. A removed
+// object member is listed where it was in old, after the member that
+// preceded it. Reordering members is not a change. The values in the
+// Changes are shared with old and new, not copied.
+func ChangeLog(old, new Value) []Change {
+	var changes []Change
+	changeLog(old.V, new.V, "", &changes)
+	return changes
+}
+
+func changeLog(a, b interface{}, path string, changes *[]Change) {
+	switch a := a.(type) {
+	case *Object:
+		if b, ok := b.(*Object); ok && a != nil && b != nil {
+			changeLogObject(a, b, path, changes)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && a != nil && b != nil {
+			changeLogArray(a, b, path, changes)
+			return
+		}
+	}
+	if CompareValues(a, b) != 0 {
+		*changes = append(*changes, Change{Path: path, Op: "replace", Old: a, New: b})
+	}
+}
+
+func changeLogObject(a, b *Object, path string, changes *[]Change) {
+	// Group each removed member with the surviving member before it, if any.
+	var leading []Entry
+	removed := make(map[string][]Entry)
+	anchor := -1
+	for i, e := range a.entries {
+		if b.Has(e.Key) {
+			anchor = i
+		} else if anchor < 0 {
+			leading = append(leading, e)
+		} else {
+			k := a.entries[anchor].Key
+			removed[k] = append(removed[k], e)
+		}
+	}
+	remove := func(entries []Entry) {
+		for _, e := range entries {
+			*changes = append(*changes, Change{Path: path + "/" + escapePointerToken(e.Key), Op: "remove", Old: e.Value})
+		}
+	}
+
+	remove(leading)
+	for _, e := range b.entries {
+		p := path + "/" + escapePointerToken(e.Key)
+		av, ok := a.Get(e.Key)
+		if !ok {
+			*changes = append(*changes, Change{Path: p, Op: "add", New: e.Value})
+			continue
+		}
+		changeLog(av, e.Value, p, changes)
+		remove(removed[e.Key])
+	}
+}
+
+func changeLogArray(a, b []interface{}, path string, changes *[]Change) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := path + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, Change{Path: p, Op: "add", New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, Change{Path: p, Op: "remove", Old: a[i]})
+		default:
+			changeLog(a[i], b[i], p, changes)
+		}
+	}
+}
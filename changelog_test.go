@@ -0,0 +1,62 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeLog(tt *testing.T) {
+	for _, test := range []struct {
+		name string
+		old  string
+		new  string
+		out  string
+	}{
+		{
+			name: "no changes",
+			old:  `{"a":1,"b":[1,{"c":2}]}`,
+			new:  `{"b":[1,{"c":2}],"a":1}`,
+			out:  `null`,
+		},
+		{
+			name: "members",
+			old:  `{"x":0,"a":1,"gone":true,"b":{"c":2,"d":3},"e":4}`,
+			new:  `{"a":1,"new":null,"b":{"c":20,"d":3},"e":{"f":5}}`,
+			out: `[{"path":"/x","op":"remove","old":0,"new":null},` +
+				`{"path":"/gone","op":"remove","old":true,"new":null},` +
+				`{"path":"/new","op":"add","old":null,"new":null},` +
+				`{"path":"/b/c","op":"replace","old":2,"new":20},` +
+				`{"path":"/e","op":"replace","old":4,"new":{"f":5}}]`,
+		},
+		{
+			name: "arrays",
+			old:  `{"a":[1,2,3],"b":[1]}`,
+			new:  `{"a":[1,5],"b":[1,{"c":1}]}`,
+			out: `[{"path":"/a/1","op":"replace","old":2,"new":5},` +
+				`{"path":"/a/2","op":"remove","old":3,"new":null},` +
+				`{"path":"/b/1","op":"add","old":null,"new":{"c":1}}]`,
+		},
+		{
+			name: "escaped keys",
+			old:  `{"a/b":{"~":1}}`,
+			new:  `{"a/b":{"~":2}}`,
+			out:  `[{"path":"/a~1b/~0","op":"replace","old":1,"new":2}]`,
+		},
+		{
+			name: "root",
+			old:  `1`,
+			new:  `"1"`,
+			out:  `[{"path":"","op":"replace","old":1,"new":"1"}]`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			changes := ChangeLog(MustNewValueFromJSON(test.old), MustNewValueFromJSON(test.new))
+			b, err := json.Marshal(changes)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package ojson
+
+import "encoding/json"
+
+// Codec encodes and decodes messages as ordered JSON. It implements both
+// gRPC's encoding.Codec and Connect's connect.Codec, without depending on
+// either, so it can be registered with encoding.RegisterCodec or passed to
+// connect.WithCodec to replace the default JSON codec. Messages must be
+// Values, Objects, or types that encode themselves to JSON, such as wrappers
+// around protojson; field order is preserved for Values and Objects.
+type Codec struct {
+	// MarshalOptions configures how messages are encoded.
+	MarshalOptions MarshalOptions
+	// DecodeOptions configures how messages are decoded into Values and Objects.
+	DecodeOptions DecodeOptions
+}
+
+// Name returns "json", the content subtype the codec handles.
+func (c Codec) Name() string {
+	return "json"
+}
+
+// Marshal encodes v as JSON.
+func (c Codec) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalOptions.Marshal(v)
+}
+
+// Unmarshal decodes data into v, which must be a *Value, an *Object, or a
+// pointer to a type that encoding/json can decode into.
+func (c Codec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *Value:
+		decoded, err := c.DecodeOptions.Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		*v = decoded
+		return nil
+	case *Object:
+		decoded, err := c.DecodeOptions.Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		obj, ok := decoded.V.(*Object)
+		if !ok {
+			return ErrNotObject
+		}
+		*v = *obj
+		return nil
+	default:
+		return json.Unmarshal(data, v)
+	}
+}
@@ -0,0 +1,52 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(tt *testing.T) {
+	// The method set shared by grpc/encoding.Codec and connect.Codec.
+	var c interface {
+		Name() string
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	} = Codec{}
+	require.Equal(tt, "json", c.Name())
+
+	const in = `{"z":1,"a":{"y":2,"b":3}}`
+
+	tt.Run("value", func(t *testing.T) {
+		require := require.New(t)
+		var v Value
+		require.NoError(c.Unmarshal([]byte(in), &v))
+		b, err := c.Marshal(v)
+		require.NoError(err)
+		require.Equal(in, string(b))
+	})
+
+	tt.Run("object", func(t *testing.T) {
+		require := require.New(t)
+		var o Object
+		require.NoError(c.Unmarshal([]byte(in), &o))
+		require.Equal([]string{"z", "a"}, o.KeyOrder())
+		b, err := c.Marshal(&o)
+		require.NoError(err)
+		require.Equal(in, string(b))
+
+		require.Error(c.Unmarshal([]byte(`[1]`), &o))
+	})
+
+	tt.Run("other", func(t *testing.T) {
+		require := require.New(t)
+		var msg struct {
+			Z int `json:"z"`
+		}
+		require.NoError(c.Unmarshal([]byte(in), &msg))
+		require.Equal(1, msg.Z)
+		b, err := c.Marshal(msg)
+		require.NoError(err)
+		require.Equal(`{"z":1}`, string(b))
+	})
+}
@@ -0,0 +1,203 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type entryComments struct {
+	leading, trailing string
+}
+
+// SetLeadingComment attaches a comment to be written on the line(s) before
+// the entry for k when marshaling with Format.Comments. An empty comment
+// removes it. The comment is kept even if k is not in the Object, and is
+// written once k is set.
+func (o *Object) SetLeadingComment(k, comment string) {
+	c := o.comments[k]
+	c.leading = comment
+	o.setComments(k, c)
+}
+
+// SetTrailingComment attaches a comment to be written after the entry for k,
+// on the same line, when marshaling with Format.Comments. An empty comment
+// removes it.
+func (o *Object) SetTrailingComment(k, comment string) {
+	c := o.comments[k]
+	c.trailing = comment
+	o.setComments(k, c)
+}
+
+// Comments returns the comments attached to the entry for k.
+func (o *Object) Comments(k string) (leading, trailing string) {
+	c := o.comments[k]
+	return c.leading, c.trailing
+}
+
+func (o *Object) setComments(k string, c entryComments) {
+	if c == (entryComments{}) {
+		delete(o.comments, k)
+		return
+	}
+	if o.comments == nil {
+		o.comments = make(map[string]entryComments)
+	}
+	o.comments[k] = c
+}
+
+// marshalComments encodes v like Marshal, along with the comments attached to
+// its Objects.
+func (f Format) marshalComments(v interface{}) ([]byte, error) {
+	w := commentWriter{
+		indent:   f.Indent,
+		visiting: make(map[*Entry]struct{}),
+	}
+	if err := w.write(v, 0); err != nil {
+		return nil, err
+	}
+	if f.TrailingNewline {
+		w.WriteString("\n")
+	}
+	return w.Bytes(), nil
+}
+
+type commentWriter struct {
+	bytes.Buffer
+	indent string
+	// visiting holds the objectIDs of the Objects being written.
+	visiting map[*Entry]struct{}
+}
+
+func (w *commentWriter) write(v interface{}, depth int) error {
+	switch v := v.(type) {
+	case *Object:
+		if v != nil {
+			return w.writeObject(v, depth)
+		}
+	case Object:
+		return w.writeObject(&v, depth)
+	case []interface{}:
+		if v != nil {
+			return w.writeArray(v, depth)
+		}
+	case Value:
+		return w.write(v.V, depth)
+	case *Value:
+		if v != nil {
+			return w.write(v.V, depth)
+		}
+	}
+
+	e := newEncodeState()
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	if w.indent == "" {
+		w.Write(e.Bytes())
+		return nil
+	}
+	return json.Indent(&w.Buffer, e.Bytes(), strings.Repeat(w.indent, depth), w.indent)
+}
+
+func (w *commentWriter) writeObject(o *Object, depth int) error {
+	if key := objectID(o); key != nil {
+		if _, ok := w.visiting[key]; ok {
+			return ErrCycle
+		}
+		w.visiting[key] = struct{}{}
+		defer delete(w.visiting, key)
+	}
+
+	if len(o.entries) == 0 {
+		w.WriteString("{}")
+		return nil
+	}
+	w.WriteString("{")
+	for i, kv := range o.entries {
+		c := o.comments[kv.Key]
+		w.newline(depth + 1)
+		if c.leading != "" {
+			if w.indent == "" {
+				w.blockComment(c.leading)
+			} else {
+				for _, line := range strings.Split(c.leading, "\n") {
+					w.lineComment(line)
+					w.newline(depth + 1)
+				}
+			}
+		}
+		b, err := json.Marshal(kv.Key)
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+		w.WriteString(":")
+		if w.indent != "" {
+			w.WriteString(" ")
+		}
+		if err := w.write(kv.Value, depth+1); err != nil {
+			return err
+		}
+		if i < len(o.entries)-1 {
+			w.WriteString(",")
+		}
+		if c.trailing != "" {
+			w.WriteString(" ")
+			if w.indent == "" || strings.Contains(c.trailing, "\n") {
+				w.blockComment(c.trailing)
+			} else {
+				w.lineComment(c.trailing)
+			}
+		}
+	}
+	w.newline(depth)
+	w.WriteString("}")
+	return nil
+}
+
+func (w *commentWriter) writeArray(arr []interface{}, depth int) error {
+	if len(arr) == 0 {
+		w.WriteString("[]")
+		return nil
+	}
+	w.WriteString("[")
+	for i, v := range arr {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.newline(depth + 1)
+		if err := w.write(v, depth+1); err != nil {
+			return err
+		}
+	}
+	w.newline(depth)
+	w.WriteString("]")
+	return nil
+}
+
+// newline starts a new line at the given depth, if indenting.
+func (w *commentWriter) newline(depth int) {
+	if w.indent == "" {
+		return
+	}
+	w.WriteString("\n")
+	for i := 0; i < depth; i++ {
+		w.WriteString(w.indent)
+	}
+}
+
+func (w *commentWriter) lineComment(s string) {
+	w.WriteString("//")
+	if s != "" {
+		w.WriteString(" ")
+		w.WriteString(s)
+	}
+}
+
+func (w *commentWriter) blockComment(s string) {
+	w.WriteString("/* ")
+	// Keep the comment from ending early.
+	w.WriteString(strings.ReplaceAll(s, "*/", "* /"))
+	w.WriteString(" */")
+}
@@ -0,0 +1,89 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments(tt *testing.T) {
+	obj := NewObject().
+		SetAndReturn("name", "web").
+		SetAndReturn("replicas", 3).
+		SetAndReturn("ports", []interface{}{80, NewObject().SetAndReturn("port", 443)}).
+		SetAndReturn("empty", NewObject())
+	obj.SetLeadingComment("name", "Generated by deployer.\nDo not edit.")
+	obj.SetTrailingComment("replicas", "scaled by the autoscaler")
+	ports, _ := obj.Get("ports")
+	ports.([]interface{})[1].(*Object).SetTrailingComment("port", "*/ TLS")
+
+	for _, test := range []struct {
+		name   string
+		format Format
+		out    string
+	}{
+		{
+			name:   "indented",
+			format: Format{Indent: "  ", TrailingNewline: true, Comments: true},
+			out: `{
+  // Generated by deployer.
+  // Do not edit.
+  "name": "web",
+  "replicas": 3, // scaled by the autoscaler
+  "ports": [
+    80,
+    {
+      "port": 443 // */ TLS
+    }
+  ],
+  "empty": {}
+}
+`,
+		},
+		{
+			name:   "compact",
+			format: Format{Comments: true},
+			out:    `{/* Generated by deployer.` + "\n" + `Do not edit. */"name":"web","replicas":3, /* scaled by the autoscaler */"ports":[80,{"port":443 /* * / TLS */}],"empty":{}}`,
+		},
+		{
+			name:   "without comments",
+			format: Format{Indent: "\t"},
+			out:    "{\n\t\"name\": \"web\",\n\t\"replicas\": 3,\n\t\"ports\": [\n\t\t80,\n\t\t{\n\t\t\t\"port\": 443\n\t\t}\n\t],\n\t\"empty\": {}\n}",
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			b, err := test.format.Marshal(obj)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("accessors", func(t *testing.T) {
+		require := require.New(t)
+		leading, trailing := obj.Comments("replicas")
+		require.Equal("", leading)
+		require.Equal("scaled by the autoscaler", trailing)
+
+		o := NewObject()
+		o.SetLeadingComment("a", "x")
+		o.SetLeadingComment("a", "")
+		leading, trailing = o.Comments("a")
+		require.Equal("", leading)
+		require.Equal("", trailing)
+	})
+
+	tt.Run("cycle", func(t *testing.T) {
+		o := NewObject()
+		o.Set("self", o)
+		_, err := Format{Comments: true}.Marshal(o)
+		require.Equal(t, ErrCycle, err)
+
+		// A copy of an Object value shares its storage.
+		o = NewObject()
+		o.Set("self", nil)
+		o.Set("self", *o)
+		_, err = Format{Comments: true}.Marshal(o)
+		require.Equal(t, ErrCycle, err)
+	})
+}
@@ -0,0 +1,159 @@
+// Package ctyjson converts between ojson Values and go-cty values, such as
+// those used by HCL and Terraform. It is a module of its own, so that ojson
+// itself doesn't depend on go-cty.
+package ctyjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/airplanedev/ojson"
+	"github.com/shopspring/decimal"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ToCty converts v to a cty.Value. Objects become cty objects and arrays
+// become tuples, since JSON arrays may mix types. cty attributes are
+// unordered, so key order is not carried over.
+func ToCty(v ojson.Value) (cty.Value, error) {
+	return toCty(v.V, "")
+}
+
+func toCty(v interface{}, path string) (cty.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	case string:
+		return cty.StringVal(v), nil
+	case ojson.RawString:
+		var s string
+		if err := json.Unmarshal([]byte(v.Raw), &s); err != nil {
+			return cty.NilVal, fmt.Errorf("converting %q: %w", path, err)
+		}
+		return cty.StringVal(s), nil
+	case float64:
+		return cty.NumberFloatVal(v), nil
+	case int:
+		return cty.NumberIntVal(int64(v)), nil
+	case int64:
+		return cty.NumberIntVal(v), nil
+	case json.Number:
+		n, err := cty.ParseNumberVal(string(v))
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("converting %q: %w", path, err)
+		}
+		return n, nil
+	case *big.Int:
+		if v == nil {
+			return cty.NullVal(cty.Number), nil
+		}
+		return cty.NumberVal(new(big.Float).SetInt(v)), nil
+	case *big.Float:
+		if v == nil {
+			return cty.NullVal(cty.Number), nil
+		}
+		return cty.NumberVal(v), nil
+	case decimal.Decimal:
+		return cty.ParseNumberVal(v.String())
+	case *ojson.Object:
+		if v == nil {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+		attrs := make(map[string]cty.Value, v.Len())
+		for _, kv := range v.Entries() {
+			e, err := toCty(kv.Value, path+"/"+escapePointerToken(kv.Key))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[kv.Key] = e
+		}
+		return cty.ObjectVal(attrs), nil
+	case []interface{}:
+		if v == nil {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+		elems := make([]cty.Value, len(v))
+		for i, e := range v {
+			c, err := toCty(e, path+"/"+strconv.Itoa(i))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems[i] = c
+		}
+		return cty.TupleVal(elems), nil
+	case ojson.Value:
+		return toCty(v.V, path)
+	default:
+		return cty.NilVal, fmt.Errorf("converting %q: unsupported type %T", path, v)
+	}
+}
+
+// FromCty converts a cty.Value to a Value. Objects and maps become Objects
+// with their keys in sorted order, which is the only order cty has; use
+// ojson.ReorderLike to restore a known order. Lists, sets, and tuples become
+// arrays. Numbers become float64 if they can be represented exactly, or
+// *big.Float otherwise. Marks are ignored. Unknown values and capsules can't
+// be converted.
+func FromCty(v cty.Value) (ojson.Value, error) {
+	v, _ = v.UnmarkDeep()
+	e, err := fromCty(v, "")
+	return ojson.Value{V: e}, err
+}
+
+func fromCty(v cty.Value, path string) (interface{}, error) {
+	if !v.IsKnown() {
+		return nil, fmt.Errorf("converting %q: value is unknown", path)
+	}
+	if v.IsNull() {
+		return nil, nil
+	}
+	t := v.Type()
+	switch {
+	case t == cty.Bool:
+		return v.True(), nil
+	case t == cty.String:
+		return v.AsString(), nil
+	case t == cty.Number:
+		f := v.AsBigFloat()
+		if n, acc := f.Float64(); acc == big.Exact {
+			return n, nil
+		}
+		return f, nil
+	case t.IsObjectType() || t.IsMapType():
+		obj := ojson.NewObject()
+		// cty iterates attributes and map elements in sorted order.
+		for it := v.ElementIterator(); it.Next(); {
+			k, e := it.Element()
+			key := k.AsString()
+			o, err := fromCty(e, path+"/"+escapePointerToken(key))
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key, o)
+		}
+		return obj, nil
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		arr := make([]interface{}, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+			o, err := fromCty(e, path+"/"+strconv.Itoa(len(arr)))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, o)
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("converting %q: unsupported type %s", path, t.FriendlyName())
+	}
+}
+
+// escapePointerToken escapes a reference token for use in a JSON Pointer.
+func escapePointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,70 @@
+package ctyjson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/airplanedev/ojson"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestToCty(tt *testing.T) {
+	require := require.New(tt)
+	v := ojson.MustNewValueFromJSON(`{"name":"web","count":2,"tags":["a",1,null],"nested":{"on":true},"empty":[]}`)
+	c, err := ToCty(v)
+	require.NoError(err)
+	require.True(c.Equals(cty.ObjectVal(map[string]cty.Value{
+		"name":   cty.StringVal("web"),
+		"count":  cty.NumberIntVal(2),
+		"tags":   cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1), cty.NullVal(cty.DynamicPseudoType)}),
+		"nested": cty.ObjectVal(map[string]cty.Value{"on": cty.True}),
+		"empty":  cty.EmptyTupleVal,
+	})).True())
+
+	n, err := ojson.DecodeOptions{BigNumbers: true}.Unmarshal([]byte(`12345678901234567890123`))
+	require.NoError(err)
+	cn, err := ToCty(n)
+	require.NoError(err)
+	require.Equal("12345678901234567890123", cn.AsBigFloat().Text('f', -1))
+
+	_, err = ToCty(ojson.Value{V: ojson.NewObject().SetAndReturn("a", []interface{}{struct{}{}})})
+	require.EqualError(err, `converting "/a/0": unsupported type struct {}`)
+}
+
+func TestFromCty(tt *testing.T) {
+	require := require.New(tt)
+	big, err := cty.ParseNumberVal("1606938044258990275541962092341162602522202993782792835301377")
+	require.NoError(err)
+	v, err := FromCty(cty.ObjectVal(map[string]cty.Value{
+		"z":    cty.StringVal("last"),
+		"a":    cty.NumberFloatVal(1.5),
+		"list": cty.ListVal([]cty.Value{cty.True, cty.False}),
+		"set":  cty.SetVal([]cty.Value{cty.StringVal("y"), cty.StringVal("x")}),
+		"map":  cty.MapVal(map[string]cty.Value{"k": cty.NullVal(cty.String)}),
+		"big":  big,
+	}).Mark("sensitive"))
+	require.NoError(err)
+	b, err := json.Marshal(v)
+	require.NoError(err)
+	require.Equal(`{"a":1.5,"big":1.606938044258990275541962092341162602522202993782792835301377e+60,"list":[true,false],"map":{"k":null},"set":["x","y"],"z":"last"}`, string(b))
+
+	_, err = FromCty(cty.ObjectVal(map[string]cty.Value{"a": cty.UnknownVal(cty.String)}))
+	require.EqualError(err, `converting "/a": value is unknown`)
+}
+
+func TestCtyRoundTrip(tt *testing.T) {
+	require := require.New(tt)
+	in := ojson.MustNewValueFromJSON(`{"resource":{"b":{"x":1},"a":[true,"s"]}}`)
+	c, err := ToCty(in)
+	require.NoError(err)
+	out, err := FromCty(c)
+	require.NoError(err)
+
+	// Restore the original order.
+	obj := out.V.(*ojson.Object)
+	ojson.ReorderLike(obj, in.V.(*ojson.Object), ojson.ReorderOptions{Recursive: true})
+	b, err := json.Marshal(obj)
+	require.NoError(err)
+	require.Equal(`{"resource":{"b":{"x":1},"a":[true,"s"]}}`, string(b))
+}
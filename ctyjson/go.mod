@@ -0,0 +1,19 @@
+module github.com/airplanedev/ojson/ctyjson
+
+go 1.17
+
+require (
+	github.com/airplanedev/ojson v0.0.0
+	github.com/shopspring/decimal v1.4.0
+	github.com/stretchr/testify v1.7.0
+	github.com/zclconf/go-cty v1.9.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.3.5 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/airplanedev/ojson => ../
@@ -0,0 +1,591 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DecodeOptions configures optional decoding behavior. The zero value decodes
+// the same way as Value.UnmarshalJSON.
+type DecodeOptions struct {
+	// PreserveStringEscapes decodes string values as RawString instead of
+	// string, so that they are re-encoded exactly as they appeared in the
+	// input (e.g. "\u00e9" stays "\u00e9" rather than becoming "é").
+	// Object keys are still decoded as plain strings, but the Object
+	// remembers how they were encoded, and marshals them the same way.
+	PreserveStringEscapes bool
+
+	// BigNumbers decodes integers too large to be represented exactly by a
+	// float64 as *big.Int, and other numbers outside the range of a float64
+	// as *big.Float, instead of losing precision or failing. All other
+	// numbers are still decoded as float64.
+	BigNumbers bool
+
+	// Decimals decodes all numbers as decimal.Decimal instead of float64,
+	// preserving their exact values. It takes precedence over BigNumbers.
+	Decimals bool
+
+	// UseNumber decodes all numbers as json.Number instead of float64, so
+	// that they are re-encoded exactly as they appeared in the input. It
+	// takes precedence over BigNumbers and Decimals.
+	UseNumber bool
+
+	// Integers decodes numbers written without a fraction or exponent, such
+	// as 42, as int64 when they are within its range. Other numbers are
+	// decoded as usual. UseNumber and Decimals take precedence over it.
+	Integers bool
+
+	// Times decodes string values that are RFC 3339 timestamps as
+	// time.Time instead of string.
+	Times bool
+	// TimeLocation, if set, converts times decoded because of Times to the
+	// given location, e.g. time.UTC.
+	TimeLocation *time.Location
+
+	// Positions records where each key and value appeared in the input, to
+	// be retrieved with Object.Position and Object.KeyPosition.
+	Positions bool
+
+	// KeyAliases renames object keys as they are decoded, mapping each alias
+	// to its canonical key, e.g. {"userId": "user_id", "uid": "user_id"}. If
+	// an object has several keys with the same canonical key, the entry is
+	// positioned at the first and takes the value of the last, as with
+	// duplicate keys.
+	KeyAliases map[string]string
+
+	// InternKeys deduplicates object keys across decodes, so that documents
+	// with the same keys share their key strings instead of each keeping
+	// its own copies. A Decoder shares keys among the values it decodes;
+	// other decodes share them process-wide. Only the first few thousand
+	// distinct keys are retained.
+	InternKeys bool
+
+	// DuplicateKeys sets how an object with the same key more than once is
+	// decoded. By default, the entry is positioned at the first occurrence
+	// and takes the value of the last.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// Paths, if not empty, lists the JSON Pointers of the only values to
+	// decode, along with the objects and arrays containing them; everything
+	// else is skipped without being materialized. A "*" token matches any
+	// key or array index, e.g. "/users/*/email". Skipped array elements are
+	// decoded as null, so that indices are unchanged.
+	Paths []string
+
+	// MaxDepth, if positive, is the maximum nesting depth of objects and
+	// arrays, counting the outermost as depth 1. Input that exceeds MaxDepth,
+	// or any of the limits below, fails the decode with a *LimitError.
+	//
+	// Regardless of MaxDepth, the depth is limited to 10000 by the
+	// encoding/json tokenizer that values are read with. Deeper input fails
+	// with a *SyntaxError, "exceeded max depth".
+	MaxDepth int
+	// MaxSize, if positive, is the maximum size of the input in bytes. For
+	// a Decoder, it applies to each value read, including the whitespace
+	// before it, and the Decoder never reads further into a value that
+	// exceeds it.
+	MaxSize int
+	// MaxKeys, if positive, is the maximum number of members of each object,
+	// counting duplicate keys separately.
+	MaxKeys int
+	// MaxArrayLen, if positive, is the maximum number of elements of each
+	// array.
+	MaxArrayLen int
+
+	// AllowTrailingData ignores anything after the first JSON value in the
+	// input. By default, anything but whitespace there is an error, as with
+	// json.Unmarshal. It does not apply to Decoder, which reads a stream of
+	// values.
+	AllowTrailingData bool
+
+	// Progress, if set, is called periodically during the decode, and once
+	// at the end of it, with how far the decode has got. If it returns an
+	// error, such as when a deadline has passed, the decode stops and
+	// returns that error.
+	Progress func(DecodeProgress) error
+	// ProgressInterval is the number of values decoded between calls to
+	// Progress. It defaults to 10000.
+	ProgressInterval int
+}
+
+// DuplicateKeyPolicy sets how DecodeOptions handles an object with the same
+// key more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysKeepLast keeps the value of the last occurrence, at the
+	// position of the first.
+	DuplicateKeysKeepLast DuplicateKeyPolicy = iota
+	// DuplicateKeysKeepFirst keeps the value of the first occurrence, and
+	// ignores the others.
+	DuplicateKeysKeepFirst
+	// DuplicateKeysError fails the decode with an error wrapping
+	// ErrDuplicateKey.
+	DuplicateKeysError
+	// DuplicateKeysCollect decodes the values of all the occurrences into an
+	// array, in input order, at the position of the first. Keys that only
+	// occur once are decoded as usual.
+	DuplicateKeysCollect
+)
+
+// ErrDuplicateKey is returned when decoding an object with the same key more
+// than once with DuplicateKeysError.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// LimitError is returned when decoding input that exceeds a limit set in
+// DecodeOptions.
+type LimitError struct {
+	// Limit is the name of the DecodeOptions field, such as "MaxDepth".
+	Limit string
+	// Max is the value of the limit.
+	Max int
+	// Position is where the input exceeded the limit.
+	Position
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("input exceeds %s of %d at line %d, column %d", e.Limit, e.Max, e.Line, e.Column)
+}
+
+// DecodeProgress reports how far a decode has got.
+type DecodeProgress struct {
+	// Bytes is the number of bytes of input consumed.
+	Bytes int64
+	// Values is the number of values decoded, counting each object and array
+	// as well as the values inside them.
+	Values int
+}
+
+// defaultProgressInterval is the default DecodeOptions.ProgressInterval.
+const defaultProgressInterval = 10000
+
+// Unmarshal decodes b into a Value according to opts.
+func (opts DecodeOptions) Unmarshal(b []byte) (Value, error) {
+	var v Value
+	err := newDecodeState(b, opts).decodeAll(&v)
+	return v, err
+}
+
+// DecodeIssue is a problem found while decoding that did not stop the
+// decode, or a conversion made because of the options, such as a duplicate
+// key or a number decoded as a *big.Int.
+type DecodeIssue struct {
+	// Pointer is the JSON Pointer of the value the issue concerns.
+	Pointer string
+	// Position is where the value, or its key, starts in the input.
+	Position
+	Msg string
+}
+
+func (i DecodeIssue) String() string {
+	return fmt.Sprintf("%s: %s at line %d, column %d", i.Pointer, i.Msg, i.Line, i.Column)
+}
+
+// UnmarshalLenient decodes b like Unmarshal, but also reports every issue it
+// recovered from, in input order, rather than only the first error it could
+// not recover from.
+func (opts DecodeOptions) UnmarshalLenient(b []byte) (Value, []DecodeIssue, error) {
+	var v Value
+	d := newDecodeState(b, opts)
+	d.pointers = true
+	d.issues = []issueAt{}
+	err := d.decodeAll(&v)
+	return v, d.report(), err
+}
+
+// decodeState holds the state of a single decode.
+type decodeState struct {
+	dec  *json.Decoder
+	data []byte
+	opts DecodeOptions
+	// keys, if set, interns object keys.
+	keys *keyInterner
+	// lineTracker, if set, locates errors in the input instead of data, when
+	// decoding a stream.
+	lineTracker *lineTracker
+	// started is set once the first token has been read.
+	started bool
+	// depth is the number of objects and arrays being decoded.
+	depth int
+
+	// positions, if set, records the positions of values, keyed by the JSON
+	// Pointer of pointer.
+	positions *positions
+	lines     lineCounter
+	// pointers tracks the JSON Pointer of the value being decoded in
+	// pointer.
+	pointers bool
+	pointer  string
+
+	// issues, if set, collects issues for UnmarshalLenient.
+	issues []issueAt
+
+	// selectPaths holds the parsed DecodeOptions.Paths. While selecting, the
+	// decode is outside every selected value, and path holds the reference
+	// tokens of the value being decoded.
+	selectPaths [][]string
+	selecting   bool
+	path        []string
+
+	// values counts the values decoded, for progress reporting.
+	values int
+}
+
+// newDecodeState returns the state for decoding b. Invalid options are
+// reported when decoding.
+func newDecodeState(b []byte, opts DecodeOptions) *decodeState {
+	d := &decodeState{
+		dec:  json.NewDecoder(bytes.NewReader(b)),
+		data: b,
+		opts: opts,
+	}
+	if opts.UseNumber || opts.BigNumbers || opts.Decimals || opts.Integers {
+		d.dec.UseNumber()
+	}
+	if opts.InternKeys {
+		d.keys = sharedKeys
+	}
+	if opts.Positions {
+		d.positions = &positions{entries: make(map[string]*entryPosition)}
+		d.lines.data = b
+		d.pointers = true
+	}
+	return d
+}
+
+// decodeInto decodes the next JSON value into v.
+func (d *decodeState) decodeInto(v *Value) error {
+	if len(d.opts.Paths) > 0 && d.selectPaths == nil {
+		d.selecting = true
+		for _, p := range d.opts.Paths {
+			tokens, err := parsePointer(p)
+			if err != nil {
+				return err
+			}
+			if len(tokens) == 0 {
+				d.selecting = false
+			}
+			d.selectPaths = append(d.selectPaths, tokens)
+		}
+	}
+	oj, delim, err := d.unmarshal()
+	if delim != 0 {
+		return fmt.Errorf("%w %v, expecting a value", ErrUnexpectedDelimiter, delim)
+	}
+	v.V = oj
+	if err == io.EOF && d.started {
+		// Token returns io.EOF for input that ends inside a value.
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return d.syntaxError(err)
+	}
+	if d.opts.Progress != nil {
+		return d.progress()
+	}
+	return nil
+}
+
+// syntaxError converts a syntax error reported by the json.Decoder into a
+// *SyntaxError, locating it in the input. Other errors are returned as-is.
+func (d *decodeState) syntaxError(err error) error {
+	e, ok := err.(*json.SyntaxError)
+	// The json.Decoder reports the input ending early either way.
+	if err == io.ErrUnexpectedEOF || ok && e.Error() == "unexpected end of JSON input" {
+		return &SyntaxError{Msg: "unexpected end of JSON input", Position: d.position(d.end())}
+	}
+	if !ok {
+		return err
+	}
+	// Offset is just after the offending byte.
+	off := int(e.Offset) - 1
+	if off < 0 {
+		off = 0
+	}
+	return &SyntaxError{Msg: e.Error(), Position: d.position(off)}
+}
+
+// position returns the Position of offset off in the input.
+func (d *decodeState) position(off int) Position {
+	if d.lineTracker != nil {
+		return d.lineTracker.position(off)
+	}
+	return positionOf(d.data, off)
+}
+
+// end returns the offset of the end of the input read so far.
+func (d *decodeState) end() int {
+	if d.lineTracker != nil {
+		return d.lineTracker.off
+	}
+	return len(d.data)
+}
+
+// decodeAll decodes the whole input, which must be a single JSON value
+// unless the options allow trailing data, into v.
+func (d *decodeState) decodeAll(v *Value) error {
+	if max := d.opts.MaxSize; max > 0 && len(d.data) > max {
+		return d.limitError("MaxSize", max, max)
+	}
+	if err := d.decodeInto(v); err == io.EOF {
+		return d.syntaxError(io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return err
+	}
+	if d.opts.AllowTrailingData {
+		return nil
+	}
+	if i := skipSpace(d.data, int(d.dec.InputOffset())); i < len(d.data) {
+		return &SyntaxError{
+			Msg:      fmt.Sprintf("invalid character %q after top-level value", d.data[i]),
+			Position: positionOf(d.data, i),
+		}
+	}
+	return nil
+}
+
+// enter records that an object or array, whose opening delimiter was just
+// read, is being decoded, checking that it isn't nested too deeply.
+func (d *decodeState) enter() error {
+	d.depth++
+	if d.opts.MaxDepth > 0 && d.depth > d.opts.MaxDepth {
+		d.depth--
+		return d.limitError("MaxDepth", d.opts.MaxDepth, int(d.dec.InputOffset())-1)
+	}
+	return nil
+}
+
+func (d *decodeState) limitError(limit string, max, off int) error {
+	return &LimitError{Limit: limit, Max: max, Position: d.position(off)}
+}
+
+// countValue counts a decoded value, reporting progress if it is due.
+func (d *decodeState) countValue() error {
+	d.values++
+	interval := d.opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	if d.values%interval != 0 {
+		return nil
+	}
+	return d.progress()
+}
+
+func (d *decodeState) progress() error {
+	return d.opts.Progress(DecodeProgress{Bytes: d.dec.InputOffset(), Values: d.values})
+}
+
+// key returns the object key k, renamed if it is an alias and interned if the
+// decode shares keys.
+func (d *decodeState) key(k string) string {
+	if canonical, ok := d.opts.KeyAliases[k]; ok {
+		k = canonical
+	}
+	if d.keys == nil {
+		return k
+	}
+	return d.keys.intern(k)
+}
+
+// selection reports whether d.path is within a selected value, or is a
+// proper prefix of one.
+func (d *decodeState) selection() (selected, partial bool) {
+	for _, p := range d.selectPaths {
+		if len(p) <= len(d.path) && matchPath(p, d.path[:len(p)]) {
+			return true, false
+		}
+		if len(p) > len(d.path) && matchPath(p[:len(d.path)], d.path) {
+			partial = true
+		}
+	}
+	return false, partial
+}
+
+// issueAt is a DecodeIssue before its Position is known.
+type issueAt struct {
+	off   int
+	issue DecodeIssue
+}
+
+// issue records an issue with the value or key at the token that was just
+// read, given the decoder's input offset before reading it.
+func (d *decodeState) issue(start int64, format string, args ...interface{}) {
+	d.issues = append(d.issues, issueAt{
+		off:   d.tokenStart(start),
+		issue: DecodeIssue{Pointer: d.pointer, Msg: fmt.Sprintf(format, args...)},
+	})
+}
+
+// report returns the recorded issues, in input order. Issues are recorded
+// out of order, since an object's duplicate key is only found once the
+// values before it have been decoded.
+func (d *decodeState) report() []DecodeIssue {
+	sort.SliceStable(d.issues, func(i, j int) bool {
+		return d.issues[i].off < d.issues[j].off
+	})
+	lines := lineCounter{data: d.data}
+	issues := make([]DecodeIssue, len(d.issues))
+	for i, in := range d.issues {
+		issues[i] = in.issue
+		issues[i].Position = lines.position(in.off)
+	}
+	return issues
+}
+
+// maxExactFloat is the largest integer magnitude up to which every integer
+// can be represented exactly by a float64.
+const maxExactFloat = 1 << 53
+
+// number converts a number token according to the options. It is only
+// called when the options require the decoder to return json.Number.
+func (d *decodeState) number(n json.Number) (interface{}, error) {
+	if d.opts.UseNumber {
+		return n, nil
+	}
+	s := n.String()
+	if d.opts.Decimals {
+		return decimal.NewFromString(s)
+	}
+	// -0 is left as a float64, which keeps its sign.
+	if d.opts.Integers && !strings.ContainsAny(s, ".eE") && s != "-0" {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	if d.opts.BigNumbers {
+		if !strings.ContainsAny(s, ".eE") {
+			i, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid number %q", s)
+			}
+			if i.IsInt64() && -maxExactFloat <= i.Int64() && i.Int64() <= maxExactFloat {
+				return float64(i.Int64()), nil
+			}
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil && (f != 0 || isZeroLiteral(s)) {
+			return f, nil
+		}
+		// Give the mantissa enough bits for every digit of the literal.
+		bf, _, err := new(big.Float).SetPrec(uint(len(s))*4).Parse(s, 10)
+		return bf, err
+	}
+	return n.Float64()
+}
+
+// isZeroLiteral reports whether the number literal s is zero, as opposed to
+// a nonzero number that underflows a float64.
+func isZeroLiteral(s string) bool {
+	for _, c := range s {
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if '1' <= c && c <= '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// time parses s as an RFC 3339 timestamp.
+func (d *decodeState) time(s string) (time.Time, bool) {
+	// Cheaply rule out most strings before trying to parse them.
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if d.opts.TimeLocation != nil {
+		t = t.In(d.opts.TimeLocation)
+	}
+	return t, true
+}
+
+// rawToken returns the source bytes of the token that was just read, given
+// the decoder's input offset before reading it.
+func (d *decodeState) rawToken(start int64) string {
+	return string(d.data[d.tokenStart(start):d.dec.InputOffset()])
+}
+
+// tokenStart returns the offset of the token that was just read, given the
+// decoder's input offset before reading it. The offset may precede the token
+// by whitespace and a ',' or ':' separator.
+func (d *decodeState) tokenStart(start int64) int {
+	i := int(start)
+	for i < len(d.data) && (isSpace(d.data[i]) || d.data[i] == ',' || d.data[i] == ':') {
+		i++
+	}
+	return i
+}
+
+// recordValue records that the value at d.pointer starts at the token that
+// was just read.
+func (d *decodeState) recordValue(start int64) {
+	e := d.positions.entries[d.pointer]
+	if e == nil {
+		e = &entryPosition{}
+		d.positions.entries[d.pointer] = e
+	}
+	e.value = d.lines.position(d.tokenStart(start))
+}
+
+// recordKey records that the key of the member at d.pointer starts at the
+// token that was just read.
+func (d *decodeState) recordKey(start int64) {
+	d.positions.entries[d.pointer] = &entryPosition{
+		key:    d.lines.position(d.tokenStart(start)),
+		hasKey: true,
+	}
+}
+
+// RawString is a JSON string that remembers how it was encoded in its
+// source. It is produced when decoding with
+// DecodeOptions.PreserveStringEscapes, and marshals back to exactly its
+// original bytes.
+type RawString struct {
+	// Raw is the quoted string, including any escape sequences, as it
+	// appeared in the source.
+	Raw string
+}
+
+var _ json.Marshaler = RawString{}
+
+// String returns the decoded value of the string.
+func (s RawString) String() string {
+	var str string
+	_ = json.Unmarshal([]byte(s.Raw), &str)
+	return str
+}
+
+// MarshalJSON returns s.Raw. It returns an error if s.Raw is not a quoted
+// JSON string, as is the case for the zero RawString.
+func (s RawString) MarshalJSON() ([]byte, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	return []byte(s.Raw), nil
+}
+
+// check returns an error if s.Raw is not a quoted JSON string.
+func (s RawString) check() error {
+	if len(s.Raw) > 0 && s.Raw[0] == '"' {
+		if end, err := scanString([]byte(s.Raw), 0); err == nil && end == len(s.Raw) {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot marshal RawString %q, which is not a quoted JSON string", s.Raw)
+}
@@ -0,0 +1,597 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreserveStringEscapes(tt *testing.T) {
+	for _, in := range []string{
+		`"caf\u00e9"`,
+		`{"a": "caf\u00e9", "b" : ["\/x", "<tag>", "plain"]}`,
+		`["\ud83d\ude00", "tab\there"]`,
+		`{"caf\u00e9": 1, "\/k": {"\u0041": "x", "b": 2}}`,
+	} {
+		tt.Run(in, func(t *testing.T) {
+			require := require.New(t)
+			v, err := DecodeOptions{PreserveStringEscapes: true}.Unmarshal([]byte(in))
+			require.NoError(err)
+			b, err := v.MarshalJSON()
+			require.NoError(err)
+			// Strings are preserved exactly; only insignificant whitespace
+			// changes.
+			require.Equal(compact(t, in), string(b))
+		})
+	}
+
+	tt.Run("decoded value", func(t *testing.T) {
+		require := require.New(t)
+		v, err := DecodeOptions{PreserveStringEscapes: true}.Unmarshal([]byte(`{"a":"caf\u00e9"}`))
+		require.NoError(err)
+		s, ok := v.V.(*Object).Get("a")
+		require.True(ok)
+		require.Equal(RawString{Raw: `"caf\u00e9"`}, s)
+		require.Equal("café", s.(RawString).String())
+	})
+
+	tt.Run("edited keys", func(t *testing.T) {
+		require := require.New(t)
+		v, err := DecodeOptions{PreserveStringEscapes: true}.Unmarshal([]byte(`{"\u0061":1,"\u0062":2}`))
+		require.NoError(err)
+		obj := v.V.(*Object)
+		obj.Delete("a")
+		obj.Set("a", 3)
+		b, err := v.MarshalJSON()
+		require.NoError(err)
+		require.Equal(`{"\u0062":2,"a":3}`, string(b))
+	})
+
+	tt.Run("invalid raw string", func(t *testing.T) {
+		require := require.New(t)
+		for _, s := range []RawString{{}, {Raw: `"a`}, {Raw: `"a"b"`}, {Raw: `1`}, {Raw: "\"\n\""}} {
+			_, err := Marshal([]interface{}{s, 1})
+			require.Error(err, s.Raw)
+			_, err = s.MarshalJSON()
+			require.Error(err, s.Raw)
+		}
+	})
+}
+
+// compact strips insignificant whitespace from JSON without otherwise
+// changing it.
+func compact(t *testing.T, s string) string {
+	var b []byte
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString && c == '\\':
+			b = append(b, c, s[i+1])
+			i++
+			continue
+		case c == '"':
+			inString = !inString
+		case !inString && isSpace(c):
+			continue
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func TestBigNumbers(tt *testing.T) {
+	for _, test := range []struct {
+		in        string
+		out       interface{}
+		marshaled string
+	}{
+		{in: `1`, out: 1.0, marshaled: `1`},
+		{in: `-9007199254740992`, out: -9007199254740992.0, marshaled: `-9007199254740992`},
+		{in: `1.5e3`, out: 1500.0, marshaled: `1500`},
+		{in: `0.0e-5000`, out: 0.0, marshaled: `0`},
+		{in: `12345678901234567890123`, out: mustBigInt("12345678901234567890123"), marshaled: `12345678901234567890123`},
+		{in: `1e400`, out: mustBigFloat("1e400"), marshaled: `1e+400`},
+		{in: `-2.5e-400`, out: mustBigFloat("-2.5e-400"), marshaled: `-2.5e-400`},
+	} {
+		tt.Run(test.in, func(t *testing.T) {
+			require := require.New(t)
+			v, err := DecodeOptions{BigNumbers: true}.Unmarshal([]byte(test.in))
+			require.NoError(err)
+			switch out := test.out.(type) {
+			case *big.Float:
+				require.IsType(out, v.V)
+				require.Zero(out.Cmp(v.V.(*big.Float)))
+			default:
+				require.Equal(test.out, v.V)
+			}
+
+			// Big numbers marshal with full precision.
+			b, err := v.MarshalJSON()
+			require.NoError(err)
+			require.Equal(test.marshaled, string(b))
+		})
+	}
+}
+
+func mustBigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
+
+func mustBigFloat(s string) *big.Float {
+	f, _, _ := new(big.Float).SetPrec(uint(len(s))*4).Parse(s, 10)
+	return f
+}
+
+func TestDecimals(tt *testing.T) {
+	require := require.New(tt)
+	in := `{"price":19.99,"total":0.30000000000000000001,"qty":3}`
+	v, err := DecodeOptions{Decimals: true}.Unmarshal([]byte(in))
+	require.NoError(err)
+
+	price, _ := v.V.(*Object).Get("price")
+	require.True(decimal.RequireFromString("19.99").Equal(price.(decimal.Decimal)))
+
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(in, string(b))
+
+	o := NewObject().
+		SetAndReturn("a", decimal.New(15, -1)).
+		SetAndReturn("b", decimal.NullDecimal{}).
+		SetAndReturn("c", &decimal.Zero)
+	require.NoError(Validate(o))
+	b, err = o.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"a":1.5,"b":null,"c":0}`, string(b))
+}
+
+func TestUseNumber(tt *testing.T) {
+	require := require.New(tt)
+	in := `{"id":12345678901234567890,"f":1.50,"e":1e400,"n":[-0]}`
+	v, err := DecodeOptions{UseNumber: true, Decimals: true}.Unmarshal([]byte(in))
+	require.NoError(err)
+
+	id, _ := v.V.(*Object).Get("id")
+	require.Equal(json.Number("12345678901234567890"), id)
+
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(in, string(b))
+
+	dec := NewDecoder(strings.NewReader(in))
+	dec.UseNumber()
+	require.NoError(dec.Decode(&v))
+	b, err = v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(in, string(b))
+}
+
+func TestIntegers(tt *testing.T) {
+	require := require.New(tt)
+	in := `[9007199254740993,-42,0,-0,1.0,1e3,2.5,9223372036854775808]`
+	v, err := DecodeOptions{Integers: true}.Unmarshal([]byte(in))
+	require.NoError(err)
+	require.Equal([]interface{}{
+		int64(9007199254740993), int64(-42), int64(0), math.Copysign(0, -1),
+		1.0, 1000.0, 2.5, 9223372036854775808.0,
+	}, v.V)
+
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`[9007199254740993,-42,0,-0,1,1000,2.5,9223372036854776000]`, string(b))
+
+	v, err = DecodeOptions{Integers: true, BigNumbers: true}.Unmarshal([]byte(`[1,9223372036854775808]`))
+	require.NoError(err)
+	require.Equal([]interface{}{int64(1), mustBigInt("9223372036854775808")}, v.V)
+}
+
+func TestDuplicateKeys(tt *testing.T) {
+	const in = `{"a":1,"b":{"x":1},"a":[2],"c":3,"a":{"y":3}}`
+	for _, test := range []struct {
+		policy DuplicateKeyPolicy
+		want   string
+	}{
+		{DuplicateKeysKeepLast, `{"a":{"y":3},"b":{"x":1},"c":3}`},
+		{DuplicateKeysKeepFirst, `{"a":1,"b":{"x":1},"c":3}`},
+		{DuplicateKeysCollect, `{"a":[1,[2],{"y":3}],"b":{"x":1},"c":3}`},
+	} {
+		tt.Run(test.want, func(t *testing.T) {
+			require := require.New(t)
+			opts := DecodeOptions{DuplicateKeys: test.policy, Positions: true}
+			v, err := opts.Unmarshal([]byte(in))
+			require.NoError(err)
+			b, err := v.MarshalJSON()
+			require.NoError(err)
+			require.Equal(test.want, string(b))
+
+			v, issues, err := opts.UnmarshalLenient([]byte(in))
+			require.NoError(err)
+			require.Len(issues, 2)
+		})
+	}
+
+	tt.Run("keep first positions", func(t *testing.T) {
+		require := require.New(t)
+		v, err := DecodeOptions{DuplicateKeys: DuplicateKeysKeepFirst, Positions: true}.Unmarshal([]byte(in))
+		require.NoError(err)
+		p, ok := v.V.(*Object).Position("/a")
+		require.True(ok)
+		require.Equal(6, p.Column)
+	})
+
+	tt.Run("error", func(t *testing.T) {
+		require := require.New(t)
+		_, err := DecodeOptions{DuplicateKeys: DuplicateKeysError}.Unmarshal([]byte(`{"x":{"a":1,"a":2}}`))
+		require.True(errors.Is(err, ErrDuplicateKey))
+		require.Contains(err.Error(), `"a"`)
+
+		_, err = DecodeOptions{DuplicateKeys: DuplicateKeysError}.Unmarshal([]byte(`{"a":{"a":1}}`))
+		require.NoError(err)
+	})
+}
+
+func TestKeyAliases(tt *testing.T) {
+	require := require.New(tt)
+	opts := DecodeOptions{
+		KeyAliases: map[string]string{"userId": "user_id", "uid": "user_id"},
+		Positions:  true,
+	}
+	v, err := opts.Unmarshal([]byte(`{"name":"a","userId":1,"items":[{"uid":2}],"uid":3}`))
+	require.NoError(err)
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"name":"a","user_id":3,"items":[{"user_id":2}]}`, string(b))
+
+	pos, ok := v.V.(*Object).KeyPosition("/items/0/user_id")
+	require.True(ok)
+	require.Equal(Position{Offset: 33, Line: 1, Column: 34}, pos)
+}
+
+func TestInternKeys(tt *testing.T) {
+	firstKey := func(v Value) string {
+		k, _ := v.V.(*Object).GetAt(0)
+		return k
+	}
+
+	tt.Run("unmarshal", func(t *testing.T) {
+		require := require.New(t)
+		opts := DecodeOptions{InternKeys: true}
+		a, err := opts.Unmarshal([]byte(`{"interned":1}`))
+		require.NoError(err)
+		b, err := opts.Unmarshal([]byte(`{"interned":2}`))
+		require.NoError(err)
+		require.Equal("interned", firstKey(a))
+		require.True(stringData(firstKey(a)) == stringData(firstKey(b)))
+
+		c, err := DecodeOptions{}.Unmarshal([]byte(`{"interned":3}`))
+		require.NoError(err)
+		require.False(stringData(firstKey(a)) == stringData(firstKey(c)))
+	})
+
+	tt.Run("decoder", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader(`{"name":1} {"name":2}`))
+		dec.Options.InternKeys = true
+		var a, b Value
+		require.NoError(dec.Decode(&a))
+		require.NoError(dec.Decode(&b))
+		require.Equal("name", firstKey(a))
+		require.True(stringData(firstKey(a)) == stringData(firstKey(b)))
+	})
+}
+
+func TestDecodeProgress(tt *testing.T) {
+	const in = `{"a":[1,2,3],"b":{"c":"d"},"e":null}`
+
+	tt.Run("periodic", func(t *testing.T) {
+		require := require.New(t)
+		var calls []DecodeProgress
+		_, err := DecodeOptions{
+			ProgressInterval: 3,
+			Progress: func(p DecodeProgress) error {
+				calls = append(calls, p)
+				return nil
+			},
+		}.Unmarshal([]byte(in))
+		require.NoError(err)
+		require.Equal([]DecodeProgress{
+			{Bytes: 7, Values: 3},
+			{Bytes: 18, Values: 6},
+			{Bytes: int64(len(in)), Values: 8},
+		}, calls)
+	})
+
+	tt.Run("abort", func(t *testing.T) {
+		require := require.New(t)
+		abort := errors.New("too slow")
+		_, err := DecodeOptions{
+			ProgressInterval: 2,
+			Progress: func(p DecodeProgress) error {
+				return abort
+			},
+		}.Unmarshal([]byte(in))
+		require.Equal(abort, err)
+	})
+}
+
+func TestUnmarshalLenient(tt *testing.T) {
+	require := require.New(tt)
+	opts := DecodeOptions{
+		BigNumbers: true,
+		Times:      true,
+		KeyAliases: map[string]string{"uid": "id"},
+	}
+	in := `{
+  "id": 1,
+  "items": [{"n": 12345678901234567890, "n": 2}],
+  "uid": 3,
+  "at": "2021-01-02T03:04:05Z"
+}`
+	v, issues, err := opts.UnmarshalLenient([]byte(in))
+	require.NoError(err)
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"id":3,"items":[{"n":2}],"at":"2021-01-02T03:04:05Z"}`, string(b))
+
+	var msgs []string
+	for _, issue := range issues {
+		msgs = append(msgs, issue.String())
+	}
+	require.Equal([]string{
+		`/items/0/n: number 12345678901234567890 can't be represented by a float64, decoded as *big.Int at line 3, column 19`,
+		`/items/0/n: duplicate key "n", keeping the last value at line 3, column 41`,
+		`/id: renamed key "uid" to "id" at line 4, column 3`,
+		`/id: duplicate key "id", keeping the last value at line 4, column 3`,
+		`/at: decoded string as a time at line 5, column 9`,
+	}, msgs)
+
+	_, issues, err = DecodeOptions{}.UnmarshalLenient([]byte(`{"a":1}`))
+	require.NoError(err)
+	require.Empty(issues)
+
+	_, _, err = DecodeOptions{}.UnmarshalLenient([]byte(`{"a":}`))
+	require.Error(err)
+}
+
+func TestDecodePaths(tt *testing.T) {
+	const in = `{"id":1,"user":{"name":"a","email":"a@example.com","tags":["x"]},"items":[{"sku":"s1","qty":1},{"sku":"s2"},3],"blob":{"big":[1,2,3]}}`
+	for _, test := range []struct {
+		name  string
+		paths []string
+		out   string
+	}{
+		{
+			name:  "members",
+			paths: []string{"/id", "/user/email"},
+			out:   `{"id":1,"user":{"email":"a@example.com"}}`,
+		},
+		{
+			name:  "subtree",
+			paths: []string{"/user"},
+			out:   `{"user":{"name":"a","email":"a@example.com","tags":["x"]}}`,
+		},
+		{
+			name:  "wildcard",
+			paths: []string{"/items/*/qty"},
+			out:   `{"items":[{"qty":1},{},null]}`,
+		},
+		{
+			name:  "index",
+			paths: []string{"/items/1"},
+			out:   `{"items":[null,{"sku":"s2"},null]}`,
+		},
+		{
+			name:  "root",
+			paths: []string{"/id", ""},
+			out:   in,
+		},
+		{
+			name:  "missing",
+			paths: []string{"/nope/x"},
+			out:   `{}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v, err := DecodeOptions{Paths: test.paths}.Unmarshal([]byte(in))
+			require.NoError(err)
+			b, err := v.MarshalJSON()
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("invalid", func(t *testing.T) {
+		_, err := DecodeOptions{Paths: []string{"id"}}.Unmarshal([]byte(in))
+		require.Error(t, err)
+		_, err = DecodeOptions{Paths: []string{"/id"}}.Unmarshal([]byte(`{"a":[1,}`))
+		require.Error(t, err)
+	})
+}
+
+func TestDecodeSyntaxError(tt *testing.T) {
+	for _, test := range []struct {
+		in     string
+		msg    string
+		offset int
+		line   int
+		column int
+	}{
+		{"{\"a\":\n  x}", "invalid character 'x' looking for beginning of value", 8, 2, 3},
+		{"[1,\n2 3]", "invalid character '3' after array element", 6, 2, 3},
+		{"{\"a\":[1,", "unexpected end of JSON input", 8, 1, 9},
+		{"", "unexpected end of JSON input", 0, 1, 1},
+		{"tru", "unexpected end of JSON input", 3, 1, 4},
+		{"{}\n\n x", "invalid character 'x' after top-level value", 5, 3, 2},
+	} {
+		tt.Run(test.in, func(t *testing.T) {
+			require := require.New(t)
+			_, err := NewValueFromJSON(test.in)
+			var syntaxErr *SyntaxError
+			require.True(errors.As(err, &syntaxErr), "%v", err)
+			require.Equal(test.msg, syntaxErr.Msg)
+			require.Equal(Position{Offset: test.offset, Line: test.line, Column: test.column}, syntaxErr.Position)
+		})
+	}
+
+	tt.Run("stream", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader("{\"a\":1}\n[\n  1,\n  2\n]\n\n{\"b\":\n  ]}"))
+		var v Value
+		require.NoError(dec.Decode(&v))
+		require.NoError(dec.Decode(&v))
+		err := dec.Decode(&v)
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 30, Line: 8, Column: 3}, syntaxErr.Position)
+		require.Equal("invalid character ']' after object key:value pair at line 8, column 3", err.Error())
+
+		dec = NewDecoder(strings.NewReader(`{"a":`))
+		err = dec.Decode(&v)
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(5, syntaxErr.Offset)
+
+		dec = NewDecoder(strings.NewReader(" \n"))
+		require.Equal(io.EOF, dec.Decode(&v))
+	})
+}
+
+func TestMaxDepth(tt *testing.T) {
+	require := require.New(tt)
+	opts := DecodeOptions{MaxDepth: 3}
+
+	_, err := opts.Unmarshal([]byte(`{"a":[{"b":1}],"c":[[2]]}`))
+	require.NoError(err)
+
+	_, err = opts.Unmarshal([]byte(`{"a":[{"b":1}],"c":[[[2]]]}`))
+	var limitErr *LimitError
+	require.True(errors.As(err, &limitErr), "%v", err)
+	require.Equal(&LimitError{Limit: "MaxDepth", Max: 3, Position: Position{Offset: 21, Line: 1, Column: 22}}, limitErr)
+	require.Equal("input exceeds MaxDepth of 3 at line 1, column 22", err.Error())
+
+	// The depth of each value in a stream is counted separately.
+	dec := NewDecoder(strings.NewReader(`[[1]] [[2]] [[[3]]]`))
+	dec.Options.MaxDepth = 2
+	var v Value
+	require.NoError(dec.Decode(&v))
+	require.NoError(dec.Decode(&v))
+	require.True(errors.As(dec.Decode(&v), &limitErr))
+	require.Equal(14, limitErr.Offset)
+
+	deep := strings.Repeat("[", 5000) + strings.Repeat("]", 5000)
+	_, err = DecodeOptions{MaxDepth: 100}.Unmarshal([]byte(deep))
+	require.True(errors.As(err, &limitErr))
+	_, err = NewValueFromJSON(deep)
+	require.NoError(err)
+}
+
+func TestDecodeLimits(tt *testing.T) {
+	limitError := func(t *testing.T, err error) *LimitError {
+		var limitErr *LimitError
+		require.True(t, errors.As(err, &limitErr), "%v", err)
+		return limitErr
+	}
+
+	tt.Run("keys", func(t *testing.T) {
+		require := require.New(t)
+		opts := DecodeOptions{MaxKeys: 2}
+		_, err := opts.Unmarshal([]byte(`{"a":{"x":1,"y":2},"b":{}}`))
+		require.NoError(err)
+
+		_, err = opts.Unmarshal([]byte(`{"a":{"x":1,"y":2,"z":3},"b":{}}`))
+		require.Equal(&LimitError{Limit: "MaxKeys", Max: 2, Position: Position{Offset: 18, Line: 1, Column: 19}}, limitError(t, err))
+
+		// Duplicate keys count.
+		_, err = opts.Unmarshal([]byte(`{"a":1,"a":2,"a":3}`))
+		limitError(t, err)
+	})
+
+	tt.Run("array length", func(t *testing.T) {
+		require := require.New(t)
+		opts := DecodeOptions{MaxArrayLen: 2}
+		_, err := opts.Unmarshal([]byte(`[[1,2],[]]`))
+		require.NoError(err)
+
+		_, err = opts.Unmarshal([]byte(`[[1,2], [3, 4, 5]]`))
+		require.Equal(&LimitError{Limit: "MaxArrayLen", Max: 2, Position: Position{Offset: 15, Line: 1, Column: 16}}, limitError(t, err))
+
+		opts.Paths = []string{"/0"}
+		_, err = opts.Unmarshal([]byte(`[1,2,3]`))
+		limitError(t, err)
+	})
+
+	tt.Run("size", func(t *testing.T) {
+		require := require.New(t)
+		opts := DecodeOptions{MaxSize: 8}
+		_, err := opts.Unmarshal([]byte(`{"a":12}`))
+		require.NoError(err)
+		_, err = opts.Unmarshal([]byte(`{"a":123}`))
+		require.Equal(8, limitError(t, err).Offset)
+
+		dec := NewDecoder(strings.NewReader(`{"a":12} 12345678 "12345678" [1,2,3]`))
+		dec.Options.MaxSize = 9
+		var v Value
+		require.NoError(dec.Decode(&v))
+		require.NoError(dec.Decode(&v))
+		err = dec.Decode(&v)
+		require.Equal(&LimitError{Limit: "MaxSize", Max: 9, Position: Position{Offset: 26, Line: 1, Column: 27}}, limitError(t, err))
+
+		// The Decoder doesn't read much past the limit.
+		r := &countingReader{r: strings.NewReader(`"` + strings.Repeat("x", 100000) + `"`)}
+		dec = NewDecoder(r)
+		dec.Options.MaxSize = 10
+		limitError(t, dec.Decode(&v))
+		require.LessOrEqual(r.n, 11)
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += n
+	return n, err
+}
+
+func TestDecodeDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 9999
+	in := strings.Repeat(`{"a":[`, depth/2) + `1` + strings.Repeat(`]}`, depth/2)
+	v, err := DecodeOptions{Positions: true, MaxDepth: depth}.Unmarshal([]byte(in))
+	require.NoError(err)
+
+	var inner interface{} = v.V
+	for i := 0; i < depth/2; i++ {
+		a, ok := inner.(*Object).Get("a")
+		require.True(ok)
+		inner = a.([]interface{})[0]
+	}
+	require.Equal(1.0, inner)
+
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(in, string(b))
+
+	// encoding/json's tokenizer caps the depth at 10000, whatever MaxDepth
+	// is.
+	_, err = DecodeOptions{MaxDepth: 20000}.Unmarshal([]byte(strings.Repeat("[", 10000) + strings.Repeat("]", 10000)))
+	require.NoError(err)
+	for _, opts := range []DecodeOptions{{}, {MaxDepth: 20000}} {
+		_, err = opts.Unmarshal([]byte(strings.Repeat("[", 10001) + strings.Repeat("]", 10001)))
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal("exceeded max depth at line 1, column 10001", err.Error())
+	}
+}
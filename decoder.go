@@ -0,0 +1,174 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decoder reads and decodes JSON values from an input stream, keeping the
+// order of object keys.
+type Decoder struct {
+	// Options configures how values are decoded by Decode. The
+	// PreserveStringEscapes and Positions options need the whole input, and
+	// aren't supported.
+	Options DecodeOptions
+
+	dec   *json.Decoder
+	lines *lineTracker
+	// keys interns keys when Options.InternKeys is set.
+	keys *keyInterner
+}
+
+// NewDecoder returns a Decoder that reads from r. It may read more data from
+// r than the JSON values it decodes.
+func NewDecoder(r io.Reader) *Decoder {
+	lines := &lineTracker{r: r}
+	return &Decoder{dec: json.NewDecoder(lines), lines: lines}
+}
+
+// Decode reads the next JSON value from the input and stores it in v. It
+// returns io.EOF if there are no more values, and a *SyntaxError if the
+// input is malformed.
+func (d *Decoder) Decode(v *Value) error {
+	if d.Options.PreserveStringEscapes || d.Options.Positions {
+		return errors.New("PreserveStringEscapes and Positions are not supported by Decoder")
+	}
+	if d.Options.UseNumber || d.Options.BigNumbers || d.Options.Decimals || d.Options.Integers {
+		// This also makes Token return numbers as json.Number.
+		d.dec.UseNumber()
+	}
+	start := int(d.dec.InputOffset())
+	d.lines.discard(start)
+	s := &decodeState{dec: d.dec, opts: d.Options, lineTracker: d.lines}
+	if d.Options.InternKeys {
+		if d.keys == nil {
+			d.keys = newKeyInterner()
+		}
+		s.keys = d.keys
+	}
+	max := d.Options.MaxSize
+	if max <= 0 {
+		return s.decodeInto(v)
+	}
+	// Let the decoder read one byte past the limit, which it may need to
+	// find the end of a number, and check the exact size afterwards.
+	d.lines.limit, d.lines.maxSize = start+max+1, max
+	defer func() { d.lines.limit = 0 }()
+	if err := s.decodeInto(v); err != nil {
+		return err
+	}
+	if int(d.dec.InputOffset())-start > max {
+		return s.limitError("MaxSize", max, start+max)
+	}
+	return nil
+}
+
+// UseNumber makes the Decoder decode numbers as json.Number, as with
+// DecodeOptions.UseNumber.
+func (d *Decoder) UseNumber() {
+	d.Options.UseNumber = true
+}
+
+// Token returns the next JSON token in the input, as json.Decoder.Token
+// does. Mixing Token with Decode and Skip allows walking into a large
+// document and only decoding the parts of it that are needed.
+func (d *Decoder) Token() (json.Token, error) {
+	return d.dec.Token()
+}
+
+// More reports whether there is another element in the current array or
+// object being read with Token, or, outside of any, whether there is another
+// value in the input.
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
+
+// Skip reads and discards the next JSON value, whether an object, array or
+// scalar, a token at a time, without building anything.
+func (d *Decoder) Skip() error {
+	return skipValue(d.dec)
+}
+
+// skipValue reads the next value from dec a token at a time, without
+// keeping it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := t.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else if depth--; depth < 0 {
+				return fmt.Errorf("%w %v, expecting a value", ErrUnexpectedDelimiter, d)
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// lineTracker records where the lines of a stream start as it is read, so
+// that offsets in it can be converted to Positions.
+type lineTracker struct {
+	r io.Reader
+	// off is the number of bytes read.
+	off int
+	// line and lineStart are the line number and starting offset of the line
+	// before the first of newlines.
+	line, lineStart int
+	// newlines holds the offsets of the newlines read since the last
+	// discard.
+	newlines []int
+	// limit, if positive, is the offset past which Read fails with a
+	// *LimitError for DecodeOptions.MaxSize, which is maxSize.
+	limit, maxSize int
+}
+
+func (t *lineTracker) Read(b []byte) (int, error) {
+	if t.limit > 0 {
+		if t.off >= t.limit {
+			return 0, &LimitError{Limit: "MaxSize", Max: t.maxSize, Position: t.position(t.limit - 1)}
+		}
+		if len(b) > t.limit-t.off {
+			b = b[:t.limit-t.off]
+		}
+	}
+	n, err := t.r.Read(b)
+	for i, c := range b[:n] {
+		if c == '\n' {
+			t.newlines = append(t.newlines, t.off+i)
+		}
+	}
+	t.off += n
+	return n, err
+}
+
+// discard forgets the newlines before off, which is no longer needed.
+func (t *lineTracker) discard(off int) {
+	i := 0
+	for ; i < len(t.newlines) && t.newlines[i] < off; i++ {
+		t.line++
+		t.lineStart = t.newlines[i] + 1
+	}
+	t.newlines = append(t.newlines[:0], t.newlines[i:]...)
+}
+
+// position returns the Position of offset off, which must not be before
+// the last offset passed to discard.
+func (t *lineTracker) position(off int) Position {
+	line, lineStart := t.line, t.lineStart
+	for _, nl := range t.newlines {
+		if nl >= off {
+			break
+		}
+		line++
+		lineStart = nl + 1
+	}
+	return Position{Offset: off, Line: line + 1, Column: off - lineStart + 1}
+}
@@ -0,0 +1,94 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(tt *testing.T) {
+	tt.Run("options", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader(`{"userId":123456789012345678901234567890,"x":1}
+{"uid":1.5}
+`))
+		dec.Options = DecodeOptions{
+			BigNumbers: true,
+			KeyAliases: map[string]string{"uid": "userId"},
+		}
+		var out []string
+		for dec.More() {
+			var v Value
+			require.NoError(dec.Decode(&v))
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			out = append(out, string(b))
+		}
+		require.Equal([]string{
+			`{"userId":123456789012345678901234567890,"x":1}`,
+			`{"userId":1.5}`,
+		}, out)
+
+		dec = NewDecoder(strings.NewReader(`"x"`))
+		dec.Options.Positions = true
+		var v Value
+		require.Error(dec.Decode(&v))
+	})
+
+	tt.Run("stream", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader(`{"b":1,"a":2} [3] "x"`))
+		var out []string
+		for {
+			var v Value
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(err)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			out = append(out, string(b))
+		}
+		require.Equal([]string{`{"b":1,"a":2}`, `[3]`, `"x"`}, out)
+	})
+
+	tt.Run("skip", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader(`[{"skip":[1,{"x":[]}]}, "also", 3, {"keep":{"b":1,"a":2}}, null]`))
+		tok, err := dec.Token()
+		require.NoError(err)
+		require.Equal(json.Delim('['), tok)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(dec.Skip())
+		}
+		var v Value
+		require.NoError(dec.Decode(&v))
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"keep":{"b":1,"a":2}}`, string(b))
+
+		require.True(dec.More())
+		require.NoError(dec.Skip())
+		require.False(dec.More())
+		tok, err = dec.Token()
+		require.NoError(err)
+		require.Equal(json.Delim(']'), tok)
+		require.Equal(io.EOF, dec.Skip())
+	})
+
+	tt.Run("skip errors", func(t *testing.T) {
+		require := require.New(t)
+		dec := NewDecoder(strings.NewReader(`[]`))
+		_, err := dec.Token()
+		require.NoError(err)
+		require.True(errors.Is(dec.Skip(), ErrUnexpectedDelimiter))
+
+		require.Error(NewDecoder(strings.NewReader(`[1,}`)).Skip())
+	})
+}
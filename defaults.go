@@ -0,0 +1,21 @@
+package ojson
+
+// ApplyDefaultsObject fills in the keys of base that are missing from it with
+// copies of their values in defaults, in place. Missing keys are appended in
+// the order they appear in defaults. Existing values are never overwritten,
+// but where both base and defaults have an object for the same key, the
+// defaults are applied to it recursively. Arrays are not merged.
+func ApplyDefaultsObject(base, defaults *Object) {
+	for _, kv := range defaults.entries {
+		dv := kv.Value
+		bv, ok := base.Get(kv.Key)
+		if !ok {
+			base.Set(kv.Key, copyValue(dv))
+			continue
+		}
+		bo, ok := bv.(*Object)
+		if do, isObj := dv.(*Object); ok && isObj && bo != nil && do != nil {
+			ApplyDefaultsObject(bo, do)
+		}
+	}
+}
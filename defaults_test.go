@@ -0,0 +1,59 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDefaultsObject(tt *testing.T) {
+	for _, test := range []struct {
+		name     string
+		base     string
+		defaults string
+		out      string
+	}{
+		{
+			name:     "missing keys in defaults order",
+			base:     `{"b":2}`,
+			defaults: `{"z":26,"b":20,"a":1}`,
+			out:      `{"b":2,"z":26,"a":1}`,
+		},
+		{
+			name:     "nested objects",
+			base:     `{"db":{"port":5433},"name":"x"}`,
+			defaults: `{"db":{"host":"localhost","port":5432},"debug":false}`,
+			out:      `{"db":{"port":5433,"host":"localhost"},"name":"x","debug":false}`,
+		},
+		{
+			name:     "existing values win over objects",
+			base:     `{"db":null,"tags":["a"]}`,
+			defaults: `{"db":{"host":"localhost"},"tags":["b","c"]}`,
+			out:      `{"db":null,"tags":["a"]}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			base := MustNewValueFromJSON(test.base).V.(*Object)
+			defaults := MustNewValueFromJSON(test.defaults).V.(*Object)
+			ApplyDefaultsObject(base, defaults)
+			b, err := json.Marshal(base)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("copies defaults", func(t *testing.T) {
+		require := require.New(t)
+		base := NewObject()
+		defaults := MustNewValueFromJSON(`{"db":{"host":"localhost"}}`).V.(*Object)
+		ApplyDefaultsObject(base, defaults)
+		db, _ := base.Get("db")
+		db.(*Object).Set("host", "example.com")
+
+		b, err := json.Marshal(defaults)
+		require.NoError(err)
+		require.Equal(`{"db":{"host":"localhost"}}`, string(b))
+	})
+}
@@ -0,0 +1,486 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Document is a lossless representation of JSON source text. It preserves
+// the exact bytes of the source, including whitespace, comments, and key
+// order, and supports editing values in place so that re-serializing the
+// Document only changes the bytes affected by the edit.
+//
+// In addition to standard JSON, a Document may contain "//" line comments and
+// "/* */" block comments.
+type Document struct {
+	src  []byte
+	root *Node
+}
+
+// NodeKind identifies the type of the value represented by a Node.
+type NodeKind int
+
+const (
+	NullNode NodeKind = iota
+	BoolNode
+	NumberNode
+	StringNode
+	ArrayNode
+	ObjectNode
+)
+
+// Node is a value in a Document's concrete syntax tree. Nodes are only valid
+// until the next edit of their Document.
+type Node struct {
+	Kind NodeKind
+	// Start and End are the byte offsets of the value in the Document's
+	// source, not including any surrounding whitespace or comments.
+	Start int
+	End   int
+	// Members holds the entries of an ObjectNode, in source order.
+	Members []*Member
+	// Elements holds the entries of an ArrayNode, in source order.
+	Elements []*Node
+}
+
+// Member is an entry of an object Node.
+type Member struct {
+	Key string
+	// KeyStart and KeyEnd are the byte offsets of the quoted key in the
+	// Document's source.
+	KeyStart int
+	KeyEnd   int
+	Value    *Node
+}
+
+// ParseDocument parses b into a Document. The Document takes ownership of b.
+func ParseDocument(b []byte) (*Document, error) {
+	root, err := parseNode(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{src: b, root: root}, nil
+}
+
+func parseNode(b []byte) (*Node, error) {
+	i, err := skipSpaceAndComments(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, i, err := parseValueNode(b, i)
+	if err != nil {
+		return nil, err
+	}
+	if i, err = skipSpaceAndComments(b, i); err != nil {
+		return nil, err
+	}
+	if i != len(b) {
+		return nil, syntaxError(b, i, "invalid character %q after top-level value", b[i])
+	}
+	return n, nil
+}
+
+// parseValueNode parses the value starting at b[i], returning its Node and
+// the index just past it. Open objects and arrays are tracked on an explicit
+// stack rather than by recursion, so that deeply nested input can't overflow
+// the goroutine stack.
+func parseValueNode(b []byte, i int) (*Node, int, error) {
+	var stack []*Node
+	for {
+		if i >= len(b) {
+			return nil, 0, syntaxError(b, i, "unexpected end of input")
+		}
+		n := &Node{Start: i}
+		var err error
+		switch c := b[i]; {
+		case c == '{':
+			n.Kind = ObjectNode
+			i++
+		case c == '[':
+			n.Kind = ArrayNode
+			i++
+		case c == '"':
+			n.Kind = StringNode
+			i, err = scanString(b, i)
+		case c == '-' || isDigit(c):
+			n.Kind = NumberNode
+			i, err = scanNumber(b, i)
+		case c == 't':
+			n.Kind = BoolNode
+			i, err = scanLiteral(b, i, "true")
+		case c == 'f':
+			n.Kind = BoolNode
+			i, err = scanLiteral(b, i, "false")
+		case c == 'n':
+			n.Kind = NullNode
+			i, err = scanLiteral(b, i, "null")
+		default:
+			return nil, 0, syntaxError(b, i, "invalid character %q looking for beginning of value", c)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.Kind == ObjectNode {
+				top.Members[len(top.Members)-1].Value = n
+			} else {
+				top.Elements = append(top.Elements, n)
+			}
+		}
+		opened := n.Kind == ObjectNode || n.Kind == ArrayNode
+		if opened {
+			stack = append(stack, n)
+		} else {
+			n.End = i
+			if len(stack) == 0 {
+				return n, i, nil
+			}
+		}
+
+		// Move on to the next member or element, closing the objects and
+		// arrays that end first.
+		for {
+			top := stack[len(stack)-1]
+			if i, err = skipSpaceAndComments(b, i); err != nil {
+				return nil, 0, err
+			}
+			closing := byte(']')
+			if top.Kind == ObjectNode {
+				closing = '}'
+			}
+			if i < len(b) && b[i] == closing {
+				i++
+				top.End = i
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return top, i, nil
+				}
+				opened = false
+				continue
+			}
+			if !opened {
+				if i >= len(b) || b[i] != ',' {
+					if top.Kind == ObjectNode {
+						return nil, 0, syntaxError(b, i, "expected ',' or '}' after object value")
+					}
+					return nil, 0, syntaxError(b, i, "expected ',' or ']' after array element")
+				}
+				if i, err = skipSpaceAndComments(b, i+1); err != nil {
+					return nil, 0, err
+				}
+			}
+			if top.Kind == ObjectNode {
+				if i, err = parseKey(b, i, top); err != nil {
+					return nil, 0, err
+				}
+			}
+			break
+		}
+	}
+}
+
+// parseKey parses the key of a member of the object n starting at b[i],
+// along with the ':' after it, and adds the member to n. It returns the index
+// of the member's value.
+func parseKey(b []byte, i int, n *Node) (int, error) {
+	if i >= len(b) || b[i] != '"' {
+		return 0, syntaxError(b, i, "expected object key")
+	}
+	m := &Member{KeyStart: i}
+	var err error
+	if i, err = scanString(b, i); err != nil {
+		return 0, err
+	}
+	m.KeyEnd = i
+	if err := json.Unmarshal(b[m.KeyStart:m.KeyEnd], &m.Key); err != nil {
+		return 0, syntaxError(b, m.KeyStart, "invalid object key")
+	}
+	if i, err = skipSpaceAndComments(b, i); err != nil {
+		return 0, err
+	}
+	if i >= len(b) || b[i] != ':' {
+		return 0, syntaxError(b, i, "expected ':' after object key")
+	}
+	n.Members = append(n.Members, m)
+	return skipSpaceAndComments(b, i+1)
+}
+
+// Bytes returns the Document's source, reflecting any edits. The returned
+// slice must not be modified.
+func (d *Document) Bytes() []byte {
+	return d.src
+}
+
+// Root returns the Node of the Document's top-level value.
+func (d *Document) Root() *Node {
+	return d.root
+}
+
+// Raw returns the source bytes of n, which must belong to d.
+func (d *Document) Raw(n *Node) []byte {
+	return d.src[n.Start:n.End]
+}
+
+// Value decodes the whole Document, dropping any comments.
+func (d *Document) Value() (Value, error) {
+	v, err := d.decode(d.root)
+	return Value{V: v}, err
+}
+
+// Get decodes the value at the given JSON Pointer (RFC 6901).
+func (d *Document) Get(pointer string) (Value, error) {
+	n, err := d.Find(pointer)
+	if err != nil {
+		return Value{}, err
+	}
+	v, err := d.decode(n)
+	return Value{V: v}, err
+}
+
+// Find returns the Node at the given JSON Pointer (RFC 6901).
+func (d *Document) Find(pointer string) (*Node, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return d.find(tokens)
+}
+
+func (d *Document) find(tokens []string) (*Node, error) {
+	n := d.root
+	for i, t := range tokens {
+		switch n.Kind {
+		case ObjectNode:
+			m := n.member(t)
+			if m == nil {
+				return nil, fmt.Errorf("key %q not found at %q", t, joinPointer(tokens[:i]))
+			}
+			n = m.Value
+		case ArrayNode:
+			idx, ok := parseArrayIndex(t)
+			if !ok || idx >= len(n.Elements) {
+				return nil, fmt.Errorf("invalid array index %q at %q", t, joinPointer(tokens[:i]))
+			}
+			n = n.Elements[idx]
+		default:
+			return nil, fmt.Errorf("cannot index into scalar value at %q", joinPointer(tokens[:i]))
+		}
+	}
+	return n, nil
+}
+
+// member returns the last member of n with key k, which matches the value
+// that decoding n would keep for k.
+func (n *Node) member(k string) *Member {
+	for i := len(n.Members) - 1; i >= 0; i-- {
+		if n.Members[i].Key == k {
+			return n.Members[i]
+		}
+	}
+	return nil
+}
+
+func (d *Document) decode(n *Node) (interface{}, error) {
+	raw := d.src[n.Start:n.End]
+	switch n.Kind {
+	case NullNode:
+		return nil, nil
+	case BoolNode:
+		return raw[0] == 't', nil
+	case NumberNode:
+		return strconv.ParseFloat(string(raw), 64)
+	case StringNode:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case ArrayNode:
+		arr := make([]interface{}, 0, len(n.Elements))
+		for _, e := range n.Elements {
+			v, err := d.decode(e)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	default:
+		obj := NewObject()
+		for _, m := range n.Members {
+			v, err := d.decode(m.Value)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(m.Key, v)
+		}
+		return obj, nil
+	}
+}
+
+// Set sets the value at the given JSON Pointer (RFC 6901) to v. An existing
+// value is replaced in place. Otherwise, if the pointer refers to a missing
+// key of an object, or to the end of an array (index "-" or the array's
+// length), the value is added after the last entry of the container,
+// following the formatting of that entry.
+//
+// Only the bytes of the replaced or added value are changed. New values are
+// encoded compactly.
+func (d *Document) Set(pointer string, v interface{}) error {
+	e := newEncodeState()
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	text := e.Bytes()
+
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return d.splice(d.root.Start, d.root.End, text)
+	}
+	parent, err := d.find(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	switch parent.Kind {
+	case ObjectNode:
+		if m := parent.member(last); m != nil {
+			return d.splice(m.Value.Start, m.Value.End, text)
+		}
+		key, err := json.Marshal(last)
+		if err != nil {
+			return err
+		}
+		sep := []byte(": ")
+		if len(parent.Members) > 0 {
+			m := parent.Members[len(parent.Members)-1]
+			if between := d.src[m.KeyEnd:m.Value.Start]; bytes.IndexByte(between, '/') < 0 {
+				sep = between
+			}
+		}
+		entry := append(append(key, sep...), text...)
+		return d.insertItem(parent, entry, bytes.HasSuffix(sep, []byte(" ")))
+	case ArrayNode:
+		if last == "-" {
+			return d.insertItem(parent, text, false)
+		}
+		idx, ok := parseArrayIndex(last)
+		switch {
+		case ok && idx < len(parent.Elements):
+			e := parent.Elements[idx]
+			return d.splice(e.Start, e.End, text)
+		case ok && idx == len(parent.Elements):
+			return d.insertItem(parent, text, false)
+		default:
+			return fmt.Errorf("invalid array index %q at %q", last, joinPointer(tokens[:len(tokens)-1]))
+		}
+	default:
+		return fmt.Errorf("cannot index into scalar value at %q", joinPointer(tokens[:len(tokens)-1]))
+	}
+}
+
+// Delete removes the value at the given JSON Pointer (RFC 6901), along with
+// the separator and whitespace between it and its neighbor.
+func (d *Document) Delete(pointer string) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot delete the root value")
+	}
+	parent, err := d.find(tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	idx := -1
+	switch parent.Kind {
+	case ObjectNode:
+		for i := len(parent.Members) - 1; i >= 0; i-- {
+			if parent.Members[i].Key == last {
+				idx = i
+				break
+			}
+		}
+	case ArrayNode:
+		if i, ok := parseArrayIndex(last); ok && i < len(parent.Elements) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("no value at %q", pointer)
+	}
+
+	spans := parent.itemSpans()
+	switch {
+	case idx > 0:
+		return d.splice(spans[idx-1][1], spans[idx][1], nil)
+	case len(spans) > 1:
+		return d.splice(spans[0][0], spans[1][0], nil)
+	default:
+		return d.splice(parent.Start+1, parent.End-1, nil)
+	}
+}
+
+// itemSpans returns the [start, end) byte offsets of each entry of a
+// container Node. An object entry spans from its key to the end of its value.
+func (n *Node) itemSpans() [][2]int {
+	var spans [][2]int
+	for _, m := range n.Members {
+		spans = append(spans, [2]int{m.KeyStart, m.Value.End})
+	}
+	for _, e := range n.Elements {
+		spans = append(spans, [2]int{e.Start, e.End})
+	}
+	return spans
+}
+
+// insertItem adds text as the last entry of the container n, copying the
+// whitespace that precedes its current last entry. If there is none, spaced
+// controls whether the new entry is separated from the previous one by a
+// space.
+func (d *Document) insertItem(n *Node, text []byte, spaced bool) error {
+	spans := n.itemSpans()
+	if len(spans) == 0 {
+		return d.splice(n.Start+1, n.Start+1, text)
+	}
+	last := spans[len(spans)-1]
+	wsStart := last[0]
+	for wsStart > 0 && isSpace(d.src[wsStart-1]) {
+		wsStart--
+	}
+	insert := append([]byte(","), d.src[wsStart:last[0]]...)
+	if wsStart == last[0] && spaced {
+		insert = append(insert, ' ')
+	}
+	insert = append(insert, text...)
+	return d.splice(last[1], last[1], insert)
+}
+
+// splice replaces d.src[start:end] with repl and reparses the result.
+func (d *Document) splice(start, end int, repl []byte) error {
+	src := make([]byte, 0, len(d.src)-(end-start)+len(repl))
+	src = append(src, d.src[:start]...)
+	src = append(src, repl...)
+	src = append(src, d.src[end:]...)
+	root, err := parseNode(src)
+	if err != nil {
+		return err
+	}
+	d.src = src
+	d.root = root
+	return nil
+}
+
+func joinPointer(tokens []string) string {
+	var b bytes.Buffer
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(escapePointerToken(t))
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package ojson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443],
+  /* limits */
+  "limits": {"cpu": 2}
+}
+`
+
+func TestDocumentEdits(tt *testing.T) {
+	for _, test := range []struct {
+		name string
+		edit func(d *Document) error
+		out  string
+	}{
+		{
+			name: "no edits",
+			edit: func(d *Document) error { return nil },
+			out:  testDocument,
+		},
+		{
+			name: "replace value",
+			edit: func(d *Document) error { return d.Set("/name", "web") },
+			out: `// Service configuration.
+{
+  "name": "web", // the service name
+  "ports": [80, 443],
+  /* limits */
+  "limits": {"cpu": 2}
+}
+`,
+		},
+		{
+			name: "add key",
+			edit: func(d *Document) error { return d.Set("/replicas", 3) },
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443],
+  /* limits */
+  "limits": {"cpu": 2},
+  "replicas": 3
+}
+`,
+		},
+		{
+			name: "append to array",
+			edit: func(d *Document) error { return d.Set("/ports/-", 8080) },
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443, 8080],
+  /* limits */
+  "limits": {"cpu": 2}
+}
+`,
+		},
+		{
+			name: "add nested key",
+			edit: func(d *Document) error {
+				return d.Set("/limits/memory", NewObject().SetAndReturn("max", "1Gi"))
+			},
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443],
+  /* limits */
+  "limits": {"cpu": 2, "memory": {"max":"1Gi"}}
+}
+`,
+		},
+		{
+			name: "delete first array element",
+			edit: func(d *Document) error { return d.Delete("/ports/0") },
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [443],
+  /* limits */
+  "limits": {"cpu": 2}
+}
+`,
+		},
+		{
+			name: "delete last key",
+			edit: func(d *Document) error { return d.Delete("/limits") },
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443]
+}
+`,
+		},
+		{
+			name: "delete only key",
+			edit: func(d *Document) error { return d.Delete("/limits/cpu") },
+			out: `// Service configuration.
+{
+  "name": "api", // the service name
+  "ports": [80, 443],
+  /* limits */
+  "limits": {}
+}
+`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			d, err := ParseDocument([]byte(testDocument))
+			require.NoError(err)
+			require.NoError(test.edit(d))
+			require.Equal(test.out, string(d.Bytes()))
+		})
+	}
+}
+
+func TestDocumentValue(tt *testing.T) {
+	require := require.New(tt)
+	d, err := ParseDocument([]byte(testDocument))
+	require.NoError(err)
+
+	v, err := d.Value()
+	require.NoError(err)
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"name":"api","ports":[80,443],"limits":{"cpu":2}}`, string(b))
+
+	v, err = d.Get("/ports/1")
+	require.NoError(err)
+	require.Equal(443.0, v.V)
+
+	n, err := d.Find("/limits")
+	require.NoError(err)
+	require.Equal(ObjectNode, n.Kind)
+	require.Equal(`{"cpu": 2}`, string(d.Raw(n)))
+
+	_, err = d.Find("/missing")
+	require.Error(err)
+}
+
+func TestParseDocumentDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 1 << 20
+	src := strings.Repeat(`{"a":[`, depth) + "1" + strings.Repeat("]}", depth)
+	d, err := ParseDocument([]byte(src))
+	require.NoError(err)
+	n := d.Root()
+	for i := 0; i < depth; i++ {
+		require.Equal(ObjectNode, n.Kind)
+		require.Equal(len(src)-2*i, n.End)
+		n = n.Members[0].Value.Elements[0]
+	}
+	require.Equal(NumberNode, n.Kind)
+
+	_, err = ParseDocument([]byte(strings.Repeat("[", depth)))
+	require.EqualError(err, "unexpected end of input at line 1, column 1048577")
+}
+
+func TestParseDocumentErrors(tt *testing.T) {
+	for _, in := range []string{
+		``,
+		`{`,
+		`{"a" 1}`,
+		`[1,]`,
+		`{"a":1} x`,
+		`/* open`,
+		`"\x"`,
+		`01`,
+	} {
+		tt.Run(in, func(t *testing.T) {
+			_, err := ParseDocument([]byte(in))
+			require.Error(t, err)
+		})
+	}
+
+	tt.Run("position", func(t *testing.T) {
+		require := require.New(t)
+		_, err := ParseDocument([]byte("{\n  \"a\": 1,\n  \"b\" 2\n}"))
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 18, Line: 3, Column: 7}, syntaxErr.Position)
+		require.Equal("expected ':' after object key at line 3, column 7", err.Error())
+	})
+}
@@ -0,0 +1,532 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrCycle is returned when marshaling an Object that (directly or through
+// nested values) contains itself.
+var ErrCycle = errors.New("object contains a reference cycle")
+
+// MarshalOptions configures optional encoding behavior. The zero value
+// encodes the same way as Value.MarshalJSON.
+type MarshalOptions struct {
+	// Floats controls how float64 values are formatted.
+	Floats FloatFormat
+	// SortKeys, if set, writes the keys of every Object in the order it
+	// defines, instead of the Object's own order, without modifying the
+	// Object. It returns a negative number if a sorts before b, a positive
+	// number if it sorts after, and zero if they are equal, in which case
+	// the keys keep their relative order. For byte order, use
+	// strings.Compare.
+	SortKeys func(a, b string) int
+}
+
+// FloatFormat controls how float64 values are formatted. The zero value
+// matches encoding/json. It only applies to float64 values held directly in
+// Values, Objects, and arrays, not to fields of other types such as structs.
+type FloatFormat struct {
+	// Digits is the maximum number of significant digits to write. Zero
+	// means the shortest representation that parses back to the same
+	// float64.
+	Digits int
+	// FixedMin and FixedMax bound the absolute values written in fixed-point
+	// notation. Nonzero values below FixedMin, or at or above FixedMax, are
+	// written in exponent notation. Zero means the encoding/json defaults of
+	// 1e-6 and 1e21.
+	FixedMin float64
+	FixedMax float64
+	// ECMAScript formats numbers like ECMAScript's Number.prototype.toString,
+	// as required by the JSON Canonicalization Scheme (RFC 8785). When set,
+	// the other fields are ignored.
+	ECMAScript bool
+}
+
+// Marshal encodes v as JSON according to opts.
+func (opts MarshalOptions) Marshal(v interface{}) ([]byte, error) {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	e.opts = opts
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), e.Bytes()...), nil
+}
+
+// appendJSON appends the JSON encoding of v to dst.
+func appendJSON(dst []byte, v interface{}) ([]byte, error) {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	if err := e.marshal(v); err != nil {
+		return dst, err
+	}
+	return append(dst, e.Bytes()...), nil
+}
+
+// encodeState accumulates the JSON encoding of a value. It walks Objects and
+// arrays itself, rather than recursing through json.Marshal, so that it can
+// keep track of the Objects currently being encoded and detect cycles.
+type encodeState struct {
+	bytes.Buffer
+
+	// visiting holds the storage of the Objects on the path from the root to
+	// the value currently being encoded. An Object may appear multiple times
+	// in a tree, but never as its own descendant. Objects are identified by
+	// their storage, which copies of them share, because Object.MarshalJSON
+	// encodes a copy.
+	visiting map[*Entry]struct{}
+
+	opts MarshalOptions
+
+	// prefix and indent, if indenting, are written before each object member
+	// and array element as json.MarshalIndent does. depth is the current
+	// level of nesting.
+	indenting      bool
+	prefix, indent string
+	depth          int
+	// noEscapeHTML leaves <, > and & in strings unescaped.
+	noEscapeHTML bool
+	// w, if set, receives the encoding as it is produced, whenever the
+	// buffer holds at least flushSize bytes, so that the whole encoding
+	// never needs to be held in memory.
+	w io.Writer
+
+	// scratch is space for formatting numbers without allocating.
+	scratch [64]byte
+}
+
+// flushSize is how much an encodeState with a writer buffers before writing.
+const flushSize = 4096
+
+func newEncodeState() *encodeState {
+	return &encodeState{
+		visiting: make(map[*Entry]struct{}),
+	}
+}
+
+// encodeStatePool holds encodeStates for reuse by getEncodeState.
+var encodeStatePool sync.Pool
+
+// getEncodeState returns an empty encodeState, reusing a pooled one if there
+// is one. It should be returned with putEncodeState once its bytes are no
+// longer needed.
+func getEncodeState() *encodeState {
+	if e, ok := encodeStatePool.Get().(*encodeState); ok {
+		return e
+	}
+	return newEncodeState()
+}
+
+// maxPooledSize is the largest buffer putEncodeState keeps, so that one huge
+// encoding doesn't pin its memory.
+const maxPooledSize = 1 << 16
+
+func putEncodeState(e *encodeState) {
+	if e.Cap() > maxPooledSize {
+		return
+	}
+	e.clear()
+	encodeStatePool.Put(e)
+}
+
+// clear empties e and resets its settings, ready for reuse.
+func (e *encodeState) clear() {
+	e.Reset()
+	if len(e.visiting) > 0 {
+		// A failed encode may leave Objects behind.
+		e.visiting = make(map[*Entry]struct{})
+	}
+	e.opts = MarshalOptions{}
+	e.indenting, e.prefix, e.indent, e.depth = false, "", "", 0
+	e.noEscapeHTML = false
+	e.w = nil
+}
+
+func (e *encodeState) marshal(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		e.marshalString(v)
+		return nil
+
+	case bool:
+		if v {
+			e.WriteString("true")
+		} else {
+			e.WriteString("false")
+		}
+		return nil
+
+	case nil:
+		e.WriteString("null")
+		return nil
+
+	case *Object:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		return e.marshalObject(v)
+
+	case Object:
+		return e.marshalObject(&v)
+
+	case []interface{}:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		return e.marshalArray(v)
+
+	case float64:
+		// The zero FloatFormat matches encoding/json.
+		b, err := e.opts.Floats.append(e.scratch[:0], v)
+		if err != nil {
+			return err
+		}
+		e.Write(b)
+		return nil
+
+	case *big.Int:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		e.WriteString(v.String())
+		return nil
+
+	case *big.Float:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		if v.IsInf() {
+			return errors.New("cannot marshal infinite big.Float")
+		}
+		e.WriteString(v.Text('g', -1))
+		return nil
+
+	case decimal.Decimal:
+		// Decimal's own MarshalJSON quotes the number by default.
+		e.WriteString(v.String())
+		return nil
+
+	case *decimal.Decimal:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		e.WriteString(v.String())
+		return nil
+
+	case decimal.NullDecimal:
+		if !v.Valid {
+			e.WriteString("null")
+			return nil
+		}
+		e.WriteString(v.Decimal.String())
+		return nil
+
+	case RawString:
+		if err := v.check(); err != nil {
+			return err
+		}
+		e.WriteString(v.Raw)
+		return nil
+
+	case Value:
+		return e.marshal(v.V)
+
+	case *Value:
+		if v == nil {
+			e.WriteString("null")
+			return nil
+		}
+		return e.marshal(v.V)
+	}
+
+	return e.marshalJSON(v)
+}
+
+// marshalJSON writes the encoding/json encoding of v, honoring the encoder's
+// indentation and HTML escaping.
+func (e *encodeState) marshalJSON(v interface{}) error {
+	// encoding/json encodes any Objects inside v with encodeStates of their
+	// own, which don't know which Objects are already being encoded, so look
+	// for cycles back to them first.
+	if len(e.visiting) > 0 && e.reachesVisiting(reflect.ValueOf(v), make(map[uintptr]struct{})) {
+		return ErrCycle
+	}
+	var b []byte
+	if e.noEscapeHTML {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		b = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	} else {
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	if e.indenting && len(b) > 0 && (b[0] == '{' || b[0] == '[') {
+		return json.Indent(&e.Buffer, b, e.prefix+strings.Repeat(e.indent, e.depth), e.indent)
+	}
+	e.Write(b)
+	return nil
+}
+
+var objectType = reflect.TypeOf(Object{})
+
+// reachesVisiting reports whether encoding/json would reach an Object being
+// encoded while encoding v. seen holds the maps, slices and pointers already
+// walked, so that cycles not involving Objects, which encoding/json detects
+// itself, don't make the walk loop.
+func (e *encodeState) reachesVisiting(v reflect.Value, seen map[uintptr]struct{}) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return !v.IsNil() && e.reachesVisiting(v.Elem(), seen)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return false
+		}
+		seen[v.Pointer()] = struct{}{}
+		return e.reachesVisiting(v.Elem(), seen)
+	case reflect.Map:
+		if v.IsNil() {
+			return false
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return false
+		}
+		seen[v.Pointer()] = struct{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			if e.reachesVisiting(iter.Value(), seen) {
+				return true
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 {
+			return false
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return false
+		}
+		seen[v.Pointer()] = struct{}{}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if e.reachesVisiting(v.Index(i), seen) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		if v.Type() == objectType {
+			// An Object's entries are unexported, but encoded all the same.
+			entries := v.FieldByName("entries")
+			if entries.Cap() > 0 {
+				if _, ok := e.visiting[(*Entry)(unsafe.Pointer(entries.Pointer()))]; ok {
+					return true
+				}
+			}
+			return e.reachesVisiting(entries, seen)
+		}
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			// encoding/json ignores unexported fields, other than embedded
+			// structs.
+			if t.Field(i).PkgPath != "" && !t.Field(i).Anonymous {
+				continue
+			}
+			if e.reachesVisiting(v.Field(i), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// marshalString writes s as a JSON string. Strings of printable ASCII
+// characters that need no escaping are written directly; others are left to
+// encoding/json, so that they are escaped exactly as it would.
+func (e *encodeState) marshalString(s string) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c > 0x7e || c == '"' || c == '\\' ||
+			!e.noEscapeHTML && (c == '<' || c == '>' || c == '&') {
+			// Strings never fail to marshal.
+			_ = e.marshalJSON(s)
+			return
+		}
+	}
+	e.WriteByte('"')
+	e.WriteString(s)
+	e.WriteByte('"')
+}
+
+// newline starts a new line at the current depth, if indenting.
+func (e *encodeState) newline() {
+	if !e.indenting {
+		return
+	}
+	e.WriteByte('\n')
+	e.WriteString(e.prefix)
+	for i := 0; i < e.depth; i++ {
+		e.WriteString(e.indent)
+	}
+}
+
+// flush writes out the buffered encoding if there is a writer and enough of
+// it has accumulated.
+func (e *encodeState) flush() error {
+	if e.w == nil || e.Len() < flushSize {
+		return nil
+	}
+	_, err := e.WriteTo(e.w)
+	return err
+}
+
+// objectID identifies o by its storage, for detecting cycles. Unlike o's
+// address, it is shared by copies of an Object value. It is nil for an Object
+// without storage, which is empty, and so can't contain itself.
+func objectID(o *Object) *Entry {
+	if cap(o.entries) == 0 {
+		return nil
+	}
+	return &o.entries[:1][0]
+}
+
+func (e *encodeState) marshalObject(o *Object) error {
+	if key := objectID(o); key != nil {
+		if _, ok := e.visiting[key]; ok {
+			return ErrCycle
+		}
+		e.visiting[key] = struct{}{}
+		defer delete(e.visiting, key)
+	}
+
+	entries := o.entries
+	if e.opts.SortKeys != nil {
+		entries = append([]Entry(nil), entries...)
+		sort.SliceStable(entries, func(i, j int) bool {
+			return e.opts.SortKeys(entries[i].Key, entries[j].Key) < 0
+		})
+	}
+
+	e.WriteString("{")
+	e.depth++
+	for i, ent := range entries {
+		if i > 0 {
+			e.WriteString(",")
+		}
+		e.newline()
+		if raw, ok := o.rawKeys[ent.Key]; ok {
+			e.WriteString(raw)
+		} else {
+			e.marshalString(ent.Key)
+		}
+		e.WriteString(":")
+		if e.indenting {
+			e.WriteString(" ")
+		}
+		if err := e.marshal(ent.Value); err != nil {
+			return err
+		}
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	if len(entries) > 0 {
+		e.newline()
+	}
+	e.WriteString("}")
+	return nil
+}
+
+func (e *encodeState) marshalArray(arr []interface{}) error {
+	e.WriteString("[")
+	e.depth++
+	for i, v := range arr {
+		if i > 0 {
+			e.WriteString(",")
+		}
+		e.newline()
+		if err := e.marshal(v); err != nil {
+			return err
+		}
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	if len(arr) > 0 {
+		e.newline()
+	}
+	e.WriteString("]")
+	return nil
+}
+
+func (f FloatFormat) format(v float64) ([]byte, error) {
+	return f.append(nil, v)
+}
+
+// append appends the formatted v to dst.
+func (f FloatFormat) append(dst []byte, v float64) ([]byte, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, &json.UnsupportedValueError{
+			Value: reflect.ValueOf(v),
+			Str:   strconv.FormatFloat(v, 'g', -1, 64),
+		}
+	}
+	fixedMin, fixedMax := f.FixedMin, f.FixedMax
+	if f.ECMAScript {
+		if v == 0 {
+			// Negative zero is written as 0.
+			return append(dst, '0'), nil
+		}
+		fixedMin, fixedMax = 1e-6, 1e21
+	} else {
+		if fixedMin == 0 {
+			fixedMin = 1e-6
+		}
+		if fixedMax == 0 {
+			fixedMax = 1e21
+		}
+		if f.Digits > 0 {
+			// Round to the requested number of significant digits, then
+			// format the rounded value as usual.
+			v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'e', f.Digits-1, 64), 64)
+		}
+	}
+
+	abs := math.Abs(v)
+	if abs == 0 || (fixedMin <= abs && abs < fixedMax) {
+		return strconv.AppendFloat(dst, v, 'f', -1, 64), nil
+	}
+	b := strconv.AppendFloat(dst, v, 'e', -1, 64)
+	// Clean up e-09 to e-9, as encoding/json and ECMAScript do.
+	if n := len(b); n-len(dst) >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+		b[n-2] = b[n-1]
+		b = b[:n-1]
+	}
+	return b, nil
+}
@@ -0,0 +1,96 @@
+package ojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloatFormat(tt *testing.T) {
+	// Constant arithmetic is exact, so use variables to get float64 rounding.
+	a, b := 0.1, 0.2
+	noisy := a + b
+
+	for _, test := range []struct {
+		name   string
+		format FloatFormat
+		in     float64
+		out    string
+	}{
+		{name: "default", in: noisy, out: "0.30000000000000004"},
+		{name: "digits", format: FloatFormat{Digits: 15}, in: noisy, out: "0.3"},
+		{name: "digits large", format: FloatFormat{Digits: 2}, in: 12345, out: "12000"},
+		{name: "digits small", format: FloatFormat{Digits: 3}, in: 1.23456e-9, out: "1.23e-9"},
+		{name: "fixed max", format: FloatFormat{FixedMax: 1e6}, in: 2.5e6, out: "2.5e+06"},
+		{name: "fixed min", format: FloatFormat{FixedMin: 0.01}, in: 0.005, out: "5e-3"},
+		{name: "below fixed max", format: FloatFormat{FixedMax: 1e6}, in: 999999, out: "999999"},
+		{name: "ecmascript", format: FloatFormat{ECMAScript: true}, in: 1e21, out: "1e+21"},
+		{name: "ecmascript small", format: FloatFormat{ECMAScript: true}, in: 1e-7, out: "1e-7"},
+		{name: "ecmascript negative zero", format: FloatFormat{ECMAScript: true}, in: math.Copysign(0, -1), out: "0"},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			b, err := MarshalOptions{Floats: test.format}.Marshal(
+				NewObject().SetAndReturn("v", []interface{}{test.in}),
+			)
+			require.NoError(err)
+			require.Equal(`{"v":[`+test.out+`]}`, string(b))
+		})
+	}
+
+	tt.Run("NaN", func(t *testing.T) {
+		_, err := MarshalOptions{Floats: FloatFormat{Digits: 3}}.Marshal(math.NaN())
+		require.Error(t, err)
+	})
+}
+
+func TestMarshalMatchesEncodingJSON(tt *testing.T) {
+	for _, v := range []interface{}{
+		"plain", "", "quote\" and \\", "<a href='x'>&amp;</a>", "tab\tnew\nline\r",
+		"\b\f\x00\x1f\x7f", "é ü 日本", "\u2028\u2029", "bad \xff utf-8",
+		0.0, math.Copysign(0, -1), 1.0, -1.5, 1e20, 1e21, 1e-6, 1e-7, 123456789.123, math.MaxFloat64,
+		math.SmallestNonzeroFloat64, true, false, nil,
+	} {
+		tt.Run(strconv.Quote(fmt.Sprint(v)), func(t *testing.T) {
+			require := require.New(t)
+			want, err := json.Marshal(v)
+			require.NoError(err)
+			got, err := Marshal(v)
+			require.NoError(err)
+			require.Equal(string(want), string(got))
+
+			// Keys are encoded the same way.
+			if s, ok := v.(string); ok {
+				want, err = json.Marshal(map[string]int{s: 1})
+				require.NoError(err)
+				got, err = Marshal(NewObject().SetAndReturn(s, 1.0))
+				require.NoError(err)
+				require.Equal(string(want), string(got))
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	o := NewObject()
+	for i := 0; i < 100; i++ {
+		item := NewObject().
+			SetAndReturn("id", float64(i)).
+			SetAndReturn("name", "item "+strconv.Itoa(i)).
+			SetAndReturn("price", 12.5).
+			SetAndReturn("active", i%2 == 0).
+			SetAndReturn("tags", []interface{}{"a", "b", nil})
+		o.Set("key"+strconv.Itoa(i), item)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := o.MarshalJSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package ojson
+
+import "io"
+
+// Encoder writes JSON values to an output stream, keeping the order of
+// object keys.
+type Encoder struct {
+	w io.Writer
+
+	// Options configures how values are encoded.
+	Options MarshalOptions
+
+	prefix, indent string
+	noEscapeHTML   bool
+}
+
+// NewEncoder returns an Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetIndent makes the Encoder indent each value it writes as MarshalIndent
+// does. Calling SetIndent("", "") disables indentation.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix, enc.indent = prefix, indent
+}
+
+// SetEscapeHTML sets whether <, > and & in strings are escaped, as with
+// json.Encoder.SetEscapeHTML. The default is true.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.noEscapeHTML = !on
+}
+
+// Encode writes the JSON encoding of v to the stream, followed by a newline.
+// v may be a Value, an *Object, or any value encoding/json can marshal.
+// Large Objects and arrays are written out as they are encoded, rather than
+// being held in memory in full, so if encoding fails part of v may already
+// have been written.
+func (enc *Encoder) Encode(v interface{}) error {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	e.opts = enc.Options
+	e.indenting = enc.prefix != "" || enc.indent != ""
+	e.prefix, e.indent = enc.prefix, enc.indent
+	e.noEscapeHTML = enc.noEscapeHTML
+	e.w = enc.w
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	e.WriteByte('\n')
+	_, err := e.WriteTo(enc.w)
+	return err
+}
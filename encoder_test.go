@@ -0,0 +1,76 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(tt *testing.T) {
+	const in = `{"b":[1,{"d":"<&>","c":[]}],"a":{}}`
+
+	tt.Run("compact", func(t *testing.T) {
+		require := require.New(t)
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		require.NoError(enc.Encode(MustNewValueFromJSON(in)))
+		require.NoError(enc.Encode(MustNewValueFromJSON(`[1]`)))
+		require.Equal(`{"b":[1,{"d":"\u003c\u0026\u003e","c":[]}],"a":{}}`+"\n[1]\n", buf.String())
+	})
+
+	tt.Run("indent", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(in)
+		v.V.(*Object).Set("s", struct {
+			X []int `json:"x"`
+		}{X: []int{1}})
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetIndent(">", "  ")
+		require.NoError(enc.Encode(v))
+
+		// The output matches json.MarshalIndent's, including for values it
+		// marshals itself.
+		want, err := json.MarshalIndent(v, ">", "  ")
+		require.NoError(err)
+		require.Equal(string(want)+"\n", buf.String())
+	})
+
+	tt.Run("no escape html", func(t *testing.T) {
+		require := require.New(t)
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		o := NewObject().SetAndReturn("<k>", "a&b").SetAndReturn("s", struct{ V string }{"<x>"})
+		require.NoError(enc.Encode(o))
+		require.Equal(`{"<k>":"a&b","s":{"V":"<x>"}}`+"\n", buf.String())
+	})
+
+	tt.Run("streams", func(t *testing.T) {
+		require := require.New(t)
+		arr := make([]interface{}, 0, 10000)
+		for i := 0; i < cap(arr); i++ {
+			arr = append(arr, strings.Repeat("x", 10))
+		}
+		w := &countingWriter{}
+		require.NoError(NewEncoder(w).Encode(arr))
+		require.Greater(w.writes, 1)
+		b, err := json.Marshal(arr)
+		require.NoError(err)
+		require.Equal(len(b)+1, w.n)
+	})
+}
+
+type countingWriter struct {
+	writes, n int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.writes++
+	w.n += len(b)
+	return len(b), nil
+}
@@ -0,0 +1,138 @@
+package ojson
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvOptions configures ExpandEnv.
+type EnvOptions struct {
+	// ErrorOnMissing makes ExpandEnv fail on a variable that is not defined
+	// and has no default, instead of substituting an empty string.
+	ErrorOnMissing bool
+}
+
+// ExpandEnv returns a copy of v with ${VAR} and ${VAR:-default} references in
+// string values replaced by the variables' values, at every depth. Keys are
+// not expanded. As in the shell, the default is used when the variable is
+// undefined or empty, and may itself contain references. $${ is written as a
+// literal ${. If lookup is nil, os.LookupEnv is used.
+func ExpandEnv(v Value, lookup func(string) (string, bool)) (Value, error) {
+	return EnvOptions{}.ExpandEnv(v, lookup)
+}
+
+// ExpandEnv is like the package-level ExpandEnv, but configured by opts.
+func (opts EnvOptions) ExpandEnv(v Value, lookup func(string) (string, bool)) (Value, error) {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	e, err := mapStrings(v.V, "", func(path string, s string) (interface{}, error) {
+		return opts.expand(path, s, lookup)
+	})
+	return Value{V: e}, err
+}
+
+func (opts EnvOptions) expand(path string, s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if strings.HasPrefix(s[i+1:], "${") {
+			b.WriteString("${")
+			i += 2
+			continue
+		}
+		if !strings.HasPrefix(s[i+1:], "{") {
+			b.WriteByte('$')
+			continue
+		}
+		end := closingBrace(s, i+2)
+		if end < 0 || end == i+2 {
+			// Unterminated or empty references are left as they are.
+			b.WriteByte('$')
+			continue
+		}
+		name, def := s[i+2:end], ""
+		hasDef := false
+		if j := strings.Index(name, ":-"); j >= 0 {
+			name, def, hasDef = name[:j], name[j+2:], true
+		}
+		val, ok := lookup(name)
+		switch {
+		case hasDef && val == "":
+			d, err := opts.expand(path, def, lookup)
+			if err != nil {
+				return "", err
+			}
+			val = d
+		case !ok && opts.ErrorOnMissing:
+			return "", fmt.Errorf("undefined variable %q at %q", name, path)
+		}
+		b.WriteString(val)
+		i = end
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the '}' closing a brace opened just
+// before i, or -1 if there is none.
+func closingBrace(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// mapStrings returns a copy of v with every string value, at any depth,
+// replaced by the result of fn, which is called with the string's JSON
+// Pointer. Keys are not passed to fn.
+func mapStrings(v interface{}, path string, fn func(path string, s string) (interface{}, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return fn(path, v)
+	case *Object:
+		if v == nil {
+			return v, nil
+		}
+		obj := NewObjectWithCapacity(len(v.entries))
+		for _, kv := range v.entries {
+			e, err := mapStrings(kv.Value, path+"/"+escapePointerToken(kv.Key), fn)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(kv.Key, e)
+		}
+		return obj, nil
+	case []interface{}:
+		if v == nil {
+			return v, nil
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			e, err := mapStrings(e, path+"/"+strconv.Itoa(i), fn)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = e
+		}
+		return arr, nil
+	default:
+		return v, nil
+	}
+}
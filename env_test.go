@@ -0,0 +1,90 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(tt *testing.T) {
+	env := map[string]string{
+		"HOST":  "db",
+		"PORT":  "5432",
+		"EMPTY": "",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	for _, test := range []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "simple",
+			in:   `{"url":"${HOST}:${PORT}","n":1}`,
+			out:  `{"url":"db:5432","n":1}`,
+		},
+		{
+			name: "nested",
+			in:   `{"b":[{"c":"${HOST}"}],"a":null}`,
+			out:  `{"b":[{"c":"db"}],"a":null}`,
+		},
+		{
+			name: "keys are not expanded",
+			in:   `{"${HOST}":"x"}`,
+			out:  `{"${HOST}":"x"}`,
+		},
+		{
+			name: "defaults",
+			in:   `["${MISSING:-a}","${EMPTY:-b}","${HOST:-c}","${MISSING:-${PORT}}"]`,
+			out:  `["a","b","db","5432"]`,
+		},
+		{
+			name: "missing",
+			in:   `"(${MISSING})"`,
+			out:  `"()"`,
+		},
+		{
+			name: "escapes and malformed references",
+			in:   `["$${HOST}","$HOST","${HOST","${}","$"]`,
+			out:  `["${HOST}","$HOST","${HOST","${}","$"]`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v := MustNewValueFromJSON(test.in)
+			out, err := ExpandEnv(v, lookup)
+			require.NoError(err)
+			b, err := json.Marshal(out)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// The input is unchanged.
+			b, err = json.Marshal(v)
+			require.NoError(err)
+			require.JSONEq(test.in, string(b))
+		})
+	}
+
+	tt.Run("error on missing", func(t *testing.T) {
+		require := require.New(t)
+		opts := EnvOptions{ErrorOnMissing: true}
+		_, err := opts.ExpandEnv(MustNewValueFromJSON(`{"a":[1,"${HOST}${MISSING}"]}`), lookup)
+		require.EqualError(err, `undefined variable "MISSING" at "/a/1"`)
+
+		out, err := opts.ExpandEnv(MustNewValueFromJSON(`"${MISSING:-x}"`), lookup)
+		require.NoError(err)
+		require.Equal("x", out.V)
+	})
+
+	tt.Run("os environment", func(t *testing.T) {
+		require := require.New(t)
+		t.Setenv("OJSON_TEST_VAR", "set")
+		out, err := ExpandEnv(MustNewValueFromJSON(`"${OJSON_TEST_VAR}"`), nil)
+		require.NoError(err)
+		require.Equal("set", out.V)
+	})
+}
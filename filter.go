@@ -0,0 +1,399 @@
+package ojson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// StreamFilter removes or replaces the values at given paths while copying
+// JSON from a reader to a writer. In both, a "*" token matches any key or
+// array index, e.g. "/users/*/email".
+type StreamFilter struct {
+	// Delete lists the JSON Pointers of values to remove, along with their
+	// keys, or their slots in arrays. The root value can't be removed.
+	Delete []string
+	// Replace maps JSON Pointers to values to write in place of the values
+	// there. If several of them match a value, the most specific wins: the
+	// one with a key or index, rather than "*", at the first position where
+	// they differ.
+	Replace map[string]interface{}
+}
+
+// Copy copies the JSON values read from src to dst, filtered by f. It reads
+// the input a byte at a time and never holds more than a single key or
+// scalar in memory, so it can filter inputs of any size. Everything that
+// isn't removed or replaced is copied byte for byte, including whitespace;
+// the only other change is dropping the ',' that separated a removed member
+// or element from its neighbors. Consecutive top-level values, such as in
+// newline-delimited JSON, are each filtered.
+func (f StreamFilter) Copy(dst io.Writer, src io.Reader) error {
+	s := &streamFilter{
+		r:       bufio.NewReader(src),
+		w:       bufio.NewWriter(dst),
+		replace: make([]streamReplacement, 0, len(f.Replace)),
+	}
+	for _, p := range f.Delete {
+		tokens, err := parsePointer(p)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return errors.New("cannot delete the root value")
+		}
+		s.delete = append(s.delete, tokens)
+	}
+	for p, v := range f.Replace {
+		tokens, err := parsePointer(p)
+		if err != nil {
+			return err
+		}
+		b, err := MarshalOptions{}.Marshal(v)
+		if err != nil {
+			return err
+		}
+		s.replace = append(s.replace, streamReplacement{pointer: p, tokens: tokens, json: b})
+	}
+	sort.Slice(s.replace, func(i, j int) bool {
+		return moreSpecific(s.replace[i], s.replace[j])
+	})
+
+	for {
+		if err := s.copySpace(); err != nil {
+			return err
+		}
+		if _, err := s.r.Peek(1); err == io.EOF {
+			break
+		}
+		if err := s.value(nil, true); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+type streamReplacement struct {
+	pointer string
+	tokens  []string
+	json    []byte
+}
+
+// moreSpecific reports whether the replacement a takes precedence over b if
+// both match a value: a has a key or index where b has "*" at the first
+// position where they differ in that way. Otherwise, they are ordered by
+// their pointers, so that the order never depends on the order of
+// StreamFilter.Replace's iteration.
+func moreSpecific(a, b streamReplacement) bool {
+	for i := 0; i < len(a.tokens) && i < len(b.tokens); i++ {
+		if wa, wb := a.tokens[i] == "*", b.tokens[i] == "*"; wa != wb {
+			return wb
+		}
+	}
+	return a.pointer < b.pointer
+}
+
+type streamFilter struct {
+	r   *bufio.Reader
+	w   *bufio.Writer
+	off int
+	// lines counts the newlines before the last byte read, and lineStart is
+	// the offset just after the last of them.
+	lines     int
+	lineStart int
+	last      byte
+	delete    [][]string
+	// replace holds the replacements in order of precedence.
+	replace []streamReplacement
+	// key holds the current object key.
+	key bytes.Buffer
+}
+
+// matchPath reports whether the pattern matches path.
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, t := range pattern {
+		if t != "*" && t != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *streamFilter) deleted(path []string) bool {
+	for _, p := range s.delete {
+		if matchPath(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *streamFilter) replacement(path []string) []byte {
+	for _, r := range s.replace {
+		if matchPath(r.tokens, path) {
+			return r.json
+		}
+	}
+	return nil
+}
+
+func (s *streamFilter) readByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err == io.EOF {
+		return 0, s.syntaxError(s.off, "unexpected end of input")
+	}
+	if err == nil {
+		s.advance(c)
+	}
+	return c, err
+}
+
+// advance records that c was read from the input.
+func (s *streamFilter) advance(c byte) {
+	if s.last == '\n' {
+		s.lines++
+		s.lineStart = s.off
+	}
+	s.last = c
+	s.off++
+}
+
+// syntaxError returns a *SyntaxError describing a problem at offset off,
+// which is at most one byte past the last byte read.
+func (s *streamFilter) syntaxError(off int, format string, args ...interface{}) error {
+	lines, lineStart := s.lines, s.lineStart
+	if s.last == '\n' && off == s.off {
+		lines, lineStart = lines+1, off
+	}
+	return &SyntaxError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: Position{Offset: off, Line: lines + 1, Column: off - lineStart + 1},
+	}
+}
+
+func (s *streamFilter) peek() (byte, error) {
+	b, err := s.r.Peek(1)
+	if err == io.EOF {
+		return 0, s.syntaxError(s.off, "unexpected end of input")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// copySpace copies whitespace from the input to the output.
+func (s *streamFilter) copySpace() error {
+	return s.readSpace(s.w)
+}
+
+// readSpace copies whitespace from the input to w.
+func (s *streamFilter) readSpace(w io.ByteWriter) error {
+	for {
+		b, err := s.r.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !isSpace(b[0]) {
+			return nil
+		}
+		s.r.ReadByte()
+		s.advance(b[0])
+		w.WriteByte(b[0])
+	}
+}
+
+// value filters the value at path, writing it if emit is set and discarding
+// it otherwise.
+func (s *streamFilter) value(path []string, emit bool) error {
+	if emit {
+		if r := s.replacement(path); r != nil {
+			if err := s.value(path, false); err != nil {
+				return err
+			}
+			s.w.Write(r)
+			return nil
+		}
+	}
+	c, err := s.peek()
+	if err != nil {
+		return err
+	}
+	switch {
+	case c == '{':
+		return s.container(path, emit, '}')
+	case c == '[':
+		return s.container(path, emit, ']')
+	case c == '"':
+		return s.str(s.writer(emit))
+	case c == '-' || isDigit(c) || c == 't' || c == 'f' || c == 'n':
+		return s.scalar(emit)
+	default:
+		return s.syntaxError(s.off, "invalid character %q", c)
+	}
+}
+
+func (s *streamFilter) writer(emit bool) io.ByteWriter {
+	if emit {
+		return s.w
+	}
+	return discardByteWriter{}
+}
+
+// container filters an object or array, which ends with closing.
+func (s *streamFilter) container(path []string, emit bool, closing byte) error {
+	c, _ := s.readByte()
+	w := s.writer(emit)
+	w.WriteByte(c)
+
+	// lead holds the separator and whitespace before a member or element,
+	// until we know whether it is removed.
+	var lead bytes.Buffer
+	var first []byte
+	written := 0
+	for i := 0; ; i++ {
+		if err := s.readSpace(&lead); err != nil {
+			return err
+		}
+		c, err := s.peek()
+		if err != nil {
+			return err
+		}
+		if c == closing {
+			s.readByte()
+			if emit {
+				s.w.Write(lead.Bytes())
+			}
+			w.WriteByte(c)
+			return nil
+		}
+		if i > 0 {
+			if c != ',' {
+				return s.syntaxError(s.off, "expected , or %c", closing)
+			}
+			s.readByte()
+			lead.WriteByte(c)
+			if err := s.readSpace(&lead); err != nil {
+				return err
+			}
+		}
+
+		var token string
+		s.key.Reset()
+		if closing == '}' {
+			if c, err := s.peek(); err != nil {
+				return err
+			} else if c != '"' {
+				return s.syntaxError(s.off, "expected string key in object")
+			}
+			if err := s.str(&s.key); err != nil {
+				return err
+			}
+			var k string
+			if err := json.Unmarshal(s.key.Bytes(), &k); err != nil {
+				return s.syntaxError(s.off, "invalid string")
+			}
+			token = k
+		} else {
+			token = strconv.Itoa(i)
+		}
+		child := append(path[:len(path):len(path)], token)
+		keep := emit && !s.deleted(child)
+		if i == 0 {
+			first = append(first[:0], lead.Bytes()...)
+		}
+		if keep {
+			if written == 0 {
+				// Everything before this was removed, so use the first
+				// member's whitespace instead of this separator.
+				s.w.Write(first)
+			} else {
+				s.w.Write(lead.Bytes())
+			}
+			s.w.Write(s.key.Bytes())
+			written++
+		}
+		lead.Reset()
+
+		if closing == '}' {
+			if err := s.readSpace(s.writer(keep)); err != nil {
+				return err
+			}
+			if c, err := s.readByte(); err != nil {
+				return err
+			} else if c != ':' {
+				return s.syntaxError(s.off-1, "expected : after object key")
+			}
+			s.writer(keep).WriteByte(':')
+			if err := s.readSpace(s.writer(keep)); err != nil {
+				return err
+			}
+		}
+		if err := s.value(child, keep); err != nil {
+			return err
+		}
+	}
+}
+
+// str copies a string literal to w.
+func (s *streamFilter) str(w io.ByteWriter) error {
+	c, _ := s.readByte()
+	w.WriteByte(c)
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return err
+		}
+		w.WriteByte(c)
+		switch {
+		case c == '"':
+			return nil
+		case c == '\\':
+			c, err := s.readByte()
+			if err != nil {
+				return err
+			}
+			w.WriteByte(c)
+		case c < 0x20:
+			return s.syntaxError(s.off-1, "invalid control character in string")
+		}
+	}
+}
+
+// scalar copies a number or literal.
+func (s *streamFilter) scalar(emit bool) error {
+	w := s.writer(emit)
+	for {
+		b, err := s.r.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch c := b[0]; {
+		case isSpace(c) || c == ',' || c == '}' || c == ']':
+			return nil
+		case isDigit(c) || c == '-' || c == '+' || c == '.' || ('a' <= c && c <= 'z') || c == 'E':
+			s.r.ReadByte()
+			s.advance(c)
+			w.WriteByte(c)
+		default:
+			return s.syntaxError(s.off, "invalid character %q", c)
+		}
+	}
+}
+
+type discardByteWriter struct{}
+
+func (discardByteWriter) WriteByte(byte) error { return nil }
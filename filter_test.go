@@ -0,0 +1,138 @@
+package ojson
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamFilter(tt *testing.T) {
+	const doc = `{
+  "id": 1,
+  "user": {"name": "a", "email": "a@example.com", "tags": ["x", "y"]},
+  "events": [
+    {"ip": "1.2.3.4", "ok": true},
+    {"ok": false, "ip": "5.6.7.8"}
+  ]
+}`
+	for _, test := range []struct {
+		name   string
+		filter StreamFilter
+		in     string
+		out    string
+	}{
+		{
+			name: "nothing",
+			in:   doc,
+			out:  doc,
+		},
+		{
+			name:   "delete",
+			filter: StreamFilter{Delete: []string{"/user/email", "/events/*/ip"}},
+			in:     doc,
+			out: `{
+  "id": 1,
+  "user": {"name": "a", "tags": ["x", "y"]},
+  "events": [
+    {"ok": true},
+    {"ok": false}
+  ]
+}`,
+		},
+		{
+			name:   "delete first and last",
+			filter: StreamFilter{Delete: []string{"/id", "/events"}},
+			in:     doc,
+			out: `{
+  "user": {"name": "a", "email": "a@example.com", "tags": ["x", "y"]}
+}`,
+		},
+		{
+			name:   "delete array elements",
+			filter: StreamFilter{Delete: []string{"/user/tags/0", "/events/1"}},
+			in:     doc,
+			out: `{
+  "id": 1,
+  "user": {"name": "a", "email": "a@example.com", "tags": ["y"]},
+  "events": [
+    {"ip": "1.2.3.4", "ok": true}
+  ]
+}`,
+		},
+		{
+			name:   "delete everything",
+			filter: StreamFilter{Delete: []string{"/*"}},
+			in:     `{"a":1,"b":[2],"c":{}}`,
+			out:    `{}`,
+		},
+		{
+			name: "replace",
+			filter: StreamFilter{Replace: map[string]interface{}{
+				"/user/email": "redacted",
+				"/user/tags":  NewObject().SetAndReturn("n", 2),
+			}},
+			in: doc,
+			out: `{
+  "id": 1,
+  "user": {"name": "a", "email": "redacted", "tags": {"n":2}},
+  "events": [
+    {"ip": "1.2.3.4", "ok": true},
+    {"ok": false, "ip": "5.6.7.8"}
+  ]
+}`,
+		},
+		{
+			name:   "sequence",
+			filter: StreamFilter{Delete: []string{"/b"}, Replace: map[string]interface{}{"/a": nil}},
+			in:     "{\"a\":\"x\\\"}\",\"b\":2}\n{\"b\":3}\n[1]\n",
+			out:    "{\"a\":null}\n{}\n[1]\n",
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			var b bytes.Buffer
+			require.NoError(test.filter.Copy(&b, strings.NewReader(test.in)))
+			require.Equal(test.out, b.String())
+		})
+	}
+
+	tt.Run("overlapping replacements", func(t *testing.T) {
+		require := require.New(t)
+		filter := StreamFilter{Replace: map[string]interface{}{
+			"/a/*": "any",
+			"/a/b": "b",
+			"/*/c": "c",
+			"/*/*": "other",
+		}}
+		// The result doesn't depend on the order of iteration over Replace.
+		for i := 0; i < 20; i++ {
+			var b bytes.Buffer
+			require.NoError(filter.Copy(&b, strings.NewReader(`{"a":{"b":1,"c":2,"d":3},"x":{"c":4,"d":5}}`)))
+			require.Equal(`{"a":{"b":"b","c":"any","d":"any"},"x":{"c":"c","d":"other"}}`, b.String())
+		}
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		var b bytes.Buffer
+		for _, in := range []string{`{"a":1`, `{"a" 1}`, `[1 2]`, `{1:2}`, `[@]`, `"a`} {
+			require.Error(StreamFilter{}.Copy(&b, strings.NewReader(in)), in)
+		}
+		err := StreamFilter{}.Copy(&b, strings.NewReader("{\n  \"a\": 1,\n  \"b\" 2\n}"))
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 18, Line: 3, Column: 7}, syntaxErr.Position)
+		err = StreamFilter{}.Copy(&b, strings.NewReader("[\"a\n\"]"))
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 3, Line: 1, Column: 4}, syntaxErr.Position)
+		err = StreamFilter{}.Copy(&b, strings.NewReader("[1,\n"))
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 4, Line: 2, Column: 1}, syntaxErr.Position)
+
+		require.Error(StreamFilter{Delete: []string{""}}.Copy(&b, strings.NewReader(`1`)))
+		require.Error(StreamFilter{Delete: []string{"a"}}.Copy(&b, strings.NewReader(`1`)))
+	})
+}
@@ -0,0 +1,107 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Format describes the whitespace style of JSON text, so that edited values
+// can be written back in the same style as the file they were read from.
+type Format struct {
+	// Indent is the string used for each level of indentation, such as "\t"
+	// or "  ". An empty Indent means the text is compact.
+	Indent string
+	// TrailingNewline is whether the text ends with a newline.
+	TrailingNewline bool
+	// CRLF is whether lines end with "\r\n" rather than "\n".
+	CRLF bool
+	// Comments writes the comments attached to Object entries, producing
+	// JSONC (JSON with comments) rather than JSON. Comments are written as
+	// "//" line comments when indenting, or "/* */" block comments otherwise.
+	// DetectFormat never sets it.
+	Comments bool
+}
+
+// DetectFormat infers the Format of the JSON text b. The indentation is taken
+// from the least-indented line that is indented at all, preferring tabs if
+// any line is indented with a tab. The line ending is taken from the first
+// line.
+func DetectFormat(b []byte) Format {
+	f := Format{
+		TrailingNewline: bytes.HasSuffix(b, []byte("\n")),
+	}
+	if i := bytes.IndexByte(b, '\n'); i > 0 && b[i-1] == '\r' {
+		f.CRLF = true
+	}
+	// JSON strings can't contain raw newlines, so every line break is
+	// structural whitespace.
+	lines := bytes.Split(bytes.TrimRight(b, " \t\r\n"), []byte("\n"))
+	spaces := 0
+	for _, line := range lines[1:] {
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '\t' {
+			f.Indent = "\t"
+			return f
+		}
+		n := 0
+		for n < len(line) && line[n] == ' ' {
+			n++
+		}
+		if n > 0 && n < len(line) && (spaces == 0 || n < spaces) {
+			spaces = n
+		}
+	}
+	f.Indent = strings.Repeat(" ", spaces)
+	return f
+}
+
+// Format returns the Format of the Document's source.
+func (d *Document) Format() Format {
+	return DetectFormat(d.src)
+}
+
+// Marshal encodes v as JSON in the style described by f.
+func (f Format) Marshal(v interface{}) ([]byte, error) {
+	var b []byte
+	if f.Comments {
+		var err error
+		if b, err = f.marshalComments(v); err != nil {
+			return nil, err
+		}
+	} else {
+		e := newEncodeState()
+		if err := e.marshal(v); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if f.Indent == "" {
+			buf.Write(e.Bytes())
+		} else if err := json.Indent(&buf, e.Bytes(), "", f.Indent); err != nil {
+			return nil, err
+		}
+		if f.TrailingNewline {
+			buf.WriteString("\n")
+		}
+		b = buf.Bytes()
+	}
+	if f.CRLF {
+		b = crlf(b)
+	}
+	return b, nil
+}
+
+// crlf returns b with each "\n" not already preceded by "\r" replaced by
+// "\r\n".
+func crlf(b []byte) []byte {
+	out := make([]byte, 0, len(b)+bytes.Count(b, []byte("\n")))
+	for i, c := range b {
+		if c == '\n' && (i == 0 || b[i-1] != '\r') {
+			out = append(out, '\r')
+		}
+		out = append(out, c)
+	}
+	return out
+}
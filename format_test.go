@@ -0,0 +1,56 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(tt *testing.T) {
+	for _, test := range []struct {
+		name   string
+		in     string
+		format Format
+	}{
+		{
+			name:   "compact",
+			in:     `{"b":1,"a":[true]}`,
+			format: Format{},
+		},
+		{
+			name:   "two spaces",
+			in:     "{\n  \"b\": 1,\n  \"a\": [\n    true\n  ]\n}\n",
+			format: Format{Indent: "  ", TrailingNewline: true},
+		},
+		{
+			name:   "four spaces",
+			in:     "{\n    \"b\": 1,\n    \"a\": [\n        true\n    ]\n}",
+			format: Format{Indent: "    "},
+		},
+		{
+			name:   "tabs",
+			in:     "{\n\t\"b\": 1,\n\t\"a\": [\n\t\ttrue\n\t]\n}\n",
+			format: Format{Indent: "\t", TrailingNewline: true},
+		},
+		{
+			name:   "crlf",
+			in:     "{\r\n  \"b\": 1,\r\n  \"a\": [\r\n    \"x\\r\\ny\"\r\n  ]\r\n}\r\n",
+			format: Format{Indent: "  ", TrailingNewline: true, CRLF: true},
+		},
+		{
+			name:   "crlf without trailing newline",
+			in:     "[\r\n\ttrue\r\n]",
+			format: Format{Indent: "\t", CRLF: true},
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			f := DetectFormat([]byte(test.in))
+			require.Equal(test.format, f)
+
+			b, err := f.Marshal(MustNewValueFromJSON(test.in))
+			require.NoError(err)
+			require.Equal(test.in, string(b))
+		})
+	}
+}
@@ -2,10 +2,14 @@ module github.com/airplanedev/ojson
 
 go 1.17
 
-require github.com/stretchr/testify v1.7.0
+require (
+	github.com/shopspring/decimal v1.4.0
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/text v0.3.5
+)
 
 require (
-	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
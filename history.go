@@ -0,0 +1,306 @@
+package ojson
+
+import "fmt"
+
+// History records edits made to Objects and arrays so that they can be undone
+// and redone. Each edit stores only the affected keys or elements, their
+// positions and their previous values rather than a snapshot of the whole
+// document.
+//
+// Edits must be made through the History for them to be recorded; changes
+// made directly on an Object are not tracked, and undoing past them may
+// produce unexpected results.
+type History struct {
+	maxDepth int
+	undo     []change
+	redo     []change
+}
+
+// change is a single recorded edit of an Object, as the functions that
+// revert and reapply it.
+type change struct {
+	undo, redo func()
+}
+
+// NewHistory returns a History that remembers at most maxDepth edits, dropping
+// the oldest ones once the limit is reached. A maxDepth of zero or less means
+// the history is unbounded.
+func NewHistory(maxDepth int) *History {
+	return &History{maxDepth: maxDepth}
+}
+
+// record records an edit that has just been made. Recording a new edit
+// clears any edits that were available to redo.
+func (h *History) record(c change) {
+	h.undo = append(h.undo, c)
+	if h.maxDepth > 0 && len(h.undo) > h.maxDepth {
+		h.undo = h.undo[len(h.undo)-h.maxDepth:]
+	}
+	h.redo = nil
+}
+
+// Set sets k to v on o, as Object.Set does, and records the edit.
+func (h *History) Set(o *Object, k string, v interface{}) {
+	h.record(setChange(o, k, v))
+}
+
+func setChange(o *Object, k string, v interface{}) change {
+	i := o.Index(k)
+	var old interface{}
+	if i >= 0 {
+		old = o.entries[i].Value
+	}
+	o.Set(k, v)
+	return change{
+		undo: func() {
+			if i < 0 {
+				o.Delete(k)
+				return
+			}
+			// Set keeps the key's position.
+			o.Set(k, old)
+		},
+		redo: func() { o.Set(k, v) },
+	}
+}
+
+// SetAt sets k to v at position i of o, as Object.SetAt does, and records
+// the edit. Undoing it moves k back to where it was, if it was present.
+func (h *History) SetAt(o *Object, i int, k string, v interface{}) {
+	j := o.Index(k)
+	var old interface{}
+	if j >= 0 {
+		old = o.entries[j].Value
+	}
+	o.SetAt(i, k, v)
+	h.record(change{
+		undo: func() {
+			if j < 0 {
+				o.Delete(k)
+				return
+			}
+			o.SetAt(j, k, old)
+		},
+		redo: func() { o.SetAt(i, k, v) },
+	})
+}
+
+// Delete removes k from o, as Object.Delete does, and records the edit.
+// Undoing it restores k, along with its comments, at its old position. It
+// reports whether k was present; if not, nothing is recorded.
+func (h *History) Delete(o *Object, k string) bool {
+	i := o.Index(k)
+	if i < 0 {
+		return false
+	}
+	old := o.entries[i].Value
+	comments, hadComments := o.comments[k]
+	o.Delete(k)
+	h.record(change{
+		undo: func() {
+			o.SetAt(i, k, old)
+			if hadComments {
+				if o.comments == nil {
+					o.comments = make(map[string]entryComments)
+				}
+				o.comments[k] = comments
+			}
+		},
+		redo: func() { o.Delete(k) },
+	})
+	return true
+}
+
+// Swap exchanges the positions of the i'th and j'th entries of o, as
+// Object.Swap does, and records the edit.
+func (h *History) Swap(o *Object, i, j int) {
+	o.Swap(i, j)
+	swap := func() { o.Swap(i, j) }
+	h.record(change{undo: swap, redo: swap})
+}
+
+// Update sets every entry of src on o, as Object.Update does, and records
+// it as a single edit.
+func (h *History) Update(o, src *Object) {
+	changes := make([]change, 0, src.Len())
+	for _, kv := range src.entries {
+		changes = append(changes, setChange(o, kv.Key, kv.Value))
+	}
+	h.record(change{
+		undo: func() {
+			for i := len(changes) - 1; i >= 0; i-- {
+				changes[i].undo()
+			}
+		},
+		redo: func() {
+			for _, c := range changes {
+				c.redo()
+			}
+		},
+	})
+}
+
+// SetElement sets the i'th element of the array at the JSON Pointer path in
+// v to x, and records the edit.
+func (h *History) SetElement(v *Value, path string, i int, x interface{}) error {
+	tokens, arr, err := historyArray(v, path)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(arr) {
+		return fmt.Errorf("array index %d out of range at %q", i, path)
+	}
+	old := arr[i]
+	arr[i] = x
+	set := func(x interface{}) func() {
+		return func() {
+			a, _ := resolvePointer(v.V, tokens)
+			if arr, ok := a.([]interface{}); ok && i < len(arr) {
+				arr[i] = x
+			}
+		}
+	}
+	h.record(change{undo: set(old), redo: set(x)})
+	return nil
+}
+
+// InsertElement inserts x into the array at the JSON Pointer path in v, so
+// that it becomes the i'th element, and records the edit. i may be the
+// length of the array, to append x.
+func (h *History) InsertElement(v *Value, path string, i int, x interface{}) error {
+	tokens, arr, err := historyArray(v, path)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i > len(arr) {
+		return fmt.Errorf("array index %d out of range at %q", i, path)
+	}
+	insertElement(v, tokens, i, x)
+	h.record(change{
+		undo: func() { removeElement(v, tokens, i) },
+		redo: func() { insertElement(v, tokens, i, x) },
+	})
+	return nil
+}
+
+// RemoveElement removes the i'th element of the array at the JSON Pointer
+// path in v, and records the edit.
+func (h *History) RemoveElement(v *Value, path string, i int) error {
+	tokens, arr, err := historyArray(v, path)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(arr) {
+		return fmt.Errorf("array index %d out of range at %q", i, path)
+	}
+	old := removeElement(v, tokens, i)
+	h.record(change{
+		undo: func() { insertElement(v, tokens, i, old) },
+		redo: func() { removeElement(v, tokens, i) },
+	})
+	return nil
+}
+
+// historyArray returns the array at the JSON Pointer path in v.
+func historyArray(v *Value, path string) ([]string, []interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, ok := resolvePointer(v.V, tokens)
+	if !ok {
+		return nil, nil, fmt.Errorf("path %q not found", path)
+	}
+	arr, ok := x.([]interface{})
+	if !ok {
+		return nil, nil, ErrNotArray
+	}
+	return tokens, arr, nil
+}
+
+// insertElement inserts x at index i of the array at tokens in v. It does
+// nothing if there is no such array, or i is out of range, as can happen
+// after edits that weren't recorded.
+func insertElement(v *Value, tokens []string, i int, x interface{}) {
+	a, _ := resolvePointer(v.V, tokens)
+	arr, ok := a.([]interface{})
+	if !ok || i > len(arr) {
+		return
+	}
+	arr = append(arr, nil)
+	copy(arr[i+1:], arr[i:])
+	arr[i] = x
+	storeArray(v, tokens, arr)
+}
+
+// removeElement removes and returns the element at index i of the array at
+// tokens in v. Like insertElement, it does nothing if there is no such
+// element.
+func removeElement(v *Value, tokens []string, i int) interface{} {
+	a, _ := resolvePointer(v.V, tokens)
+	arr, ok := a.([]interface{})
+	if !ok || i >= len(arr) {
+		return nil
+	}
+	old := arr[i]
+	copy(arr[i:], arr[i+1:])
+	arr[len(arr)-1] = nil
+	storeArray(v, tokens, arr[:len(arr)-1])
+	return old
+}
+
+// storeArray replaces the array at tokens in v with arr, which may have a
+// different length.
+func storeArray(v *Value, tokens []string, arr []interface{}) {
+	if len(tokens) == 0 {
+		v.V = arr
+		return
+	}
+	parent, _ := resolvePointer(v.V, tokens[:len(tokens)-1])
+	t := tokens[len(tokens)-1]
+	switch parent := parent.(type) {
+	case *Object:
+		// Set keeps the key's position.
+		parent.Set(t, arr)
+	case []interface{}:
+		if i, ok := parseArrayIndex(t); ok && i < len(parent) {
+			parent[i] = arr
+		}
+	}
+}
+
+// Undo reverts the most recent edit. It returns false if there is nothing to
+// undo.
+func (h *History) Undo() bool {
+	if len(h.undo) == 0 {
+		return false
+	}
+	c := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	c.undo()
+	h.redo = append(h.redo, c)
+	return true
+}
+
+// Redo reapplies the most recently undone edit. It returns false if there is
+// nothing to redo.
+func (h *History) Redo() bool {
+	if len(h.redo) == 0 {
+		return false
+	}
+	c := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	c.redo()
+	h.undo = append(h.undo, c)
+	return true
+}
+
+// CanUndo reports whether there is an edit to undo.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo reports whether there is an undone edit to redo.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
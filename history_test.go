@@ -0,0 +1,178 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(tt *testing.T) {
+	marshal := func(t *testing.T, o *Object) string {
+		b, err := json.Marshal(o)
+		require.NoError(t, err)
+		return string(b)
+	}
+	marshalValue := func(t *testing.T, v Value) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	tt.Run("undo and redo", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2}`).V.(*Object)
+		h := NewHistory(0)
+
+		h.Set(o, "a", 10.0)
+		h.Set(o, "c", 3.0)
+		require.Equal(`{"a":10,"b":2,"c":3}`, marshal(t, o))
+
+		require.True(h.Undo())
+		require.Equal(`{"a":10,"b":2}`, marshal(t, o))
+		require.True(h.Undo())
+		require.Equal(`{"a":1,"b":2}`, marshal(t, o))
+		require.False(h.Undo())
+
+		require.True(h.Redo())
+		require.True(h.Redo())
+		require.Equal(`{"a":10,"b":2,"c":3}`, marshal(t, o))
+		require.False(h.Redo())
+	})
+
+	tt.Run("new edit clears redo", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		h := NewHistory(0)
+
+		h.Set(o, "a", 1.0)
+		require.True(h.Undo())
+		require.True(h.CanRedo())
+		h.Set(o, "b", 2.0)
+		require.False(h.CanRedo())
+		require.Equal(`{"b":2}`, marshal(t, o))
+	})
+
+	tt.Run("bounded depth", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		h := NewHistory(2)
+
+		h.Set(o, "a", 1.0)
+		h.Set(o, "a", 2.0)
+		h.Set(o, "a", 3.0)
+		require.True(h.Undo())
+		require.True(h.Undo())
+		require.False(h.Undo())
+		require.Equal(`{"a":1}`, marshal(t, o))
+	})
+
+	tt.Run("delete", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2,"c":3}`).V.(*Object)
+		o.SetLeadingComment("b", "kept")
+		h := NewHistory(0)
+
+		require.False(h.Delete(o, "missing"))
+		require.False(h.CanUndo())
+		require.True(h.Delete(o, "b"))
+		require.Equal(`{"a":1,"c":3}`, marshal(t, o))
+
+		// The key returns to its old position, with its comments.
+		require.True(h.Undo())
+		require.Equal(`{"a":1,"b":2,"c":3}`, marshal(t, o))
+		leading, _ := o.Comments("b")
+		require.Equal("kept", leading)
+
+		require.True(h.Redo())
+		require.Equal(`{"a":1,"c":3}`, marshal(t, o))
+	})
+
+	tt.Run("positional edits", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2,"c":3}`).V.(*Object)
+		h := NewHistory(0)
+
+		h.SetAt(o, 0, "c", 30.0)
+		h.SetAt(o, 1, "d", 4.0)
+		h.Swap(o, 0, 3)
+		require.Equal(`{"b":2,"d":4,"a":1,"c":30}`, marshal(t, o))
+
+		require.True(h.Undo())
+		require.Equal(`{"c":30,"d":4,"a":1,"b":2}`, marshal(t, o))
+		require.True(h.Undo())
+		require.True(h.Undo())
+		require.Equal(`{"a":1,"b":2,"c":3}`, marshal(t, o))
+
+		require.True(h.Redo())
+		require.True(h.Redo())
+		require.True(h.Redo())
+		require.Equal(`{"b":2,"d":4,"a":1,"c":30}`, marshal(t, o))
+	})
+
+	tt.Run("update", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2}`).V.(*Object)
+		h := NewHistory(0)
+
+		h.Update(o, MustNewValueFromJSON(`{"c":3,"a":10,"d":4}`).V.(*Object))
+		require.Equal(`{"a":10,"b":2,"c":3,"d":4}`, marshal(t, o))
+
+		// The whole update is a single edit.
+		require.True(h.Undo())
+		require.Equal(`{"a":1,"b":2}`, marshal(t, o))
+		require.False(h.CanUndo())
+		require.True(h.Redo())
+		require.Equal(`{"a":10,"b":2,"c":3,"d":4}`, marshal(t, o))
+	})
+
+	tt.Run("array elements", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"a":{"list":[1,2,3]},"b":[[0]]}`)
+		h := NewHistory(0)
+
+		require.NoError(h.SetElement(&v, "/a/list", 1, 20.0))
+		require.NoError(h.InsertElement(&v, "/a/list", 0, 0.0))
+		require.NoError(h.InsertElement(&v, "/a/list", 4, 4.0))
+		require.NoError(h.RemoveElement(&v, "/a/list", 2))
+		require.NoError(h.InsertElement(&v, "/b/0", 1, 1.0))
+		require.Equal(`{"a":{"list":[0,1,3,4]},"b":[[0,1]]}`, marshalValue(t, v))
+
+		for _, want := range []string{
+			`{"a":{"list":[0,1,3,4]},"b":[[0]]}`,
+			`{"a":{"list":[0,1,20,3,4]},"b":[[0]]}`,
+			`{"a":{"list":[0,1,20,3]},"b":[[0]]}`,
+			`{"a":{"list":[1,20,3]},"b":[[0]]}`,
+			`{"a":{"list":[1,2,3]},"b":[[0]]}`,
+		} {
+			require.True(h.Undo())
+			require.Equal(want, marshalValue(t, v))
+		}
+		require.False(h.CanUndo())
+		for h.Redo() {
+		}
+		require.Equal(`{"a":{"list":[0,1,3,4]},"b":[[0,1]]}`, marshalValue(t, v))
+	})
+
+	tt.Run("root array", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`[]`)
+		h := NewHistory(0)
+		require.NoError(h.InsertElement(&v, "", 0, "x"))
+		require.Equal(`["x"]`, marshalValue(t, v))
+		require.True(h.Undo())
+		require.Equal(`[]`, marshalValue(t, v))
+	})
+
+	tt.Run("array errors", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"a":[1],"o":{}}`)
+		h := NewHistory(0)
+		require.EqualError(h.SetElement(&v, "/a", 1, nil), `array index 1 out of range at "/a"`)
+		require.EqualError(h.InsertElement(&v, "/a", -1, nil), `array index -1 out of range at "/a"`)
+		require.EqualError(h.RemoveElement(&v, "/a", 1), `array index 1 out of range at "/a"`)
+		require.EqualError(h.RemoveElement(&v, "/x", 0), `path "/x" not found`)
+		require.Equal(ErrNotArray, h.InsertElement(&v, "/o", 0, nil))
+		require.False(h.CanUndo())
+	})
+}
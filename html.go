@@ -0,0 +1,153 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"html/template"
+	"strconv"
+)
+
+// HTMLOptions configures RenderHTML.
+type HTMLOptions struct {
+	// Table renders objects and arrays as two-column tables of keys or
+	// indices and values, instead of nested lists.
+	Table bool
+	// Collapsible wraps every non-empty object and array in a <details>
+	// element, initially open, whose summary shows its size.
+	Collapsible bool
+}
+
+// RenderHTML renders v as an HTML fragment, with object keys in order and
+// all keys and values escaped. Elements have classes, such as ojson-object,
+// ojson-key, and ojson-string, for styling. Scalar values are written as
+// JSON.
+func RenderHTML(v Value, opts HTMLOptions) (template.HTML, error) {
+	r := htmlRenderer{
+		opts:     opts,
+		visiting: make(map[*Entry]struct{}),
+	}
+	if err := r.render(v.V); err != nil {
+		return "", err
+	}
+	return template.HTML(r.String()), nil
+}
+
+type htmlRenderer struct {
+	bytes.Buffer
+	opts HTMLOptions
+	// visiting holds the objectIDs of the Objects being rendered.
+	visiting map[*Entry]struct{}
+}
+
+func (r *htmlRenderer) render(v interface{}) error {
+	switch v := v.(type) {
+	case *Object:
+		if v != nil {
+			return r.renderObject(v)
+		}
+	case Object:
+		return r.renderObject(&v)
+	case []interface{}:
+		if v != nil {
+			return r.renderArray(v)
+		}
+	case Value:
+		return r.render(v.V)
+	}
+
+	e := newEncodeState()
+	if s, ok := v.(string); ok {
+		// Leave escaping HTML to html.EscapeString, for readable output.
+		enc := json.NewEncoder(e)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+		e.Truncate(e.Len() - 1)
+	} else if err := e.marshal(v); err != nil {
+		return err
+	}
+	class := "ojson-number"
+	switch b := e.Bytes(); {
+	case b[0] == '"':
+		class = "ojson-string"
+	case b[0] == 'n':
+		class = "ojson-null"
+	case b[0] == 't' || b[0] == 'f':
+		class = "ojson-bool"
+	case b[0] == '{' || b[0] == '[':
+		class = "ojson-json"
+	}
+	r.WriteString(`<span class="` + class + `">`)
+	r.WriteString(html.EscapeString(e.String()))
+	r.WriteString("</span>")
+	return nil
+}
+
+func (r *htmlRenderer) renderObject(o *Object) error {
+	if key := objectID(o); key != nil {
+		if _, ok := r.visiting[key]; ok {
+			return ErrCycle
+		}
+		r.visiting[key] = struct{}{}
+		defer delete(r.visiting, key)
+	}
+
+	if len(o.entries) == 0 {
+		r.WriteString(`<span class="ojson-object">{}</span>`)
+		return nil
+	}
+	return r.renderContainer("ojson-object", "{"+strconv.Itoa(len(o.entries))+"}", len(o.entries), func(i int) (string, interface{}) {
+		kv := o.entries[i]
+		return `<span class="ojson-key">` + html.EscapeString(kv.Key) + `</span>`, kv.Value
+	})
+}
+
+func (r *htmlRenderer) renderArray(arr []interface{}) error {
+	if len(arr) == 0 {
+		r.WriteString(`<span class="ojson-array">[]</span>`)
+		return nil
+	}
+	return r.renderContainer("ojson-array", "["+strconv.Itoa(len(arr))+"]", len(arr), func(i int) (string, interface{}) {
+		return `<span class="ojson-index">` + strconv.Itoa(i) + `</span>`, arr[i]
+	})
+}
+
+// renderContainer renders the n entries of an object or array, each with the
+// label and value returned by entry.
+func (r *htmlRenderer) renderContainer(class, summary string, n int, entry func(i int) (string, interface{})) error {
+	if r.opts.Collapsible {
+		r.WriteString(`<details open><summary>` + summary + `</summary>`)
+	}
+	if r.opts.Table {
+		r.WriteString(`<table class="` + class + `">`)
+	} else {
+		r.WriteString(`<ul class="` + class + `">`)
+	}
+	for i := 0; i < n; i++ {
+		label, v := entry(i)
+		if r.opts.Table {
+			r.WriteString("<tr><th>" + label + "</th><td>")
+		} else {
+			r.WriteString("<li>" + label + ": ")
+		}
+		if err := r.render(v); err != nil {
+			return err
+		}
+		if r.opts.Table {
+			r.WriteString("</td></tr>")
+		} else {
+			r.WriteString("</li>")
+		}
+	}
+	if r.opts.Table {
+		r.WriteString("</table>")
+	} else {
+		r.WriteString("</ul>")
+	}
+	if r.opts.Collapsible {
+		r.WriteString("</details>")
+	}
+	return nil
+}
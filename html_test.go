@@ -0,0 +1,73 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(tt *testing.T) {
+	v := MustNewValueFromJSON(`{"z":"<b>&","a":[1,true],"n":null,"e":{}}`)
+	for _, test := range []struct {
+		name string
+		opts HTMLOptions
+		out  string
+	}{
+		{
+			name: "lists",
+			out: `<ul class="ojson-object">` +
+				`<li><span class="ojson-key">z</span>: <span class="ojson-string">&#34;&lt;b&gt;&amp;&#34;</span></li>` +
+				`<li><span class="ojson-key">a</span>: <ul class="ojson-array">` +
+				`<li><span class="ojson-index">0</span>: <span class="ojson-number">1</span></li>` +
+				`<li><span class="ojson-index">1</span>: <span class="ojson-bool">true</span></li></ul></li>` +
+				`<li><span class="ojson-key">n</span>: <span class="ojson-null">null</span></li>` +
+				`<li><span class="ojson-key">e</span>: <span class="ojson-object">{}</span></li>` +
+				`</ul>`,
+		},
+		{
+			name: "collapsible table",
+			opts: HTMLOptions{Table: true, Collapsible: true},
+			out: `<details open><summary>{4}</summary><table class="ojson-object">` +
+				`<tr><th><span class="ojson-key">z</span></th><td><span class="ojson-string">&#34;&lt;b&gt;&amp;&#34;</span></td></tr>` +
+				`<tr><th><span class="ojson-key">a</span></th><td><details open><summary>[2]</summary><table class="ojson-array">` +
+				`<tr><th><span class="ojson-index">0</span></th><td><span class="ojson-number">1</span></td></tr>` +
+				`<tr><th><span class="ojson-index">1</span></th><td><span class="ojson-bool">true</span></td></tr></table></details></td></tr>` +
+				`<tr><th><span class="ojson-key">n</span></th><td><span class="ojson-null">null</span></td></tr>` +
+				`<tr><th><span class="ojson-key">e</span></th><td><span class="ojson-object">{}</span></td></tr>` +
+				`</table></details>`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			h, err := RenderHTML(v, test.opts)
+			require.NoError(err)
+			require.Equal(test.out, string(h))
+		})
+	}
+
+	tt.Run("escapes keys", func(t *testing.T) {
+		require := require.New(t)
+		h, err := RenderHTML(Value{V: NewObject().SetAndReturn("<script>", 1)}, HTMLOptions{})
+		require.NoError(err)
+		require.Contains(string(h), `<span class="ojson-key">&lt;script&gt;</span>`)
+	})
+
+	tt.Run("cycle", func(t *testing.T) {
+		o := NewObject()
+		o.Set("self", []interface{}{o})
+		_, err := RenderHTML(Value{V: o}, HTMLOptions{})
+		require.Equal(t, ErrCycle, err)
+
+		// A copy of an Object value shares its storage.
+		o = NewObject()
+		o.Set("self", nil)
+		o.Set("self", *o)
+		_, err = RenderHTML(Value{V: o}, HTMLOptions{})
+		require.Equal(t, ErrCycle, err)
+	})
+
+	tt.Run("invalid raw string", func(t *testing.T) {
+		_, err := RenderHTML(Value{V: RawString{}}, HTMLOptions{})
+		require.Error(t, err)
+	})
+}
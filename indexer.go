@@ -0,0 +1,96 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Indexer provides random access to the elements of a large JSON array. It
+// scans the array once to record the byte offsets of its elements, after
+// which any element, or range of elements, can be decoded by reading just
+// its bytes, without parsing the rest of the array.
+type Indexer struct {
+	// Options configures how elements are decoded.
+	Options DecodeOptions
+
+	r      io.ReaderAt
+	starts []int64
+	ends   []int64
+}
+
+// NewIndexer scans the JSON array in the first size bytes of r, such as an
+// *os.File or a *bytes.Reader, and returns an Indexer for its elements. r
+// must not change while the Indexer is in use. Only the offsets are kept in
+// memory, not the elements themselves.
+func NewIndexer(r io.ReaderAt, size int64) (*Indexer, error) {
+	dec := json.NewDecoder(io.NewSectionReader(r, 0, size))
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t != json.Delim('[') {
+		return nil, ErrNotArray
+	}
+	ix := &Indexer{r: r}
+	for dec.More() {
+		start := dec.InputOffset()
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		ix.starts = append(ix.starts, start)
+		ix.ends = append(ix.ends, dec.InputOffset())
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return ix, nil
+}
+
+// Len returns the number of elements in the array.
+func (ix *Indexer) Len() int {
+	return len(ix.starts)
+}
+
+// Element decodes the i'th element of the array.
+func (ix *Indexer) Element(i int) (Value, error) {
+	vs, err := ix.Range(i, i+1)
+	if err != nil {
+		return Value{}, err
+	}
+	return vs[0], nil
+}
+
+// Range decodes the elements of the array from index i up to but not
+// including j, reading them from r in one go.
+func (ix *Indexer) Range(i, j int) ([]Value, error) {
+	if i < 0 || j > len(ix.starts) || i > j {
+		return nil, fmt.Errorf("element range [%d:%d] out of range with length %d", i, j, len(ix.starts))
+	}
+	if i == j {
+		return []Value{}, nil
+	}
+	base := ix.starts[i]
+	b := make([]byte, ix.ends[j-1]-base)
+	// ReadAt may return io.EOF along with all of b if it ends the input.
+	if n, err := ix.r.ReadAt(b, base); n < len(b) {
+		return nil, err
+	}
+	vs := make([]Value, j-i)
+	for n := range vs {
+		// Each element's span may start with the preceding separator.
+		raw := b[ix.starts[i+n]-base : ix.ends[i+n]-base]
+		for len(raw) > 0 && (isSpace(raw[0]) || raw[0] == ',') {
+			raw = raw[1:]
+		}
+		if err := newDecodeState(raw, ix.Options).decodeAll(&vs[n]); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
@@ -0,0 +1,74 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexer(tt *testing.T) {
+	const in = `[ {"b":1,"a":2}, "two" ,3,
+	[4, {"x":null}],null]`
+
+	tt.Run("bytes", func(t *testing.T) {
+		require := require.New(t)
+		ix, err := NewIndexer(bytes.NewReader([]byte(in)), int64(len(in)))
+		require.NoError(err)
+		require.Equal(5, ix.Len())
+
+		v, err := ix.Element(0)
+		require.NoError(err)
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"b":1,"a":2}`, string(b))
+
+		vs, err := ix.Range(1, 5)
+		require.NoError(err)
+		b, err = json.Marshal(vs)
+		require.NoError(err)
+		require.Equal(`["two",3,[4,{"x":null}],null]`, string(b))
+
+		vs, err = ix.Range(2, 2)
+		require.NoError(err)
+		require.Empty(vs)
+
+		_, err = ix.Element(5)
+		require.EqualError(err, "element range [5:6] out of range with length 5")
+		_, err = ix.Range(3, 1)
+		require.Error(err)
+	})
+
+	tt.Run("file", func(t *testing.T) {
+		require := require.New(t)
+		path := filepath.Join(t.TempDir(), "array.json")
+		require.NoError(os.WriteFile(path, []byte(in), 0o600))
+		f, err := os.Open(path)
+		require.NoError(err)
+		defer f.Close()
+		info, err := f.Stat()
+		require.NoError(err)
+
+		ix, err := NewIndexer(f, info.Size())
+		require.NoError(err)
+		ix.Options.Decimals = true
+		v, err := ix.Element(4)
+		require.NoError(err)
+		require.Nil(v.V)
+		v, err = ix.Element(2)
+		require.NoError(err)
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`3`, string(b))
+	})
+
+	tt.Run("invalid", func(t *testing.T) {
+		for _, in := range []string{`{}`, `[1,`, `[1] 2`, `[1 2]`, ``} {
+			_, err := NewIndexer(bytes.NewReader([]byte(in)), int64(len(in)))
+			require.Error(t, err, in)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package ojson
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+)
+
+// CoerceIntegers returns a copy of v in which every float64 that is an exact
+// integer within the range of an int64, such as 2.0, is replaced by an int64,
+// at every depth. json.Number and *big.Int values that are integers within
+// that range are replaced too. Other values are unchanged, and v itself is
+// not modified.
+func CoerceIntegers(v Value) Value {
+	return Value{V: coerceIntegers(v.V)}
+}
+
+func coerceIntegers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if i, ok := exactInt64(v); ok {
+			return i
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		return v
+	case *big.Int:
+		if v != nil && v.IsInt64() {
+			return v.Int64()
+		}
+		return v
+	case *Object:
+		if v == nil {
+			return v
+		}
+		obj := NewObjectWithCapacity(len(v.entries))
+		for _, kv := range v.entries {
+			obj.Set(kv.Key, coerceIntegers(kv.Value))
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = coerceIntegers(e)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// exactInt64 returns f as an int64 if it is an integer within the range of
+// an int64.
+func exactInt64(f float64) (int64, bool) {
+	// -2^63 is exactly representable, but 2^63-1 isn't, so the upper bound
+	// is exclusive.
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= -math.MinInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
@@ -0,0 +1,41 @@
+package ojson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoerceIntegers(tt *testing.T) {
+	require := require.New(tt)
+	v := MustNewValueFromJSON(`{"a":2.0,"b":[1.5,-3,1e3,1e19],"c":{"d":"2"}}`)
+	out := CoerceIntegers(v)
+
+	obj := out.V.(*Object)
+	a, _ := obj.Get("a")
+	require.Equal(int64(2), a)
+	b, _ := obj.Get("b")
+	require.Equal([]interface{}{1.5, int64(-3), int64(1000), 1e19}, b)
+	c, _ := obj.Get("c")
+	d, _ := c.(*Object).Get("d")
+	require.Equal("2", d)
+
+	encoded, err := json.Marshal(out)
+	require.NoError(err)
+	require.Equal(`{"a":2,"b":[1.5,-3,1000,10000000000000000000],"c":{"d":"2"}}`, string(encoded))
+
+	// The input is unchanged.
+	a, _ = v.V.(*Object).Get("a")
+	require.Equal(2.0, a)
+
+	n, err := DecodeOptions{BigNumbers: true}.Unmarshal([]byte(`[1, 9007199254740993]`))
+	require.NoError(err)
+	require.Equal([]interface{}{int64(1), int64(9007199254740993)}, CoerceIntegers(n).V)
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), -9223372036854775808, 9223372036854775807} {
+		_, ok := exactInt64(f)
+		require.Equal(f == -9223372036854775808, ok, f)
+	}
+}
@@ -0,0 +1,35 @@
+package ojson
+
+import "sync"
+
+// maxInternedKeys bounds the number of distinct keys a keyInterner retains,
+// so that documents with unbounded key sets can't grow it forever.
+const maxInternedKeys = 4096
+
+// keyInterner deduplicates object keys across decodes, so that documents
+// sharing a schema share their key strings instead of each allocating its
+// own copies. It is safe for concurrent use.
+type keyInterner struct {
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+// sharedKeys interns keys for decodes with DecodeOptions.InternKeys that
+// don't have an interner of their own.
+var sharedKeys = newKeyInterner()
+
+func newKeyInterner() *keyInterner {
+	return &keyInterner{keys: make(map[string]string)}
+}
+
+func (in *keyInterner) intern(k string) string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if s, ok := in.keys[k]; ok {
+		return s
+	}
+	if len(in.keys) < maxInternedKeys {
+		in.keys[k] = k
+	}
+	return k
+}
@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package ojson
+
+import "iter"
+
+// All returns an iterator over the Object's keys and values, in key order.
+// The Object must not be modified during iteration.
+func (o *Object) All() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		for _, kv := range o.entries {
+			if !yield(kv.Key, kv.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward is like All, but iterates from the last key to the first.
+func (o *Object) Backward() iter.Seq2[string, interface{}] {
+	return o.ReverseRange
+}
@@ -0,0 +1,43 @@
+//go:build go1.23
+
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAll(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"c":1,"a":2,"b":3}`).V.(*Object)
+
+	var keys []string
+	var values []interface{}
+	for k, v := range o.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	require.Equal([]string{"c", "a", "b"}, keys)
+	require.Equal([]interface{}{1.0, 2.0, 3.0}, values)
+
+	keys = nil
+	for k := range o.All() {
+		keys = append(keys, k)
+		if k == "a" {
+			break
+		}
+	}
+	require.Equal([]string{"c", "a"}, keys)
+}
+
+func TestBackward(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"c":1,"a":2,"b":3}`).V.(*Object)
+
+	var keys []string
+	for k := range o.Backward() {
+		keys = append(keys, k)
+	}
+	require.Equal([]string{"b", "a", "c"}, keys)
+}
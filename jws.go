@@ -0,0 +1,168 @@
+package ojson
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// This file implements JSON Web Signatures (RFC 7515) and JSON Web Tokens
+// (RFC 7519) in compact serialization, with headers and claims held in
+// Objects so that they're encoded in insertion order.
+
+// jwsAlgorithm returns the JWS "alg" value for the built-in Signers and
+// Verifiers.
+func jwsAlgorithm(k interface{}) (string, bool) {
+	switch k.(type) {
+	case HMACSHA256:
+		return "HS256", true
+	case Ed25519Signer, Ed25519Verifier:
+		return "EdDSA", true
+	default:
+		return "", false
+	}
+}
+
+// JWSSigningInput returns the input that is signed to produce a JWS with the
+// given protected header and payload: their base64url encodings joined by a
+// period. A payload that isn't a []byte is encoded as JSON.
+func JWSSigningInput(header *Object, payload interface{}) (string, error) {
+	h, err := header.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	p, err := jwsPayload(payload)
+	if err != nil {
+		return "", err
+	}
+	return b64url(h) + "." + b64url(p), nil
+}
+
+func jwsPayload(payload interface{}) ([]byte, error) {
+	if b, ok := payload.([]byte); ok {
+		return b, nil
+	}
+	e := newEncodeState()
+	if err := e.marshal(payload); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// SignJWS returns the compact serialization of a JWS of payload, which is
+// encoded as by JWSSigningInput. If header has no "alg", the signed header
+// is a copy of it with "alg" set according to s, which must then be one of
+// the Signers in this package; header itself is not modified. If detached
+// is true, the payload is omitted from the result (RFC 7515, appendix F) and
+// must be provided separately to VerifyJWS.
+func SignJWS(header *Object, payload interface{}, s Signer, detached bool) (string, error) {
+	if _, ok := header.Get("alg"); !ok {
+		alg, ok := jwsAlgorithm(s)
+		if !ok {
+			return "", fmt.Errorf("JWS header has no alg and it can't be determined from a %T", s)
+		}
+		h := NewObjectWithCapacity(header.Len() + 1)
+		for _, kv := range header.entries {
+			h.Set(kv.Key, kv.Value)
+		}
+		h.Set("alg", alg)
+		header = h
+	}
+	input, err := JWSSigningInput(header, payload)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.Sign([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	if detached {
+		input = input[:strings.IndexByte(input, '.')+1]
+	}
+	return input + "." + b64url(sig), nil
+}
+
+// VerifyJWS checks the signature of the compact JWS token with v and returns
+// its header and payload. If the token's payload is detached, it is taken
+// from detachedPayload instead, which is encoded as by JWSSigningInput. For
+// the Verifiers in this package, the header's "alg" must match the key.
+func VerifyJWS(token string, detachedPayload interface{}, v Verifier) (*Object, Value, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, Value{}, errors.New("malformed JWS: expected three segments")
+	}
+	h, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, Value{}, fmt.Errorf("malformed JWS header: %w", err)
+	}
+	hv, err := NewValueFromJSON(string(h))
+	if err != nil {
+		return nil, Value{}, fmt.Errorf("malformed JWS header: %w", err)
+	}
+	header, ok := hv.V.(*Object)
+	if !ok {
+		return nil, Value{}, errors.New("malformed JWS header: not an object")
+	}
+	if want, ok := jwsAlgorithm(v); ok {
+		if alg, _ := header.Get("alg"); alg != want {
+			return nil, Value{}, fmt.Errorf("JWS alg %v doesn't match %s key", alg, want)
+		}
+	}
+
+	var payload []byte
+	if parts[1] == "" {
+		if detachedPayload == nil {
+			return nil, Value{}, errors.New("JWS payload is detached but none was provided")
+		}
+		if payload, err = jwsPayload(detachedPayload); err != nil {
+			return nil, Value{}, err
+		}
+		parts[1] = b64url(payload)
+	} else if payload, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, Value{}, fmt.Errorf("malformed JWS payload: %w", err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, Value{}, ErrInvalidSignature
+	}
+	if err := v.Verify([]byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, Value{}, err
+	}
+	pv, err := NewValueFromJSON(string(payload))
+	if err != nil {
+		return nil, Value{}, fmt.Errorf("malformed JWS payload: %w", err)
+	}
+	return header, pv, nil
+}
+
+// SignJWT returns a signed JWT with the given claims, which are encoded in
+// order. Its header is {"alg":...,"typ":"JWT"}.
+func SignJWT(claims *Object, s Signer) (string, error) {
+	alg, ok := jwsAlgorithm(s)
+	if !ok {
+		return "", fmt.Errorf("can't determine the JWS alg of a %T", s)
+	}
+	header := NewObject().SetAndReturn("alg", alg).SetAndReturn("typ", "JWT")
+	return SignJWS(header, claims, s, false)
+}
+
+// ParseJWT verifies the signature of a JWT and returns its claims, in the
+// order they were encoded. It doesn't check time-based claims such as "exp"
+// and "nbf".
+func ParseJWT(token string, v Verifier) (*Object, error) {
+	_, payload, err := VerifyJWS(token, nil, v)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := payload.V.(*Object)
+	if !ok {
+		return nil, errors.New("JWT claims are not an object")
+	}
+	return claims, nil
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package ojson
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWT(tt *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(tt, err)
+
+	for _, test := range []struct {
+		name     string
+		signer   Signer
+		verifier Verifier
+		header   string
+	}{
+		{name: "hmac", signer: HMACSHA256("secret"), verifier: HMACSHA256("secret"), header: `{"alg":"HS256","typ":"JWT"}`},
+		{name: "ed25519", signer: Ed25519Signer(priv), verifier: Ed25519Verifier(pub), header: `{"alg":"EdDSA","typ":"JWT"}`},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			claims := NewObject().
+				SetAndReturn("sub", "user").
+				SetAndReturn("iss", "issuer").
+				SetAndReturn("custom_b", 1).
+				SetAndReturn("custom_a", true)
+			token, err := SignJWT(claims, test.signer)
+			require.NoError(err)
+
+			parts := strings.Split(token, ".")
+			require.Len(parts, 3)
+			h, err := base64.RawURLEncoding.DecodeString(parts[0])
+			require.NoError(err)
+			require.Equal(test.header, string(h))
+			p, err := base64.RawURLEncoding.DecodeString(parts[1])
+			require.NoError(err)
+			require.Equal(`{"sub":"user","iss":"issuer","custom_b":1,"custom_a":true}`, string(p))
+
+			parsed, err := ParseJWT(token, test.verifier)
+			require.NoError(err)
+			require.Equal([]string{"sub", "iss", "custom_b", "custom_a"}, parsed.KeyOrder())
+
+			_, err = ParseJWT(parts[0]+"."+b64url([]byte(`{"sub":"admin"}`))+"."+parts[2], test.verifier)
+			require.Equal(ErrInvalidSignature, err)
+		})
+	}
+
+	tt.Run("alg mismatch", func(t *testing.T) {
+		token, err := SignJWT(NewObject(), HMACSHA256("secret"))
+		require.NoError(t, err)
+		_, err = ParseJWT(token, Ed25519Verifier(pub))
+		require.Error(t, err)
+	})
+}
+
+func TestDetachedJWS(tt *testing.T) {
+	require := require.New(tt)
+	key := HMACSHA256("secret")
+	payload := MustNewValueFromJSON(`{"b":1,"a":2}`)
+
+	in := NewObject().SetAndReturn("kid", "1")
+	token, err := SignJWS(in, payload, key, true)
+	require.NoError(err)
+	// The caller's header is left as it was.
+	require.Equal([]string{"kid"}, in.KeyOrder())
+	require.Len(strings.Split(token, "."), 3)
+	require.Contains(token, "..")
+
+	header, got, err := VerifyJWS(token, payload, key)
+	require.NoError(err)
+	require.Equal([]string{"kid", "alg"}, header.KeyOrder())
+	require.Equal([]string{"b", "a"}, got.V.(*Object).KeyOrder())
+
+	_, _, err = VerifyJWS(token, MustNewValueFromJSON(`{"a":2,"b":1}`), key)
+	require.Equal(ErrInvalidSignature, err)
+	_, _, err = VerifyJWS(token, nil, key)
+	require.Error(err)
+}
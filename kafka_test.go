@@ -0,0 +1,109 @@
+package ojson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaSerializer(tt *testing.T) {
+	require := require.New(tt)
+	s := NewKafkaSerializer()
+	defer s.Close()
+
+	obj := NewObject().SetAndReturn("b", 1).SetAndReturn("a", []interface{}{"x"})
+	b, err := s.Serialize("events", obj)
+	require.NoError(err)
+	require.Equal(`{"b":1,"a":["x"]}`, string(b))
+
+	// Returned bytes must not be reused by later encodes.
+	b2, err := s.Encode(Value{V: "y"})
+	require.NoError(err)
+	require.Equal(`"y"`, string(b2))
+	require.Equal(`{"b":1,"a":["x"]}`, string(b))
+
+	cyclic := NewObject()
+	cyclic.Set("self", cyclic)
+	_, err = s.Encode(cyclic)
+	require.True(errors.Is(err, ErrCycle))
+	// The pooled state is still usable after a failure.
+	b, err = s.Encode(obj)
+	require.NoError(err)
+	require.Equal(`{"b":1,"a":["x"]}`, string(b))
+}
+
+func TestKafkaSerializerPool(tt *testing.T) {
+	require := require.New(tt)
+	s := &KafkaSerializer{MaxPooledSize: 16}
+
+	b, err := s.Encode(strings.Repeat("x", 100))
+	require.NoError(err)
+	require.Len(b, 102)
+	// Buffers over the cap aren't kept.
+	e, _ := s.pool.Get().(*encodeState)
+	require.True(e == nil || e.Cap() <= 16)
+
+	b, err = s.Encode([]interface{}{1})
+	require.NoError(err)
+	require.Equal(`[1]`, string(b))
+	// Other serializers have pools of their own.
+	other := NewKafkaSerializer()
+	e, _ = other.pool.Get().(*encodeState)
+	require.Nil(e)
+}
+
+func TestKafkaDeserializer(tt *testing.T) {
+	require := require.New(tt)
+	d := NewKafkaDeserializer()
+	defer d.Close()
+
+	v, err := d.Deserialize("events", []byte(`{"b":1,"a":{"c":null}}`))
+	require.NoError(err)
+	require.Equal(MustNewValueFromJSON(`{"b":1,"a":{"c":null}}`), v)
+
+	// Tombstones decode to null.
+	v, err = d.Deserialize("events", nil)
+	require.NoError(err)
+	require.Equal(Value{}, v)
+
+	var into Value
+	require.NoError(d.DeserializeInto("events", []byte(`[1,2]`), &into))
+	require.Equal(MustNewValueFromJSON(`[1,2]`), into)
+
+	var m map[string]interface{}
+	require.Error(d.Decode([]byte(`{}`), &m))
+	require.Error(d.Decode([]byte(`{"a":`), &into))
+}
+
+func TestKafkaDeserializerInternsKeys(tt *testing.T) {
+	require := require.New(tt)
+	d := NewKafkaDeserializer()
+
+	var wg sync.WaitGroup
+	vals := make([]Value, 8)
+	for i := range vals {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			require.NoError(d.Decode([]byte(`{"name":"x"}`), &vals[i]))
+		}(i)
+	}
+	wg.Wait()
+
+	first := vals[0].V.(*Object).KeyOrder()[0]
+	for _, v := range vals {
+		k := v.V.(*Object).KeyOrder()[0]
+		require.Equal("name", k)
+		require.True(stringData(first) == stringData(k))
+	}
+}
+
+// stringData returns the address of s's bytes.
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
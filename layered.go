@@ -0,0 +1,131 @@
+package ojson
+
+import "strconv"
+
+// Layered merges a sequence of documents, such as defaults, environment
+// settings, and overrides, into one, and records which layer supplied each
+// value. Objects are merged recursively: keys keep the position they had in
+// the first layer that set them, and new keys are appended. Any other value,
+// including arrays and null, replaces the value below it.
+type Layered struct {
+	names []string
+	root  interface{}
+	// provenance maps the JSON Pointer of every value in root to the name of
+	// the layer that supplied it.
+	provenance map[string]string
+}
+
+// NewLayered returns an empty Layered.
+func NewLayered() *Layered {
+	return &Layered{provenance: make(map[string]string)}
+}
+
+// Add merges v on top of the existing layers. v is not modified, and isn't
+// shared with the merged result.
+func (l *Layered) Add(name string, v Value) {
+	l.names = append(l.names, name)
+	l.root = l.merge(l.root, v.V, "", name)
+}
+
+// AddJSON parses b and merges it on top of the existing layers.
+func (l *Layered) AddJSON(name string, b []byte) error {
+	var v Value
+	if err := v.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	l.Add(name, v)
+	return nil
+}
+
+// Layers returns the names of the layers, in the order they were added.
+func (l *Layered) Layers() []string {
+	return l.names
+}
+
+// Value returns the merged document. It must not be modified.
+func (l *Layered) Value() Value {
+	return Value{V: l.root}
+}
+
+// Provenance returns the name of the layer that supplied the value at the
+// given JSON Pointer. For objects merged from several layers, that is the
+// last layer that contributed to them. It returns false if there is no value
+// at the pointer.
+func (l *Layered) Provenance(pointer string) (string, bool) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := resolvePointer(l.root, tokens); !ok || len(l.names) == 0 {
+		return "", false
+	}
+	name, ok := l.provenance[pointer]
+	return name, ok
+}
+
+func (l *Layered) merge(dst, src interface{}, path, layer string) interface{} {
+	s, ok := src.(*Object)
+	if !ok || s == nil {
+		v := copyValue(src)
+		l.record(v, path, layer)
+		return v
+	}
+	l.provenance[path] = layer
+	d, ok := dst.(*Object)
+	if !ok || d == nil {
+		d = NewObject()
+	}
+	d.grow(len(s.entries))
+	for _, kv := range s.entries {
+		old, _ := d.Get(kv.Key)
+		d.Set(kv.Key, l.merge(old, kv.Value, path+"/"+escapePointerToken(kv.Key), layer))
+	}
+	return d
+}
+
+// record attributes v, and everything in it, to layer.
+func (l *Layered) record(v interface{}, path, layer string) {
+	l.provenance[path] = layer
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return
+		}
+		for _, kv := range v.entries {
+			l.record(kv.Value, path+"/"+escapePointerToken(kv.Key), layer)
+		}
+	case []interface{}:
+		for i, e := range v {
+			l.record(e, path+"/"+strconv.Itoa(i), layer)
+		}
+	}
+}
+
+// copyValue returns a deep copy of the Objects and arrays in v. Other values
+// are shared.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return v
+		}
+		obj := NewObjectWithCapacity(len(v.entries))
+		for _, kv := range v.entries {
+			obj.Set(kv.Key, copyValue(kv.Value))
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = copyValue(e)
+		}
+		return arr
+	case Value:
+		return Value{V: copyValue(v.V)}
+	default:
+		return v
+	}
+}
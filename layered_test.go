@@ -0,0 +1,58 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayered(tt *testing.T) {
+	require := require.New(tt)
+	defaults := MustNewValueFromJSON(`{"server":{"host":"localhost","port":8080},"log":{"level":"info"},"features":["a"]}`)
+	l := NewLayered()
+	l.Add("defaults", defaults)
+	require.NoError(l.AddJSON("env", []byte(`{"server":{"port":9090,"tls":true},"features":["b","c"]}`)))
+	require.NoError(l.AddJSON("overrides", []byte(`{"log":"stderr","debug":true}`)))
+	require.Error(l.AddJSON("bad", []byte(`{`)))
+	require.Equal([]string{"defaults", "env", "overrides"}, l.Layers())
+
+	b, err := json.Marshal(l.Value())
+	require.NoError(err)
+	require.Equal(`{"server":{"host":"localhost","port":9090,"tls":true},"log":"stderr","features":["b","c"],"debug":true}`, string(b))
+
+	for pointer, layer := range map[string]string{
+		"":             "overrides",
+		"/server":      "env",
+		"/server/host": "defaults",
+		"/server/port": "env",
+		"/server/tls":  "env",
+		"/log":         "overrides",
+		"/features":    "env",
+		"/features/1":  "env",
+		"/debug":       "overrides",
+	} {
+		got, ok := l.Provenance(pointer)
+		require.True(ok, pointer)
+		require.Equal(layer, got, pointer)
+	}
+
+	// Values that were replaced no longer have a provenance.
+	for _, pointer := range []string{"/log/level", "/features/2", "/missing", "bad"} {
+		_, ok := l.Provenance(pointer)
+		require.False(ok, pointer)
+	}
+
+	// The layers aren't modified.
+	b, err = json.Marshal(defaults)
+	require.NoError(err)
+	require.Equal(`{"server":{"host":"localhost","port":8080},"log":{"level":"info"},"features":["a"]}`, string(b))
+}
+
+func TestLayeredEmpty(tt *testing.T) {
+	require := require.New(tt)
+	l := NewLayered()
+	require.Equal(Value{}, l.Value())
+	_, ok := l.Provenance("")
+	require.False(ok)
+}
@@ -0,0 +1,257 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LazyValue is a JSON value stored in an io.ReaderAt, such as an *os.File,
+// that is only read when it is accessed. Navigating into a LazyValue reads
+// the input a token at a time without keeping it in memory, and returns the
+// child as another LazyValue recording just its byte offsets, so documents
+// far larger than memory can be explored. Only Decode materializes a value.
+//
+// The input must not change while LazyValues referring to it are in use.
+type LazyValue struct {
+	r     io.ReaderAt
+	opts  DecodeOptions
+	start int64
+	end   int64
+	kind  NodeKind
+}
+
+// NewLazyValue returns a LazyValue for the JSON document in the first size
+// bytes of r, which is decoded according to opts when materialized. The
+// document is checked for syntax errors only as it is read.
+func NewLazyValue(r io.ReaderAt, size int64, opts DecodeOptions) (*LazyValue, error) {
+	return newLazyValue(r, opts, 0, size)
+}
+
+// newLazyValue returns the LazyValue for the value that starts at or after
+// start, skipping whitespace and separators, and ends by end.
+func newLazyValue(r io.ReaderAt, opts DecodeOptions, start, end int64) (*LazyValue, error) {
+	var buf [64]byte
+	for start < end {
+		n, err := r.ReadAt(buf[:], start)
+		if n == 0 {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		for _, c := range buf[:n] {
+			if !isSpace(c) && c != ':' && c != ',' {
+				kind, ok := lazyKind(c)
+				if !ok {
+					return nil, fmt.Errorf("invalid character %q looking for beginning of value", c)
+				}
+				return &LazyValue{r: r, opts: opts, start: start, end: end, kind: kind}, nil
+			}
+			start++
+		}
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+func lazyKind(c byte) (NodeKind, bool) {
+	switch {
+	case c == '{':
+		return ObjectNode, true
+	case c == '[':
+		return ArrayNode, true
+	case c == '"':
+		return StringNode, true
+	case c == 't' || c == 'f':
+		return BoolNode, true
+	case c == 'n':
+		return NullNode, true
+	case c == '-' || isDigit(c):
+		return NumberNode, true
+	default:
+		return 0, false
+	}
+}
+
+// Kind returns the type of the value.
+func (l *LazyValue) Kind() NodeKind {
+	return l.kind
+}
+
+// Offset returns the byte offset in the input at which the value starts.
+func (l *LazyValue) Offset() int64 {
+	return l.start
+}
+
+// Decode reads and decodes the whole value.
+func (l *LazyValue) Decode() (Value, error) {
+	b := make([]byte, l.end-l.start)
+	if n, err := l.r.ReadAt(b, l.start); n < len(b) {
+		return Value{}, err
+	}
+	var v Value
+	err := newDecodeState(b, l.opts).decodeAll(&v)
+	return v, err
+}
+
+// Get returns the value of k in the object. If k appears more than once, the
+// last value is returned, as when decoding.
+func (l *LazyValue) Get(k string) (*LazyValue, bool, error) {
+	var found *LazyValue
+	err := l.eachMember(func(key string, v *LazyValue) bool {
+		if key == k {
+			found = v
+		}
+		return true
+	})
+	return found, found != nil, err
+}
+
+// Keys returns the keys of the object, in order.
+func (l *LazyValue) Keys() ([]string, error) {
+	keys := []string{}
+	err := l.eachMember(func(key string, _ *LazyValue) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys, err
+}
+
+// Index returns the i'th element of the array.
+func (l *LazyValue) Index(i int) (*LazyValue, error) {
+	var found *LazyValue
+	n := 0
+	err := l.eachElement(func(v *LazyValue) bool {
+		if n == i {
+			found = v
+			return false
+		}
+		n++
+		return true
+	})
+	if err == nil && found == nil {
+		err = fmt.Errorf("array index %d out of range with length %d", i, n)
+	}
+	return found, err
+}
+
+// Len returns the number of members of an object or elements of an array.
+func (l *LazyValue) Len() (int, error) {
+	n := 0
+	count := func() bool {
+		n++
+		return true
+	}
+	var err error
+	switch l.kind {
+	case ObjectNode:
+		err = l.eachMember(func(string, *LazyValue) bool { return count() })
+	case ArrayNode:
+		err = l.eachElement(func(*LazyValue) bool { return count() })
+	default:
+		err = errors.New("value is not an object or array")
+	}
+	return n, err
+}
+
+// Pointer returns the value at the given JSON Pointer within l.
+func (l *LazyValue) Pointer(pointer string) (*LazyValue, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	v := l
+	for _, t := range tokens {
+		switch v.kind {
+		case ObjectNode:
+			next, ok, err := v.Get(t)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q not found", pointer)
+			}
+			v = next
+		case ArrayNode:
+			i, ok := parseArrayIndex(t)
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q not found", pointer)
+			}
+			if v, err = v.Index(i); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("json pointer %q not found", pointer)
+		}
+	}
+	return v, nil
+}
+
+// decoder returns a json.Decoder that reads the value from the input. Its
+// input offsets are relative to l.start.
+func (l *LazyValue) decoder() *json.Decoder {
+	return json.NewDecoder(io.NewSectionReader(l.r, l.start, l.end-l.start))
+}
+
+// eachMember calls fn with each member of the object until fn returns false.
+func (l *LazyValue) eachMember(fn func(k string, v *LazyValue) bool) error {
+	if l.kind != ObjectNode {
+		return ErrNotObject
+	}
+	dec := l.decoder()
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		k, ok := t.(string)
+		if !ok {
+			return errors.New("expected string key in object")
+		}
+		v, err := l.child(dec)
+		if err != nil {
+			return err
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// eachElement calls fn with each element of the array until fn returns
+// false.
+func (l *LazyValue) eachElement(fn func(v *LazyValue) bool) error {
+	if l.kind != ArrayNode {
+		return ErrNotArray
+	}
+	dec := l.decoder()
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		v, err := l.child(dec)
+		if err != nil {
+			return err
+		}
+		if !fn(v) {
+			return nil
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// child skips over the next value in dec, and returns a LazyValue for it.
+func (l *LazyValue) child(dec *json.Decoder) (*LazyValue, error) {
+	start := l.start + dec.InputOffset()
+	if err := skipValue(dec); err != nil {
+		return nil, err
+	}
+	return newLazyValue(l.r, l.opts, start, l.start+dec.InputOffset())
+}
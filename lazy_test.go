@@ -0,0 +1,110 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyValue(tt *testing.T) {
+	const in = ` {"name":"big", "items": [ {"id":1,"tags":["a"]}, {"id":2,"b":2,"a":1} ],
+	"meta":{"count":2,"count":3}, "n":1.5e3}`
+
+	open := func(t *testing.T, in string) *LazyValue {
+		l, err := NewLazyValue(bytes.NewReader([]byte(in)), int64(len(in)), DecodeOptions{})
+		require.NoError(t, err)
+		return l
+	}
+	decode := func(t *testing.T, l *LazyValue) string {
+		v, err := l.Decode()
+		require.NoError(t, err)
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	tt.Run("navigate", func(t *testing.T) {
+		require := require.New(t)
+		l := open(t, in)
+		require.Equal(ObjectNode, l.Kind())
+
+		keys, err := l.Keys()
+		require.NoError(err)
+		require.Equal([]string{"name", "items", "meta", "n"}, keys)
+
+		items, ok, err := l.Get("items")
+		require.NoError(err)
+		require.True(ok)
+		require.Equal(ArrayNode, items.Kind())
+		n, err := items.Len()
+		require.NoError(err)
+		require.Equal(2, n)
+
+		item, err := items.Index(1)
+		require.NoError(err)
+		require.Equal(`{"id":2,"b":2,"a":1}`, decode(t, item))
+		require.Equal(int64(50), item.Offset())
+
+		_, err = items.Index(2)
+		require.EqualError(err, "array index 2 out of range with length 2")
+
+		_, ok, err = l.Get("missing")
+		require.NoError(err)
+		require.False(ok)
+	})
+
+	tt.Run("pointer", func(t *testing.T) {
+		require := require.New(t)
+		l := open(t, in)
+		for pointer, out := range map[string]string{
+			"":                `{"name":"big","items":[{"id":1,"tags":["a"]},{"id":2,"b":2,"a":1}],"meta":{"count":3},"n":1500}`,
+			"/items/0/tags/0": `"a"`,
+			"/meta/count":     `3`,
+			"/n":              `1500`,
+		} {
+			v, err := l.Pointer(pointer)
+			require.NoError(err)
+			require.Equal(out, decode(t, v), pointer)
+		}
+		_, err := l.Pointer("/name/x")
+		require.EqualError(err, `json pointer "/name/x" not found`)
+		_, err = l.Pointer("/items/x")
+		require.Error(err)
+	})
+
+	tt.Run("file", func(t *testing.T) {
+		require := require.New(t)
+		path := filepath.Join(t.TempDir(), "doc.json")
+		require.NoError(os.WriteFile(path, []byte(in), 0o600))
+		f, err := os.Open(path)
+		require.NoError(err)
+		defer f.Close()
+		info, err := f.Stat()
+		require.NoError(err)
+
+		l, err := NewLazyValue(f, info.Size(), DecodeOptions{Decimals: true})
+		require.NoError(err)
+		v, err := l.Pointer("/n")
+		require.NoError(err)
+		require.Equal(NumberNode, v.Kind())
+		require.Equal(`1500`, decode(t, v))
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		_, err := NewLazyValue(bytes.NewReader(nil), 0, DecodeOptions{})
+		require.Error(err)
+		_, err = NewLazyValue(bytes.NewReader([]byte("  x")), 3, DecodeOptions{})
+		require.Error(err)
+
+		l := open(t, `{"a":[1,}`)
+		_, _, err = l.Get("a")
+		require.Error(err)
+		_, err = open(t, `[1]`).Keys()
+		require.EqualError(err, "value is not an object")
+	})
+}
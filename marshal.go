@@ -0,0 +1,53 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+var errTooManyOptions = errors.New("ojson: at most one options value may be given")
+
+// Marshal encodes v as JSON, keeping the order of the keys of Objects. v may
+// be a Value, an *Object, or any value encoding/json can marshal. At most
+// one MarshalOptions may be given; without one, v is encoded the same way as
+// by json.Marshal, apart from key order.
+func Marshal(v interface{}, opts ...MarshalOptions) ([]byte, error) {
+	switch len(opts) {
+	case 0:
+		return MarshalOptions{}.Marshal(v)
+	case 1:
+		return opts[0].Marshal(v)
+	default:
+		return nil, errTooManyOptions
+	}
+}
+
+// MarshalIndent is like Marshal, but writes each object member and array
+// element on its own line, starting with prefix and followed by one copy of
+// indent per level of nesting, as json.MarshalIndent does.
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the JSON text b into a Value, with objects decoded as
+// *Object. At most one DecodeOptions may be given; without one, b is decoded
+// the same way as by Value.UnmarshalJSON.
+func Unmarshal(b []byte, opts ...DecodeOptions) (Value, error) {
+	switch len(opts) {
+	case 0:
+		return DecodeOptions{}.Unmarshal(b)
+	case 1:
+		return opts[0].Unmarshal(b)
+	default:
+		return Value{}, errTooManyOptions
+	}
+}
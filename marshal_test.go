@@ -0,0 +1,75 @@
+package ojson
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageMarshal(tt *testing.T) {
+	tt.Run("defaults", func(t *testing.T) {
+		require := require.New(t)
+		v, err := Unmarshal([]byte(`{"b":[1,{"d":2,"c":3}],"a":null}`))
+		require.NoError(err)
+		b, err := Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"b":[1,{"d":2,"c":3}],"a":null}`, string(b))
+
+		b, err = Marshal(v.V.(*Object))
+		require.NoError(err)
+		require.Equal(`{"b":[1,{"d":2,"c":3}],"a":null}`, string(b))
+
+		b, err = Marshal(map[string]interface{}{"y": NewObject().SetAndReturn("z", 1).SetAndReturn("x", 2)})
+		require.NoError(err)
+		require.Equal(`{"y":{"z":1,"x":2}}`, string(b))
+	})
+
+	tt.Run("options", func(t *testing.T) {
+		require := require.New(t)
+		v, err := Unmarshal([]byte(`{"n":123456789012345678901234567890}`), DecodeOptions{BigNumbers: true})
+		require.NoError(err)
+		n, _ := v.V.(*Object).Get("n")
+		require.IsType(&big.Int{}, n)
+
+		b, err := Marshal(NewObject().SetAndReturn("b", 1).SetAndReturn("a", 2), MarshalOptions{SortKeys: NaturalCompare})
+		require.NoError(err)
+		require.Equal(`{"a":2,"b":1}`, string(b))
+	})
+
+	tt.Run("too many options", func(t *testing.T) {
+		require := require.New(t)
+		_, err := Unmarshal([]byte(`1`), DecodeOptions{}, DecodeOptions{})
+		require.Error(err)
+		_, err = Marshal(1, MarshalOptions{}, MarshalOptions{})
+		require.Error(err)
+	})
+}
+
+func TestMarshalIndent(tt *testing.T) {
+	require := require.New(tt)
+	v := MustNewValueFromJSON(`{"b":[1,{"d":2,"c":"x"}],"a":{},"e":[]}`)
+
+	b, err := MarshalIndent(v, "", "  ")
+	require.NoError(err)
+	require.Equal(`{
+  "b": [
+    1,
+    {
+      "d": 2,
+      "c": "x"
+    }
+  ],
+  "a": {},
+  "e": []
+}`, string(b))
+
+	b, err = v.V.(*Object).MarshalIndent("> ", "\t")
+	require.NoError(err)
+	require.Equal("{\n> \t\"b\": [\n> \t\t1,\n> \t\t{\n> \t\t\t\"d\": 2,\n> \t\t\t\"c\": \"x\"\n> \t\t}\n> \t],\n> \t\"a\": {},\n> \t\"e\": []\n> }", string(b))
+
+	o := NewObject()
+	o.Set("self", o)
+	_, err = MarshalIndent(o, "", "  ")
+	require.Equal(ErrCycle, err)
+}
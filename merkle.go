@@ -0,0 +1,260 @@
+package ojson
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+)
+
+// HashTree caches a SHA-256 digest for every subtree of a document, so that
+// after changing one value only its ancestors need to be re-hashed, and so
+// that two documents can be compared top-down by descending only into the
+// subtrees whose digests differ.
+//
+// Digests depend on key order: two objects with the same members in a
+// different order have different digests.
+type HashTree struct {
+	v    Value
+	root *hashNode
+}
+
+type hashNode struct {
+	sum [sha256.Size]byte
+	// keys holds the keys of an object node, parallel to children.
+	keys []string
+	// children holds the nodes of an object's values or an array's elements.
+	// It is nil for scalars.
+	children []*hashNode
+	object   bool
+	array    bool
+}
+
+// Domain separation prefixes, so that e.g. an array can't collide with a
+// scalar whose encoding happens to match its children's digests.
+const (
+	hashScalar byte = iota
+	hashArray
+	hashObject
+)
+
+// NewHashTree hashes every subtree of v. The HashTree keeps a reference to
+// v, which must then only be modified through the HashTree's Set method.
+func NewHashTree(v Value) (*HashTree, error) {
+	root, err := buildHashNode(v.V)
+	if err != nil {
+		return nil, err
+	}
+	return &HashTree{v: v, root: root}, nil
+}
+
+func buildHashNode(v interface{}) (*hashNode, error) {
+	n := &hashNode{}
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			break
+		}
+		n.object = true
+		for _, kv := range v.entries {
+			c, err := buildHashNode(kv.Value)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, kv.Key)
+			n.children = append(n.children, c)
+		}
+		n.rehash()
+		return n, nil
+	case []interface{}:
+		if v == nil {
+			break
+		}
+		n.array = true
+		for _, e := range v {
+			c, err := buildHashNode(e)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, c)
+		}
+		n.rehash()
+		return n, nil
+	}
+	b, err := Canonicalize(v)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write([]byte{hashScalar})
+	h.Write(b)
+	h.Sum(n.sum[:0])
+	return n, nil
+}
+
+// rehash recomputes the digest of a container node from its children's.
+func (n *hashNode) rehash() {
+	h := sha256.New()
+	if n.object {
+		h.Write([]byte{hashObject})
+		for i, k := range n.keys {
+			writeLengthPrefixed(h, []byte(k))
+			h.Write(n.children[i].sum[:])
+		}
+	} else {
+		h.Write([]byte{hashArray})
+		for _, c := range n.children {
+			h.Write(c.sum[:])
+		}
+	}
+	h.Sum(n.sum[:0])
+}
+
+func writeLengthPrefixed(h hash.Hash, b []byte) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))])
+	h.Write(b)
+}
+
+// Value returns the document the HashTree was built from.
+func (t *HashTree) Value() Value {
+	return t.v
+}
+
+// Sum returns the digest of the whole document.
+func (t *HashTree) Sum() [sha256.Size]byte {
+	return t.root.sum
+}
+
+// SumAt returns the digest of the subtree at the given JSON Pointer.
+func (t *HashTree) SumAt(pointer string) ([sha256.Size]byte, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	n := t.root
+	for _, tok := range tokens {
+		i, ok := n.index(tok)
+		if !ok {
+			return [sha256.Size]byte{}, fmt.Errorf("no value at %q", pointer)
+		}
+		n = n.children[i]
+	}
+	return n.sum, nil
+}
+
+func (n *hashNode) index(tok string) (int, bool) {
+	if n.object {
+		for i := len(n.keys) - 1; i >= 0; i-- {
+			if n.keys[i] == tok {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	i, ok := parseArrayIndex(tok)
+	return i, ok && i < len(n.children)
+}
+
+// Set sets the value at the given JSON Pointer, which must refer to an
+// existing value or to a new key of an existing object, and re-hashes only
+// the new value and its ancestors.
+func (t *HashTree) Set(pointer string, v interface{}) error {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	c, err := buildHashNode(v)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		t.v.V = v
+		t.root = c
+		return nil
+	}
+
+	path := []*hashNode{t.root}
+	val := t.v.V
+	for _, tok := range tokens[:len(tokens)-1] {
+		n := path[len(path)-1]
+		i, ok := n.index(tok)
+		if !ok {
+			return fmt.Errorf("no value at %q", pointer)
+		}
+		path = append(path, n.children[i])
+		val, _ = resolvePointer(val, []string{tok})
+	}
+
+	parent := path[len(path)-1]
+	last := tokens[len(tokens)-1]
+	switch container := val.(type) {
+	case *Object:
+		if i, ok := parent.index(last); ok {
+			parent.children[i] = c
+		} else {
+			parent.keys = append(parent.keys, last)
+			parent.children = append(parent.children, c)
+		}
+		container.Set(last, v)
+	case []interface{}:
+		i, ok := parent.index(last)
+		if !ok {
+			return fmt.Errorf("invalid array index %q", last)
+		}
+		parent.children[i] = c
+		container[i] = v
+	default:
+		return errors.New("cannot set a value inside a scalar")
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].rehash()
+	}
+	return nil
+}
+
+// DiffHashTrees returns the JSON Pointers of the outermost values that
+// differ between a and b, in document order. It only descends into subtrees
+// whose digests differ, and into objects and arrays present in both; an
+// array whose length changed, or a value whose type changed, is reported as
+// a whole.
+func DiffHashTrees(a, b *HashTree) []string {
+	var changed []string
+	diffHashNodes(a.root, b.root, "", &changed)
+	return changed
+}
+
+func diffHashNodes(a, b *hashNode, path string, changed *[]string) {
+	switch {
+	case a.sum == b.sum:
+		return
+	case a.object && b.object:
+		n := len(*changed)
+		for i, k := range a.keys {
+			p := path + "/" + escapePointerToken(k)
+			if j, ok := b.index(k); ok {
+				diffHashNodes(a.children[i], b.children[j], p, changed)
+			} else {
+				*changed = append(*changed, p)
+			}
+		}
+		for _, k := range b.keys {
+			if _, ok := a.index(k); !ok {
+				*changed = append(*changed, path+"/"+escapePointerToken(k))
+			}
+		}
+		if len(*changed) == n {
+			// Only the key order differs.
+			*changed = append(*changed, path)
+		}
+	case a.array && b.array && len(a.children) == len(b.children):
+		for i := range a.children {
+			diffHashNodes(a.children[i], b.children[i], path+"/"+strconv.Itoa(i), changed)
+		}
+	default:
+		*changed = append(*changed, path)
+	}
+}
@@ -0,0 +1,66 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashTree(tt *testing.T) {
+	const doc = `{"a":{"b":[1,2,{"c":"x"}],"d":true},"e":null}`
+
+	tt.Run("set rehashes ancestors", func(t *testing.T) {
+		require := require.New(t)
+		tree, err := NewHashTree(MustNewValueFromJSON(doc))
+		require.NoError(err)
+		before := tree.Sum()
+		dBefore, err := tree.SumAt("/a/d")
+		require.NoError(err)
+
+		require.NoError(tree.Set("/a/b/2/c", "y"))
+		require.NotEqual(before, tree.Sum())
+		dAfter, err := tree.SumAt("/a/d")
+		require.NoError(err)
+		require.Equal(dBefore, dAfter)
+
+		// The cached digests match hashing the edited document from scratch.
+		fresh, err := NewHashTree(MustNewValueFromJSON(`{"a":{"b":[1,2,{"c":"y"}],"d":true},"e":null}`))
+		require.NoError(err)
+		require.Equal(fresh.Sum(), tree.Sum())
+		b, err := tree.Value().MarshalJSON()
+		require.NoError(err)
+		require.Equal(`{"a":{"b":[1,2,{"c":"y"}],"d":true},"e":null}`, string(b))
+
+		require.NoError(tree.Set("/a/b/2/c", "x"))
+		require.Equal(before, tree.Sum())
+
+		require.NoError(tree.Set("/a/new", 1))
+		require.Error(tree.Set("/a/b/3", 1))
+		require.Error(tree.Set("/missing/x", 1))
+	})
+
+	tt.Run("diff", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			other   string
+			changed []string
+		}{
+			{name: "equal", other: doc},
+			{name: "leaf", other: `{"a":{"b":[1,3,{"c":"x"}],"d":true},"e":null}`, changed: []string{"/a/b/1"}},
+			{name: "several", other: `{"a":{"b":[1,2,{"c":"z"}],"d":false},"e":null}`, changed: []string{"/a/b/2/c", "/a/d"}},
+			{name: "added and removed keys", other: `{"a":{"b":[1,2,{"c":"x"}]},"e":null,"f":1}`, changed: []string{"/a/d", "/f"}},
+			{name: "array length", other: `{"a":{"b":[1,2],"d":true},"e":null}`, changed: []string{"/a/b"}},
+			{name: "key order", other: `{"a":{"d":true,"b":[1,2,{"c":"x"}]},"e":null}`, changed: []string{"/a"}},
+			{name: "type", other: `{"a":{"b":{},"d":true},"e":null}`, changed: []string{"/a/b"}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				require := require.New(t)
+				a, err := NewHashTree(MustNewValueFromJSON(doc))
+				require.NoError(err)
+				b, err := NewHashTree(MustNewValueFromJSON(test.other))
+				require.NoError(err)
+				require.Equal(test.changed, DiffHashTrees(a, b))
+			})
+		}
+	})
+}
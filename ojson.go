@@ -1,11 +1,16 @@
 package ojson
 
 import (
-	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Value represents a JSON value unmarshaled from a string that maintains
@@ -21,37 +26,264 @@ var _ json.Unmarshaler = &Value{}
 var _ json.Marshaler = Value{}
 var _ sql.Scanner = &Value{}
 var _ driver.Valuer = Value{}
+var _ io.WriterTo = Value{}
 
 // Object represents a JSON object that maintains key ordering.
 type Object struct {
-	keyOrder []string
-	values   map[string]interface{}
+	// entries holds the Object's entries in key order.
+	entries []Entry
+	// index maps each key to its position in entries. It is only built once
+	// there are more than indexThreshold entries; smaller Objects, which are
+	// the most common, are searched linearly, which is about as fast and
+	// saves the memory of a map.
+	index map[string]int
+
+	// positions is set when decoding with DecodeOptions.Positions.
+	positions *objectPositions
+	// comments holds comments attached to entries, by key.
+	comments map[string]entryComments
+	// rawKeys holds the quoted keys, as they appeared in the source, of an
+	// Object decoded with DecodeOptions.PreserveStringEscapes.
+	rawKeys map[string]string
 }
 
+// indexThreshold is the number of entries past which an Object indexes its
+// keys with a map.
+const indexThreshold = 8
+
 var _ json.Marshaler = Object{}
+var _ json.Unmarshaler = &Object{}
+var _ sql.Scanner = &Object{}
+var _ driver.Valuer = Object{}
+var _ io.WriterTo = &Object{}
+
+// ErrNotObject is returned when a value that should be an object is not.
+var ErrNotObject = errors.New("value is not an object")
+
+// ErrUnexpectedDelimiter is returned when decoding finds a closing delimiter
+// where a value was expected, or one that doesn't match the object or array
+// it closes.
+var ErrUnexpectedDelimiter = errors.New("unexpected delimiter")
+
+// ScanTypeError is returned by Scan when the database value is of a type
+// that can't hold JSON.
+type ScanTypeError struct {
+	// Src is the value that was passed to Scan.
+	Src interface{}
+}
+
+func (e *ScanTypeError) Error() string {
+	return fmt.Sprintf("cannot scan %T as JSON, expected []byte", e.Src)
+}
 
 func NewObject() *Object {
-	return &Object{
-		keyOrder: make([]string, 0),
-		values:   make(map[string]interface{}),
+	return &Object{}
+}
+
+// NewObjectWithCapacity returns an empty Object with room for n keys, so
+// that setting up to n keys doesn't need to reallocate.
+func NewObjectWithCapacity(n int) *Object {
+	return &Object{entries: make([]Entry, 0, n)}
+}
+
+// find returns the position of k in entries, or -1 if k is not present.
+func (o *Object) find(k string) int {
+	if o.index != nil {
+		if i, ok := o.index[k]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := range o.entries {
+		if o.entries[i].Key == k {
+			return i
+		}
+	}
+	return -1
+}
+
+// reindex updates the index for the entries from position i onwards, which
+// have moved, building or dropping the index if the Object has grown past or
+// shrunk to indexThreshold entries.
+func (o *Object) reindex(i int) {
+	if len(o.entries) <= indexThreshold {
+		o.index = nil
+		return
+	}
+	if o.index == nil {
+		// Size the index for the capacity of entries, which is how large
+		// the Object is expected to grow.
+		o.index = make(map[string]int, cap(o.entries))
+		i = 0
+	}
+	for ; i < len(o.entries); i++ {
+		o.index[o.entries[i].Key] = i
 	}
 }
 
 func (o *Object) Get(k string) (interface{}, bool) {
-	v, ok := o.values[k]
-	return v, ok
+	if i := o.find(k); i >= 0 {
+		return o.entries[i].Value, true
+	}
+	return nil, false
+}
+
+// Has reports whether k is present in the Object.
+func (o *Object) Has(k string) bool {
+	return o.find(k) >= 0
+}
+
+// GetFold is like Get, but matches keys case-insensitively, under Unicode
+// case folding. If several keys match, an exact match is preferred, and
+// otherwise the first match in key order is returned.
+func (o *Object) GetFold(k string) (interface{}, bool) {
+	if v, ok := o.Get(k); ok {
+		return v, true
+	}
+	for _, e := range o.entries {
+		if strings.EqualFold(e.Key, k) {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// GetAny returns the value of the first of keys that is present in the
+// Object, along with that key. It is useful when the same field may appear
+// under several names.
+func (o *Object) GetAny(keys ...string) (string, interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := o.Get(k); ok {
+			return k, v, true
+		}
+	}
+	return "", nil, false
 }
 
 func (o *Object) Set(k string, v interface{}) {
 	// Use original order if inserting twice.
-	if _, ok := o.values[k]; !ok {
-		o.keyOrder = append(o.keyOrder, k)
+	if i := o.find(k); i >= 0 {
+		o.entries[i].Value = v
+		return
 	}
-	o.values[k] = v
+	o.entries = append(o.entries, Entry{Key: k, Value: v})
+	o.reindex(len(o.entries) - 1)
 }
 
+// Delete removes k, along with any comments attached to it, from the Object.
+// The remaining keys keep their order. It reports whether k was present.
+func (o *Object) Delete(k string) bool {
+	i := o.find(k)
+	if i < 0 {
+		return false
+	}
+	delete(o.comments, k)
+	delete(o.rawKeys, k)
+	copy(o.entries[i:], o.entries[i+1:])
+	o.entries[len(o.entries)-1] = Entry{}
+	o.entries = o.entries[:len(o.entries)-1]
+	if o.index != nil {
+		delete(o.index, k)
+	}
+	o.reindex(i)
+	return true
+}
+
+// Len returns the number of keys in the Object.
+func (o *Object) Len() int {
+	return len(o.entries)
+}
+
+// KeyOrder returns the Object's keys in order. The slice is a copy.
 func (o *Object) KeyOrder() []string {
-	return o.keyOrder
+	keys := make([]string, len(o.entries))
+	for i, e := range o.entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Range calls fn for each key and value in the Object, in key order, until fn
+// returns false. The Object must not be modified during iteration.
+func (o *Object) Range(fn func(k string, v interface{}) bool) {
+	for _, e := range o.entries {
+		if !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// ReverseRange is like Range, but visits the keys from last to first.
+func (o *Object) ReverseRange(fn func(k string, v interface{}) bool) {
+	for i := len(o.entries) - 1; i >= 0; i-- {
+		if !fn(o.entries[i].Key, o.entries[i].Value) {
+			return
+		}
+	}
+}
+
+// Entry is a key and its value in an Object.
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// Entries returns the Object's entries in key order. The slice is a copy, so
+// modifying it does not affect the Object, but the values themselves are
+// not copied.
+func (o *Object) Entries() []Entry {
+	return append([]Entry{}, o.entries...)
+}
+
+// Index returns the position of k in the Object's key order, or -1 if k is
+// not present.
+func (o *Object) Index(k string) int {
+	return o.find(k)
+}
+
+// GetAt returns the key and value of the i'th entry in the Object. It panics
+// if i is out of range.
+func (o *Object) GetAt(i int) (string, interface{}) {
+	e := o.entries[i]
+	return e.Key, e.Value
+}
+
+// SetAt sets k to v and moves k to position i, so that afterwards
+// o.Index(k) == i. Entries at or after i are shifted back by one. It panics
+// if i is out of range, i.e. negative or greater than the number of other
+// keys, without modifying the Object.
+func (o *Object) SetAt(i int, k string, v interface{}) {
+	from := len(o.entries)
+	j := o.find(k)
+	others := len(o.entries)
+	if j >= 0 {
+		others--
+	}
+	if i < 0 || i > others {
+		panic("ojson: SetAt index out of range")
+	}
+	if j >= 0 {
+		copy(o.entries[j:], o.entries[j+1:])
+		o.entries = o.entries[:len(o.entries)-1]
+		from = j
+	}
+	o.entries = append(o.entries, Entry{})
+	copy(o.entries[i+1:], o.entries[i:])
+	o.entries[i] = Entry{Key: k, Value: v}
+	if i < from {
+		from = i
+	}
+	o.reindex(from)
+}
+
+// Swap exchanges the positions of the i'th and j'th entries. It panics if
+// either is out of range.
+func (o *Object) Swap(i, j int) {
+	o.entries[i], o.entries[j] = o.entries[j], o.entries[i]
+	if o.index != nil {
+		o.index[o.entries[i].Key] = i
+		o.index[o.entries[j].Key] = j
+	}
 }
 
 // SetAndReturn is equivalent to Set, while returning a pointer to the Object.
@@ -62,52 +294,196 @@ func (o *Object) SetAndReturn(k string, v interface{}) *Object {
 	return o
 }
 
-func (o Object) MarshalJSON() ([]byte, error) {
-	b := new(bytes.Buffer)
-	b.WriteString("{")
-	for i, k := range o.keyOrder {
-		if i > 0 {
-			b.WriteString(",")
+// Update sets every entry of src on o. As with Set, keys already in o keep
+// their positions, and new keys are appended in the order they appear in src.
+func (o *Object) Update(src *Object) {
+	o.grow(len(src.entries))
+	for _, e := range src.entries {
+		o.Set(e.Key, e.Value)
+	}
+}
+
+// UpdateFromMap sets every entry of m on o. New keys are appended in the
+// order they appear in order, followed by any remaining keys of m in sorted
+// order. Keys in order that aren't in m are ignored.
+func (o *Object) UpdateFromMap(m map[string]interface{}, order []string) {
+	o.grow(len(m))
+	done := make(map[string]struct{}, len(order))
+	for _, k := range order {
+		if v, ok := m[k]; ok {
+			o.Set(k, v)
+			done[k] = struct{}{}
 		}
-		if err := json.NewEncoder(b).Encode(k); err != nil {
-			return nil, err
+	}
+	rest := make([]string, 0, len(m)-len(done))
+	for k := range m {
+		if _, ok := done[k]; !ok {
+			rest = append(rest, k)
 		}
-		b.WriteString(":")
-		v, _ := o.Get(k)
-		b2, err := json.Marshal(v)
-		if err != nil {
-			return nil, err
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		o.Set(k, m[k])
+	}
+}
+
+// grow ensures there is room to append n more keys without reallocating.
+func (o *Object) grow(n int) {
+	if cap(o.entries)-len(o.entries) < n {
+		entries := make([]Entry, len(o.entries), len(o.entries)+n)
+		copy(entries, o.entries)
+		o.entries = entries
+	}
+}
+
+// setEntries replaces the Object's entries with entries, which must not
+// contain duplicate keys.
+func (o *Object) setEntries(entries []Entry) {
+	o.entries = entries
+	o.index = nil
+	o.reindex(0)
+}
+
+// Filter returns a new Object containing only the entries of o for which keep
+// returns true, in their original order. Values are not copied.
+func (o *Object) Filter(keep func(k string, v interface{}) bool) *Object {
+	obj := NewObject()
+	for _, e := range o.entries {
+		if keep(e.Key, e.Value) {
+			obj.Set(e.Key, e.Value)
 		}
-		b.Write(b2)
 	}
-	b.WriteString("}")
-	return b.Bytes(), nil
+	return obj
+}
+
+// FilterInPlace removes the entries of o for which keep returns false.
+func (o *Object) FilterInPlace(keep func(k string, v interface{}) bool) {
+	entries := o.entries[:0]
+	for _, e := range o.entries {
+		if keep(e.Key, e.Value) {
+			entries = append(entries, e)
+		}
+	}
+	for i := len(entries); i < len(o.entries); i++ {
+		o.entries[i] = Entry{}
+	}
+	o.setEntries(entries)
+}
+
+// Reset removes every entry, along with any comments and positions, from the
+// Object, but keeps the memory allocated for its entries, so that building it
+// up again doesn't need to reallocate.
+func (o *Object) Reset() {
+	for i := range o.entries {
+		o.entries[i] = Entry{}
+	}
+	o.entries = o.entries[:0]
+	o.index = nil
+	o.positions = nil
+	o.comments = nil
+	o.rawKeys = nil
+}
+
+// MarshalJSON encodes the Object with its keys in order. It returns ErrCycle
+// if the Object contains itself.
+func (o Object) MarshalJSON() ([]byte, error) {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	if err := e.marshalObject(&o); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), e.Bytes()...), nil
+}
+
+// AppendJSON appends the JSON encoding of the Object to dst and returns the
+// extended slice, like MarshalJSON but without allocating when dst has room.
+// On error, dst is returned unchanged.
+func (o *Object) AppendJSON(dst []byte) ([]byte, error) {
+	return appendJSON(dst, o)
+}
+
+// MarshalIndent is like MarshalJSON, but indents the output as MarshalIndent
+// does.
+func (o *Object) MarshalIndent(prefix, indent string) ([]byte, error) {
+	return MarshalIndent(o, prefix, indent)
+}
+
+// WriteTo writes the JSON encoding of the Object to w, with its keys in
+// order. It implements io.WriterTo.
+func (o *Object) WriteTo(w io.Writer) (int64, error) {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	if err := e.marshal(o); err != nil {
+		return 0, err
+	}
+	return e.WriteTo(w)
+}
+
+// WriteTo writes the JSON encoding of the Value to w. It implements
+// io.WriterTo.
+func (v Value) WriteTo(w io.Writer) (int64, error) {
+	e := getEncodeState()
+	defer putEncodeState(e)
+	if err := e.marshal(v.V); err != nil {
+		return 0, err
+	}
+	return e.WriteTo(w)
 }
 
 func (v Value) Value() (driver.Value, error) {
 	return json.Marshal(v)
 }
 
+// AppendJSON appends the JSON encoding of the Value to dst and returns the
+// extended slice. On error, dst is returned unchanged.
+func (v Value) AppendJSON(dst []byte) ([]byte, error) {
+	return appendJSON(dst, v.V)
+}
+
 func (v Value) MarshalJSON() ([]byte, error) {
-	return json.Marshal(v.V)
+	return MarshalOptions{}.Marshal(v.V)
 }
 
 func (v *Value) Scan(src interface{}) error {
 	source, ok := src.([]byte)
 	if !ok {
-		return errors.New("type assertion .([]byte) failed")
+		return &ScanTypeError{Src: src}
 	}
 	return v.UnmarshalJSON(source)
 }
 
 func (v *Value) UnmarshalJSON(b []byte) error {
-	dec := json.NewDecoder(bytes.NewReader(b))
-	oj, d, err := unmarshal(dec)
-	if d != 0 {
-		return errors.New("unexpected delimiter")
+	return newDecodeState(b, DecodeOptions{}).decodeAll(v)
+}
+
+// UnmarshalJSON decodes a JSON object into o, replacing its contents. It
+// returns an error if b holds any other kind of value.
+func (o *Object) UnmarshalJSON(b []byte) error {
+	var v Value
+	if err := v.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	obj, ok := v.V.(*Object)
+	if !ok {
+		return ErrNotObject
+	}
+	*o = *obj
+	return nil
+}
+
+// Value encodes the Object as JSON, for storing in a database column.
+func (o Object) Value() (driver.Value, error) {
+	return o.MarshalJSON()
+}
+
+// Scan decodes a JSON object stored in a database column into o. It returns
+// an error if the column holds any other kind of value.
+func (o *Object) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return &ScanTypeError{Src: src}
 	}
-	v.V = oj
-	return err
+	return o.UnmarshalJSON(source)
 }
 
 func NewValueFromJSON(s string) (Value, error) {
@@ -126,91 +502,356 @@ func MustNewValueFromJSON(s string) Value {
 	}
 }
 
-// unmarshal consumes from dec to decode the next chunk of JSON. It either
-// returns a JSON value corresponding to the result of a successful parse, or
-// a delimiter token if that is the next value in the decoder (needed to
-// correctly parse the ending ']' character of a JSON array)
-func unmarshal(dec *json.Decoder) (interface{}, json.Delim, error) {
-	var o interface{}
-	t, err := dec.Token()
-	if err != nil {
-		return nil, 0, err
-	}
-	switch v := t.(type) {
-	case json.Delim:
-		switch v {
-		case '{':
-			obj, err := unmarshalObject(dec)
+// unmarshal consumes from the decoder to decode the next chunk of JSON. It
+// either returns a JSON value corresponding to the result of a successful
+// parse, or a delimiter token if that is the next value in the decoder. It
+// keeps the objects and arrays being decoded on an explicit stack rather than
+// recursing, so deep input can't overflow the goroutine stack. The
+// json.Decoder it reads tokens from still limits the depth to 10000.
+func (d *decodeState) unmarshal() (interface{}, json.Delim, error) {
+	var stack []decodeFrame
+	for {
+		var top *decodeFrame
+		if n := len(stack); n > 0 {
+			top = &stack[n-1]
+			done, err := d.next(top)
 			if err != nil {
 				return nil, 0, err
 			}
-			o = obj
+			if done {
+				d.depth--
+				d.pointer = top.pointer
+				if len(d.path) > top.pathDepth {
+					d.path = d.path[:top.pathDepth]
+				}
+				v := top.value()
+				stack = stack[:n-1]
+				if len(stack) == 0 {
+					return v, 0, nil
+				}
+				if err := d.store(&stack[len(stack)-1], v, true); err != nil {
+					return nil, 0, err
+				}
+				continue
+			}
+		}
 
-		case '[':
-			arr, err := unmarshalArray(dec)
-			if err != nil {
+		start := d.dec.InputOffset()
+		t, err := d.dec.Token()
+		if err != nil {
+			return nil, 0, err
+		}
+		d.started = true
+		delim, isDelim := t.(json.Delim)
+		if isDelim && delim != '{' && delim != '[' {
+			if top == nil {
+				return nil, delim, nil
+			}
+			return nil, 0, fmt.Errorf("%w %v, expecting a value", ErrUnexpectedDelimiter, delim)
+		}
+		if d.positions != nil {
+			d.recordValue(start)
+		}
+		if d.opts.Progress != nil {
+			if err := d.countValue(); err != nil {
 				return nil, 0, err
 			}
-			o = arr
+		}
+		if isDelim {
+			if err := d.enter(); err != nil {
+				return nil, 0, err
+			}
+			f := decodeFrame{pointer: d.pointer, pathDepth: len(d.path)}
+			if delim == '{' {
+				f.obj = NewObject()
+				if d.positions != nil {
+					f.obj.positions = &objectPositions{positions: d.positions, pointer: d.pointer}
+				}
+			} else {
+				f.arr = make([]interface{}, 0)
+			}
+			stack = append(stack, f)
+			continue
+		}
 
-		default:
-			return nil, v, nil
+		v, err := d.scalar(t, start)
+		if err != nil {
+			return nil, 0, err
+		}
+		if top == nil {
+			return v, 0, nil
+		}
+		if err := d.store(top, v, false); err != nil {
+			return nil, 0, err
 		}
+	}
+}
 
-	case float64, string, bool, nil:
-		o = v
+// decodeFrame is an object or array that unmarshal is decoding.
+type decodeFrame struct {
+	// obj is the object being decoded, or nil if it is arr.
+	obj *Object
+	arr []interface{}
 
-	default:
-		return nil, 0, errors.New("unexpected type")
+	// pointer and pathDepth are d.pointer and len(d.path) at the container
+	// itself.
+	pointer   string
+	pathDepth int
+
+	// key is the key of the object member being decoded, and members is the
+	// number of members read so far. rawKey is the key as it appeared in the
+	// source, when preserving string escapes.
+	key     string
+	rawKey  string
+	members int
+	// collected holds the keys whose values have been collected into arrays
+	// by DuplicateKeysCollect.
+	collected map[string]struct{}
+
+	// slot is how the member or element being decoded was selected, when
+	// selecting.
+	slot selectMode
+}
+
+func (f *decodeFrame) value() interface{} {
+	if f.obj != nil {
+		return f.obj
 	}
-	return o, 0, nil
+	return f.arr
 }
 
-func unmarshalArray(dec *json.Decoder) ([]interface{}, error) {
-	arr := make([]interface{}, 0)
+// selectMode is how a value is treated while selecting.
+type selectMode int
+
+const (
+	// selectNone means the decode isn't selecting.
+	selectNone selectMode = iota
+	// selectAll means the value is within a selected value, so all of it is
+	// decoded.
+	selectAll
+	// selectPartial means the value may contain selected values. It is
+	// dropped if it turns out to be a scalar.
+	selectPartial
+)
+
+// next advances f to its next member or element, reading the key of an
+// object member. It returns true if f ended instead, having consumed its
+// closing delimiter.
+func (d *decodeState) next(f *decodeFrame) (bool, error) {
 	for {
-		o, d, err := unmarshal(dec)
+		f.slot = selectNone
+		if f.obj == nil {
+			n := len(f.arr)
+			if d.pointers {
+				d.pointer = f.pointer + "/" + strconv.Itoa(n)
+			}
+			if !d.dec.More() {
+				t, err := d.dec.Token()
+				if err != nil {
+					return false, err
+				}
+				if t != json.Delim(']') {
+					return false, fmt.Errorf("%w %v, expecting ]", ErrUnexpectedDelimiter, t)
+				}
+				return true, nil
+			}
+			if max := d.opts.MaxArrayLen; max > 0 && n == max {
+				return false, d.limitError("MaxArrayLen", max, d.tokenStart(d.dec.InputOffset()))
+			}
+			if d.selecting {
+				d.path = append(d.path[:f.pathDepth], strconv.Itoa(n))
+				if skip, err := d.selectNext(f); err != nil {
+					return false, err
+				} else if skip {
+					// Keep the indices of the other elements.
+					f.arr = append(f.arr, nil)
+					continue
+				}
+			}
+			return false, nil
+		}
+
+		if max := d.opts.MaxKeys; max > 0 && f.members == max && d.dec.More() {
+			return false, d.limitError("MaxKeys", max, d.tokenStart(d.dec.InputOffset()))
+		}
+		start := d.dec.InputOffset()
+		t, err := d.dec.Token()
 		if err != nil {
-			return arr, err
+			return false, err
 		}
-		switch d {
-		case ']':
-			return arr, nil
-		case 0:
-			arr = append(arr, o)
+		var v string
+		switch t := t.(type) {
+		case json.Delim:
+			if t == '}' {
+				return true, nil
+			}
+			return false, fmt.Errorf("%w %v, expecting }", ErrUnexpectedDelimiter, t)
+		case string:
+			v = t
 		default:
-			return arr, errors.New("unexpected delimiter (expecting ])")
+			return false, errors.New("unexpected token")
+		}
+		f.members++
+
+		k := d.key(v)
+		f.key, f.rawKey = k, ""
+		if d.opts.PreserveStringEscapes && k == v {
+			f.rawKey = d.rawToken(start)
+		}
+		if d.pointers {
+			d.pointer = f.pointer + "/" + escapePointerToken(k)
+		}
+		dup := f.obj.Has(k)
+		if d.issues != nil {
+			if k != v {
+				d.issue(start, "renamed key %q to %q", v, k)
+			}
+			if dup && d.opts.DuplicateKeys != DuplicateKeysError {
+				d.issue(start, "duplicate key %q, %s", k, duplicateKeyActions[d.opts.DuplicateKeys])
+			}
+		}
+		if dup && d.opts.DuplicateKeys == DuplicateKeysKeepFirst {
+			// Skip the value without recording its position over the first
+			// one's.
+			if err := skipValue(d.dec); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if d.positions != nil {
+			d.recordKey(start)
 		}
+		if d.selecting {
+			d.path = append(d.path[:f.pathDepth], k)
+			if skip, err := d.selectNext(f); err != nil {
+				return false, err
+			} else if skip {
+				continue
+			}
+		}
+		return false, nil
 	}
 }
 
-func unmarshalObject(dec *json.Decoder) (*Object, error) {
-	obj := NewObject()
-	for {
-		t, err := dec.Token()
+// selectNext decides how to decode the next value, at d.path, while
+// selecting. It returns true if the value was skipped.
+func (d *decodeState) selectNext(f *decodeFrame) (bool, error) {
+	selected, partial := d.selection()
+	switch {
+	case selected:
+		// Decode all of the value, and resume selecting once it is stored.
+		d.selecting = false
+		f.slot = selectAll
+	case partial:
+		f.slot = selectPartial
+	default:
+		return true, skipValue(d.dec)
+	}
+	return false, nil
+}
+
+// store stores v, which is a container if container is set, as f's current
+// member or element.
+func (d *decodeState) store(f *decodeFrame, v interface{}, container bool) error {
+	switch f.slot {
+	case selectAll:
+		d.selecting = true
+	case selectPartial:
+		if !container {
+			// A scalar can't contain the selected values.
+			if f.obj == nil {
+				f.arr = append(f.arr, nil)
+			}
+			return nil
+		}
+	}
+	if f.obj == nil {
+		f.arr = append(f.arr, v)
+		return nil
+	}
+	return d.setMember(f.obj, f.key, f.rawKey, v, &f.collected)
+}
+
+// scalar converts a string, number, boolean or null token, read from the
+// given input offset, according to the options.
+func (d *decodeState) scalar(t json.Token, start int64) (interface{}, error) {
+	switch v := t.(type) {
+	case string:
+		var o interface{} = v
+		if d.opts.PreserveStringEscapes {
+			o = RawString{Raw: d.rawToken(start)}
+		}
+		if d.opts.Times {
+			if t, ok := d.time(v); ok {
+				o = t
+				if d.issues != nil {
+					d.issue(start, "decoded string as a time")
+				}
+			}
+		}
+		return o, nil
+
+	case json.Number:
+		n, err := d.number(v)
 		if err != nil {
 			return nil, err
 		}
-		switch v := t.(type) {
-		case json.Delim:
-			if v == '}' {
-				return obj, nil
-			} else {
-				return nil, errors.New("unexpected delimiter (expecting })")
+		if d.issues != nil {
+			switch n.(type) {
+			case *big.Int, *big.Float:
+				d.issue(start, "number %s can't be represented by a float64, decoded as %T", v, n)
 			}
+		}
+		return n, nil
 
-		case string:
-			o, d, err := unmarshal(dec)
-			if err != nil {
-				return nil, err
-			}
-			if d != 0 {
-				return nil, errors.New("unexpected delimiter")
-			}
-			obj.Set(v, o)
+	case float64, bool, nil:
+		return v, nil
 
-		default:
-			return nil, errors.New("unexpected token")
+	default:
+		return nil, errors.New("unexpected type")
+	}
+}
+
+// duplicateKeyActions describes what each DuplicateKeyPolicy does with a
+// duplicate key, for DecodeIssues.
+var duplicateKeyActions = map[DuplicateKeyPolicy]string{
+	DuplicateKeysKeepLast:  "keeping the last value",
+	DuplicateKeysKeepFirst: "keeping the first value",
+	DuplicateKeysCollect:   "collecting the values into an array",
+}
+
+// setMember sets k to v in obj, following the DuplicateKeyPolicy if k is
+// already set.
+func (d *decodeState) setMember(obj *Object, k, rawKey string, v interface{}, collected *map[string]struct{}) error {
+	i := obj.find(k)
+	if i < 0 {
+		obj.Set(k, v)
+		if rawKey != "" {
+			if obj.rawKeys == nil {
+				obj.rawKeys = make(map[string]string)
+			}
+			obj.rawKeys[k] = rawKey
+		}
+		return nil
+	}
+	prev := obj.entries[i].Value
+	switch d.opts.DuplicateKeys {
+	case DuplicateKeysKeepFirst:
+		// next skips these values without decoding them.
+	case DuplicateKeysError:
+		return fmt.Errorf("%w %q", ErrDuplicateKey, k)
+	case DuplicateKeysCollect:
+		if _, ok := (*collected)[k]; ok {
+			obj.entries[i].Value = append(prev.([]interface{}), v)
+			break
+		}
+		if *collected == nil {
+			*collected = make(map[string]struct{})
 		}
+		(*collected)[k] = struct{}{}
+		obj.entries[i].Value = []interface{}{prev, v}
+	default:
+		obj.entries[i].Value = v
 	}
+	return nil
 }
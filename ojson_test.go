@@ -1,7 +1,11 @@
 package ojson
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -16,16 +20,15 @@ func TestMarshalUnmarshal(tt *testing.T) {
 			`{"gh\"jkl":[true,123,["asdf"]],"asdf":null}`,
 			Value{
 				V: &Object{
-					keyOrder: []string{"gh\"jkl", "asdf"},
-					values: map[string]interface{}{
-						"asdf": nil,
-						"gh\"jkl": []interface{}{
+					entries: []Entry{
+						{Key: "gh\"jkl", Value: []interface{}{
 							true,
 							123.0,
 							[]interface{}{
 								"asdf",
 							},
-						},
+						}},
+						{Key: "asdf", Value: nil},
 					},
 				},
 			},
@@ -34,22 +37,19 @@ func TestMarshalUnmarshal(tt *testing.T) {
 			`{"b":{"c":1,"d":2},"a":{"d":2,"c":1}}`,
 			Value{
 				V: &Object{
-					keyOrder: []string{"b", "a"},
-					values: map[string]interface{}{
-						"a": &Object{
-							keyOrder: []string{"d", "c"},
-							values: map[string]interface{}{
-								"c": 1.0,
-								"d": 2.0,
+					entries: []Entry{
+						{Key: "b", Value: &Object{
+							entries: []Entry{
+								{Key: "c", Value: 1.0},
+								{Key: "d", Value: 2.0},
 							},
-						},
-						"b": &Object{
-							keyOrder: []string{"c", "d"},
-							values: map[string]interface{}{
-								"c": 1.0,
-								"d": 2.0,
+						}},
+						{Key: "a", Value: &Object{
+							entries: []Entry{
+								{Key: "d", Value: 2.0},
+								{Key: "c", Value: 1.0},
 							},
-						},
+						}},
 					},
 				},
 			},
@@ -101,22 +101,19 @@ func TestMarshalValidJson(tt *testing.T) {
 			},
 			oj: Value{
 				V: &Object{
-					keyOrder: []string{"a", "b"},
-					values: map[string]interface{}{
-						"a": &Object{
-							keyOrder: []string{"c", "d"},
-							values: map[string]interface{}{
-								"c": 1.0,
-								"d": 2.0,
+					entries: []Entry{
+						{Key: "a", Value: &Object{
+							entries: []Entry{
+								{Key: "c", Value: 1.0},
+								{Key: "d", Value: 2.0},
 							},
-						},
-						"b": &Object{
-							keyOrder: []string{"e", "f"},
-							values: map[string]interface{}{
-								"f": 1.0,
-								"e": 2.0,
+						}},
+						{Key: "b", Value: &Object{
+							entries: []Entry{
+								{Key: "e", Value: 2.0},
+								{Key: "f", Value: 1.0},
 							},
-						},
+						}},
 					},
 				},
 			},
@@ -132,3 +129,543 @@ func TestMarshalValidJson(tt *testing.T) {
 		})
 	}
 }
+
+func TestMarshalCycle(tt *testing.T) {
+	tt.Run("direct", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		o.Set("self", o)
+		_, err := json.Marshal(Value{V: o})
+		require.True(errors.Is(err, ErrCycle))
+	})
+	tt.Run("nested in array", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		o.Set("a", []interface{}{NewObject().SetAndReturn("b", o)})
+		_, err := o.MarshalJSON()
+		require.True(errors.Is(err, ErrCycle))
+	})
+	tt.Run("through a map", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		o.Set("m", map[string]interface{}{"o": o})
+		_, err := json.Marshal(o)
+		require.True(errors.Is(err, ErrCycle))
+	})
+	tt.Run("through a struct and another object", func(t *testing.T) {
+		require := require.New(t)
+		type wrapper struct {
+			Values []interface{}
+		}
+		o := NewObject()
+		inner := NewObject().SetAndReturn("back", map[string]interface{}{"o": o})
+		o.Set("w", &wrapper{Values: []interface{}{inner}})
+		_, err := json.Marshal(Value{V: o})
+		require.True(errors.Is(err, ErrCycle))
+	})
+	tt.Run("shared object is not a cycle", func(t *testing.T) {
+		require := require.New(t)
+		shared := NewObject().SetAndReturn("x", 1.0)
+		o := NewObject().SetAndReturn("a", shared).SetAndReturn("b", shared)
+		s, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"a":{"x":1},"b":{"x":1}}`, string(s))
+
+		o.Set("m", map[string]interface{}{"c": shared, "d": shared})
+		s, err = json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"a":{"x":1},"b":{"x":1},"m":{"c":{"x":1},"d":{"x":1}}}`, string(s))
+	})
+}
+
+func TestFilter(tt *testing.T) {
+	const in = `{"_id":1,"a":null,"b":"x","_rev":2,"c":[]}`
+	notPrivate := func(k string, v interface{}) bool {
+		return !strings.HasPrefix(k, "_")
+	}
+	notNull := func(k string, v interface{}) bool {
+		return v != nil
+	}
+
+	tt.Run("filter", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(in).V.(*Object)
+		b, err := json.Marshal(o.Filter(notPrivate).Filter(notNull))
+		require.NoError(err)
+		require.Equal(`{"b":"x","c":[]}`, string(b))
+
+		// The original is unchanged.
+		b, err = json.Marshal(o)
+		require.NoError(err)
+		require.Equal(in, string(b))
+	})
+
+	tt.Run("filter in place", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(in).V.(*Object)
+		o.FilterInPlace(notPrivate)
+		b, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"a":null,"b":"x","c":[]}`, string(b))
+		_, ok := o.Get("_id")
+		require.False(ok)
+	})
+}
+
+func TestUpdate(tt *testing.T) {
+	tt.Run("object", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2}`).V.(*Object)
+		o.Update(MustNewValueFromJSON(`{"c":3,"a":10,"d":4}`).V.(*Object))
+		b, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"a":10,"b":2,"c":3,"d":4}`, string(b))
+	})
+
+	tt.Run("map", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1}`).V.(*Object)
+		o.UpdateFromMap(map[string]interface{}{
+			"z": 26.0,
+			"y": 25.0,
+			"b": 2.0,
+			"a": 10.0,
+			"c": 3.0,
+		}, []string{"y", "missing", "z"})
+		b, err := json.Marshal(o)
+		require.NoError(err)
+		require.Equal(`{"a":10,"y":25,"z":26,"b":2,"c":3}`, string(b))
+	})
+}
+
+func TestPositionalAccess(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"b":1,"a":2,"c":3}`).V.(*Object)
+
+	require.Equal(0, o.Index("b"))
+	require.Equal(2, o.Index("c"))
+	require.Equal(-1, o.Index("missing"))
+
+	k, v := o.GetAt(1)
+	require.Equal("a", k)
+	require.Equal(2.0, v)
+	require.Panics(func() { o.GetAt(3) })
+}
+
+func TestPositionalMutation(tt *testing.T) {
+	marshal := func(t *testing.T, o *Object) string {
+		b, err := json.Marshal(o)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	tt.Run("insert", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2}`).V.(*Object)
+		o.SetAt(0, "first", 0.0)
+		o.SetAt(2, "mid", 1.5)
+		o.SetAt(4, "last", 3.0)
+		require.Equal(`{"first":0,"a":1,"mid":1.5,"b":2,"last":3}`, marshal(t, o))
+		require.Panics(func() { o.SetAt(6, "x", nil) })
+	})
+
+	tt.Run("move existing", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2,"c":3}`).V.(*Object)
+		o.SetAt(0, "c", 30.0)
+		require.Equal(`{"c":30,"a":1,"b":2}`, marshal(t, o))
+		o.SetAt(2, "c", 3.0)
+		require.Equal(`{"a":1,"b":2,"c":3}`, marshal(t, o))
+		require.Equal(2, o.Index("c"))
+		require.Panics(func() { o.SetAt(3, "a", nil) })
+		require.Panics(func() { o.SetAt(-1, "a", nil) })
+		require.Panics(func() { o.SetAt(-1, "x", nil) })
+		require.Equal(`{"a":1,"b":2,"c":3}`, marshal(t, o))
+		require.Equal(0, o.Index("a"))
+	})
+
+	tt.Run("swap", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"a":1,"b":2,"c":3}`).V.(*Object)
+		o.Swap(0, 2)
+		require.Equal(`{"c":3,"b":2,"a":1}`, marshal(t, o))
+		require.Panics(func() { o.Swap(0, 3) })
+	})
+}
+
+func TestLargeObject(tt *testing.T) {
+	// Objects with more than indexThreshold keys look keys up through an
+	// index, which every mutation must keep in step with the key order.
+	requireIndexed := func(t *testing.T, o *Object) {
+		for i, k := range o.KeyOrder() {
+			require.Equal(t, i, o.Index(k), k)
+			v, ok := o.Get(k)
+			require.True(t, ok, k)
+			require.Equal(t, k, v)
+		}
+	}
+
+	o := NewObject()
+	for i := 0; i < 2*indexThreshold; i++ {
+		k := strconv.Itoa(i)
+		o.Set(k, k)
+	}
+	requireIndexed(tt, o)
+
+	tt.Run("delete", func(t *testing.T) {
+		o := o.Filter(func(string, interface{}) bool { return true })
+		require.True(t, o.Delete("3"))
+		require.False(t, o.Has("3"))
+		require.Equal(t, -1, o.Index("3"))
+		requireIndexed(t, o)
+		for o.Len() > 1 {
+			k, _ := o.GetAt(0)
+			o.Delete(k)
+			requireIndexed(t, o)
+		}
+	})
+
+	tt.Run("set at and swap", func(t *testing.T) {
+		o := o.Filter(func(string, interface{}) bool { return true })
+		o.SetAt(2, "10", "10")
+		requireIndexed(t, o)
+		o.SetAt(12, "0", "0")
+		requireIndexed(t, o)
+		o.SetAt(5, "new", "new")
+		requireIndexed(t, o)
+		o.Swap(0, o.Len()-1)
+		requireIndexed(t, o)
+	})
+
+	tt.Run("reorder", func(t *testing.T) {
+		o := o.Filter(func(string, interface{}) bool { return true })
+		SortKeys(Value{V: o}, SortKeysOptions{Compare: func(a, b string) int {
+			return strings.Compare(b, a)
+		}})
+		requireIndexed(t, o)
+		o.FilterInPlace(func(k string, _ interface{}) bool { return len(k) == 2 })
+		require.Equal(t, []string{"15", "14", "13", "12", "11", "10"}, o.KeyOrder())
+		requireIndexed(t, o)
+	})
+}
+
+func TestGetFold(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"Name":1,"NAME":2,"name":3,"Straße":4}`).V.(*Object)
+
+	v, ok := o.GetFold("name")
+	require.True(ok)
+	require.Equal(3.0, v)
+
+	v, ok = o.GetFold("nAmE")
+	require.True(ok)
+	require.Equal(1.0, v)
+
+	v, ok = o.GetFold("STRAßE")
+	require.True(ok)
+	require.Equal(4.0, v)
+
+	_, ok = o.GetFold("missing")
+	require.False(ok)
+
+	// The stored keys are unchanged.
+	require.Equal([]string{"Name", "NAME", "name", "Straße"}, o.KeyOrder())
+}
+
+func TestGetAny(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"uid":1,"userId":2}`).V.(*Object)
+
+	k, v, ok := o.GetAny("user_id", "userId", "uid")
+	require.True(ok)
+	require.Equal("userId", k)
+	require.Equal(2.0, v)
+
+	_, _, ok = o.GetAny("user_id")
+	require.False(ok)
+}
+
+func TestWriteTo(tt *testing.T) {
+	require := require.New(tt)
+	const in = `{"b":[1,{"d":2,"c":3}],"a":"x"}`
+	v := MustNewValueFromJSON(in)
+
+	var buf bytes.Buffer
+	n, err := v.WriteTo(&buf)
+	require.NoError(err)
+	require.Equal(int64(len(in)), n)
+	require.Equal(in, buf.String())
+
+	buf.Reset()
+	n, err = v.V.(*Object).WriteTo(&buf)
+	require.NoError(err)
+	require.Equal(int64(len(in)), n)
+	require.Equal(in, buf.String())
+
+	buf.Reset()
+	_, err = (*Object)(nil).WriteTo(&buf)
+	require.NoError(err)
+	require.Equal(`null`, buf.String())
+
+	o := NewObject()
+	o.Set("self", o)
+	buf.Reset()
+	_, err = o.WriteTo(&buf)
+	require.Equal(ErrCycle, err)
+	require.Zero(buf.Len())
+}
+
+func TestDelete(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"a":1,"b":2,"c":3}`).V.(*Object)
+	o.SetLeadingComment("b", "doomed")
+
+	require.True(o.Delete("b"))
+	require.False(o.Delete("b"))
+	require.False(o.Delete("missing"))
+	require.Equal([]string{"a", "c"}, o.KeyOrder())
+	_, ok := o.Get("b")
+	require.False(ok)
+
+	// Setting the key again appends it, without its old comments.
+	o.Set("b", 4.0)
+	b, err := json.Marshal(o)
+	require.NoError(err)
+	require.Equal(`{"a":1,"c":3,"b":4}`, string(b))
+	leading, _ := o.Comments("b")
+	require.Empty(leading)
+}
+
+func TestReset(tt *testing.T) {
+	require := require.New(tt)
+	o, err := DecodeOptions{Positions: true}.Unmarshal([]byte(`{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9}`))
+	require.NoError(err)
+	obj := o.V.(*Object)
+	obj.SetLeadingComment("a", "first")
+	capacity := cap(obj.entries)
+
+	obj.Reset()
+	require.Equal(0, obj.Len())
+	require.False(obj.Has("a"))
+	_, ok := obj.Position("/a")
+	require.False(ok)
+	leading, _ := obj.Comments("a")
+	require.Empty(leading)
+	require.Equal(capacity, cap(obj.entries))
+
+	obj.Set("z", 26.0)
+	obj.Set("a", 1.0)
+	b, err := json.Marshal(obj)
+	require.NoError(err)
+	require.Equal(`{"z":26,"a":1}`, string(b))
+	require.Equal(capacity, cap(obj.entries))
+}
+
+func TestNewObjectWithCapacity(tt *testing.T) {
+	require := require.New(tt)
+	o := NewObjectWithCapacity(2 * indexThreshold)
+	require.Equal(0, o.Len())
+	require.Equal(2*indexThreshold, cap(o.entries))
+
+	for i := 0; i < 2*indexThreshold; i++ {
+		o.Set(strconv.Itoa(i), float64(i))
+	}
+	require.Equal(2*indexThreshold, cap(o.entries))
+	require.Equal(indexThreshold, o.Index(strconv.Itoa(indexThreshold)))
+
+	b, err := json.Marshal(NewObjectWithCapacity(0).SetAndReturn("a", 1.0))
+	require.NoError(err)
+	require.Equal(`{"a":1}`, string(b))
+}
+
+func TestLen(tt *testing.T) {
+	require := require.New(tt)
+	o := NewObject()
+	require.Equal(0, o.Len())
+	o.Set("a", 1.0)
+	o.Set("b", 2.0)
+	o.Set("a", 3.0)
+	require.Equal(2, o.Len())
+	o.Delete("a")
+	require.Equal(1, o.Len())
+}
+
+func TestHas(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"a":null,"b":1}`).V.(*Object)
+	require.True(o.Has("a"))
+	require.True(o.Has("b"))
+	require.False(o.Has("c"))
+	o.Delete("a")
+	require.False(o.Has("a"))
+}
+
+func TestEntries(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"b":1,"a":[2]}`).V.(*Object)
+	entries := o.Entries()
+	require.Equal([]Entry{
+		{Key: "b", Value: 1.0},
+		{Key: "a", Value: []interface{}{2.0}},
+	}, entries)
+
+	// The slice is a snapshot.
+	entries[0].Key = "z"
+	o.Set("c", 3.0)
+	require.Equal([]string{"b", "a", "c"}, o.KeyOrder())
+	require.Len(entries, 2)
+
+	require.Empty(NewObject().Entries())
+}
+
+func TestRange(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"c":1,"a":2,"b":3}`).V.(*Object)
+
+	var keys []string
+	o.Range(func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return v != 2.0
+	})
+	require.Equal([]string{"c", "a"}, keys)
+}
+
+func TestReverseRange(tt *testing.T) {
+	require := require.New(tt)
+	o := MustNewValueFromJSON(`{"c":1,"a":2,"b":3}`).V.(*Object)
+
+	var keys []string
+	o.ReverseRange(func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	require.Equal([]string{"b", "a", "c"}, keys)
+
+	keys = nil
+	o.ReverseRange(func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return k != "a"
+	})
+	require.Equal([]string{"b", "a"}, keys)
+}
+
+func TestObjectUnmarshalJSON(tt *testing.T) {
+	tt.Run("struct field", func(t *testing.T) {
+		require := require.New(t)
+		var s struct {
+			Config *Object `json:"config"`
+			Extra  Object  `json:"extra"`
+		}
+		require.NoError(json.Unmarshal([]byte(`{"config":{"b":1,"a":2},"extra":{"y":[],"x":{}}}`), &s))
+		require.Equal([]string{"b", "a"}, s.Config.KeyOrder())
+		require.Equal([]string{"y", "x"}, s.Extra.KeyOrder())
+
+		b, err := json.Marshal(s)
+		require.NoError(err)
+		require.Equal(`{"config":{"b":1,"a":2},"extra":{"y":[],"x":{}}}`, string(b))
+	})
+
+	tt.Run("replaces contents", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject().SetAndReturn("old", 1.0)
+		require.NoError(o.UnmarshalJSON([]byte(`{"new":2}`)))
+		require.Equal([]string{"new"}, o.KeyOrder())
+	})
+
+	tt.Run("not an object", func(t *testing.T) {
+		for _, in := range []string{`[1]`, `"x"`, `1`, `null`} {
+			var o Object
+			require.Error(t, json.Unmarshal([]byte(in), &o), in)
+		}
+	})
+}
+
+func TestObjectScanValue(tt *testing.T) {
+	require := require.New(tt)
+	const in = `{"b":1,"a":{"d":2,"c":3}}`
+
+	var o Object
+	require.NoError(o.Scan([]byte(in)))
+	require.Equal([]string{"b", "a"}, o.KeyOrder())
+
+	s, err := o.Value()
+	require.NoError(err)
+	require.Equal([]byte(in), s.([]byte))
+
+	require.Error(o.Scan([]byte(`[1,2]`)))
+	require.Error(o.Scan(in))
+}
+
+func TestTrailingData(tt *testing.T) {
+	for _, in := range []string{`{"a":1}garbage`, `[1] [2]`, `1 2`, `"x"}`, `null,`, `true false`} {
+		tt.Run(in, func(t *testing.T) {
+			require := require.New(t)
+			_, err := NewValueFromJSON(in)
+			require.Error(err)
+			require.Contains(err.Error(), "after top-level value")
+
+			var o Object
+			require.Error(o.UnmarshalJSON([]byte(in)))
+
+			_, err = DecodeOptions{AllowTrailingData: true}.Unmarshal([]byte(in))
+			require.NoError(err)
+		})
+	}
+
+	v, err := NewValueFromJSON(" {\"a\":1}\n\t ")
+	require.NoError(tt, err)
+	require.Equal(tt, []string{"a"}, v.V.(*Object).KeyOrder())
+}
+
+func TestSentinelErrors(tt *testing.T) {
+	require := require.New(tt)
+
+	var o Object
+	require.True(errors.Is(o.UnmarshalJSON([]byte(`[1]`)), ErrNotObject))
+	require.True(errors.Is(o.Scan([]byte(`"x"`)), ErrNotObject))
+
+	var scanErr *ScanTypeError
+	var v Value
+	require.True(errors.As(v.Scan("{}"), &scanErr))
+	require.Equal("{}", scanErr.Src)
+	require.True(errors.As(o.Scan(42), &scanErr))
+	require.Equal("cannot scan int as JSON, expected []byte", scanErr.Error())
+
+	_, err := Count(MustNewValueFromJSON(`{}`), "")
+	require.True(errors.Is(err, ErrNotArray))
+
+	dec := NewDecoder(strings.NewReader(`[1]`))
+	_, err = dec.Token()
+	require.NoError(err)
+	require.NoError(dec.Skip())
+	err = dec.Decode(&v)
+	require.True(errors.Is(err, ErrUnexpectedDelimiter))
+	require.EqualError(err, "unexpected delimiter ], expecting a value")
+}
+
+func TestAppendJSON(tt *testing.T) {
+	require := require.New(tt)
+	const in = `{"b":[1,{"d":2,"c":"x"}],"a":null}`
+	v := MustNewValueFromJSON(in)
+
+	buf := []byte("prefix:")
+	buf, err := v.AppendJSON(buf)
+	require.NoError(err)
+	require.Equal("prefix:"+in, string(buf))
+
+	buf, err = v.V.(*Object).AppendJSON(buf[:0])
+	require.NoError(err)
+	require.Equal(in, string(buf))
+
+	// A buffer with room is reused.
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, err = v.V.(*Object).AppendJSON(buf[:0])
+	})
+	require.Zero(allocs)
+
+	o := NewObject()
+	o.Set("self", o)
+	buf, err = o.AppendJSON([]byte("x"))
+	require.Equal(ErrCycle, err)
+	require.Equal("x", string(buf))
+}
@@ -0,0 +1,159 @@
+package ojson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ReorderOptions configures ReorderLike.
+type ReorderOptions struct {
+	// Recursive also reorders nested objects whose corresponding template
+	// value is an object. Objects in an array are reordered like the first
+	// element of the corresponding template array, if that is an object.
+	Recursive bool
+}
+
+// ReorderLike rearranges the keys of o, in place, to follow the order of the
+// keys in template. Keys of o that aren't in template are moved to the end,
+// keeping their relative order.
+func ReorderLike(o, template *Object, opts ReorderOptions) {
+	keys := orderLike(o, template)
+	entries := make([]Entry, len(keys))
+	for i, k := range keys {
+		entries[i] = o.entries[o.find(k)]
+	}
+	o.setEntries(entries)
+
+	if !opts.Recursive {
+		return
+	}
+	for _, kv := range o.entries {
+		if t, ok := template.Get(kv.Key); ok {
+			reorderValueLike(kv.Value, t, opts)
+		}
+	}
+}
+
+// orderLike returns the keys of o in the order of the keys in template,
+// followed by the keys that aren't in template.
+func orderLike(o, template *Object) []string {
+	keyOrder := make([]string, 0, len(o.entries))
+	for _, kv := range template.entries {
+		if o.Has(kv.Key) {
+			keyOrder = append(keyOrder, kv.Key)
+		}
+	}
+	for _, kv := range o.entries {
+		if !template.Has(kv.Key) {
+			keyOrder = append(keyOrder, kv.Key)
+		}
+	}
+	return keyOrder
+}
+
+func reorderValueLike(v, template interface{}, opts ReorderOptions) {
+	switch v := v.(type) {
+	case *Object:
+		if t, ok := template.(*Object); ok && v != nil && t != nil {
+			ReorderLike(v, t, opts)
+		}
+	case []interface{}:
+		t, ok := template.([]interface{})
+		if !ok || len(t) == 0 {
+			return
+		}
+		for _, e := range v {
+			reorderValueLike(e, t[0], opts)
+		}
+	}
+}
+
+// KeyOrderMismatch describes an object whose keys aren't in the expected
+// order.
+type KeyOrderMismatch struct {
+	// Path is the JSON Pointer of the object.
+	Path     string
+	Expected []string
+	Actual   []string
+}
+
+// KeyOrderError is returned by VerifyKeyOrder and VerifyKeyOrderLike when one
+// or more objects have keys out of order.
+type KeyOrderError struct {
+	Mismatches []KeyOrderMismatch
+}
+
+func (e *KeyOrderError) Error() string {
+	var b strings.Builder
+	for i, m := range e.Mismatches {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "key order mismatch at %q: expected %q, got %q", m.Path, m.Expected, m.Actual)
+	}
+	return b.String()
+}
+
+// VerifyKeyOrder checks that the keys of o appear in the order given by
+// expected, with any keys not in expected at the end. It returns a
+// *KeyOrderError if they don't.
+func VerifyKeyOrder(o *Object, expected []string) error {
+	template := NewObject()
+	for _, k := range expected {
+		template.Set(k, nil)
+	}
+	return VerifyKeyOrderLike(o, template, ReorderOptions{})
+}
+
+// VerifyKeyOrderLike checks that o is already in the order that ReorderLike
+// would put it in given the same template and options, returning a
+// *KeyOrderError listing every object that isn't.
+func VerifyKeyOrderLike(o, template *Object, opts ReorderOptions) error {
+	var mismatches []KeyOrderMismatch
+	verifyKeyOrderLike(o, template, opts, "", &mismatches)
+	if len(mismatches) > 0 {
+		return &KeyOrderError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+func verifyKeyOrderLike(o, template *Object, opts ReorderOptions, path string, mismatches *[]KeyOrderMismatch) {
+	expected := orderLike(o, template)
+	for i, kv := range o.entries {
+		if expected[i] != kv.Key {
+			*mismatches = append(*mismatches, KeyOrderMismatch{
+				Path:     path,
+				Expected: expected,
+				Actual:   o.KeyOrder(),
+			})
+			break
+		}
+	}
+
+	if !opts.Recursive {
+		return
+	}
+	for _, kv := range o.entries {
+		if t, ok := template.Get(kv.Key); ok {
+			verifyValueKeyOrderLike(kv.Value, t, opts, path+"/"+escapePointerToken(kv.Key), mismatches)
+		}
+	}
+}
+
+func verifyValueKeyOrderLike(v, template interface{}, opts ReorderOptions, path string, mismatches *[]KeyOrderMismatch) {
+	switch v := v.(type) {
+	case *Object:
+		if t, ok := template.(*Object); ok && v != nil && t != nil {
+			verifyKeyOrderLike(v, t, opts, path, mismatches)
+		}
+	case []interface{}:
+		t, ok := template.([]interface{})
+		if !ok || len(t) == 0 {
+			return
+		}
+		for i, e := range v {
+			verifyValueKeyOrderLike(e, t[0], opts, path+"/"+strconv.Itoa(i), mismatches)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderLike(tt *testing.T) {
+	const (
+		in       = `{"extra":0,"name":"n","id":1,"meta":{"b":2,"a":1},"items":[{"y":1,"x":2},{"x":3}]}`
+		template = `{"id":0,"name":"","meta":{"a":0,"b":0},"items":[{"x":0,"y":0}],"unused":null}`
+	)
+	for _, test := range []struct {
+		name string
+		opts ReorderOptions
+		out  string
+	}{
+		{
+			name: "top level",
+			out:  `{"id":1,"name":"n","meta":{"b":2,"a":1},"items":[{"y":1,"x":2},{"x":3}],"extra":0}`,
+		},
+		{
+			name: "recursive",
+			opts: ReorderOptions{Recursive: true},
+			out:  `{"id":1,"name":"n","meta":{"a":1,"b":2},"items":[{"x":2,"y":1},{"x":3}],"extra":0}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			o := MustNewValueFromJSON(in).V.(*Object)
+			ReorderLike(o, MustNewValueFromJSON(template).V.(*Object), test.opts)
+			b, err := json.Marshal(o)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+}
+
+func TestVerifyKeyOrder(tt *testing.T) {
+	tt.Run("sequence", func(t *testing.T) {
+		require := require.New(t)
+		o := MustNewValueFromJSON(`{"id":1,"name":"n","extra":0}`).V.(*Object)
+		require.NoError(VerifyKeyOrder(o, []string{"id", "name", "missing"}))
+
+		err := VerifyKeyOrder(o, []string{"name", "id"})
+		var kerr *KeyOrderError
+		require.True(errors.As(err, &kerr))
+		require.Equal([]KeyOrderMismatch{{
+			Path:     "",
+			Expected: []string{"name", "id", "extra"},
+			Actual:   []string{"id", "name", "extra"},
+		}}, kerr.Mismatches)
+
+		// Unknown keys must come last.
+		require.Error(VerifyKeyOrder(o, []string{"id", "extra"}))
+	})
+
+	tt.Run("template", func(t *testing.T) {
+		require := require.New(t)
+		template := MustNewValueFromJSON(`{"id":0,"meta":{"a":0,"b":0},"items":[{"x":0,"y":0}]}`).V.(*Object)
+		o := MustNewValueFromJSON(`{"id":1,"meta":{"b":2,"a":1},"items":[{"x":1,"y":2},{"y":1,"x":2}]}`).V.(*Object)
+
+		require.NoError(VerifyKeyOrderLike(o, template, ReorderOptions{}))
+
+		err := VerifyKeyOrderLike(o, template, ReorderOptions{Recursive: true})
+		var kerr *KeyOrderError
+		require.True(errors.As(err, &kerr))
+		require.Len(kerr.Mismatches, 2)
+		require.Equal("/meta", kerr.Mismatches[0].Path)
+		require.Equal("/items/1", kerr.Mismatches[1].Path)
+		require.Equal(`key order mismatch at "/meta": expected ["a" "b"], got ["b" "a"]; `+
+			`key order mismatch at "/items/1": expected ["x" "y"], got ["y" "x"]`, err.Error())
+
+		ReorderLike(o, template, ReorderOptions{Recursive: true})
+		require.NoError(VerifyKeyOrderLike(o, template, ReorderOptions{Recursive: true}))
+	})
+}
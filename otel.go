@@ -0,0 +1,203 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ToOTLPAnyValue converts v to an OpenTelemetry AnyValue, such as a log
+// record body, in its OTLP/JSON encoding: an Object like {"stringValue":
+// "x"}, or {"kvlistValue": {"values": [{"key": "k", "value": ...}]}} for an
+// Object, which keeps its keys in order. Numbers that are exact integers are
+// converted to intValue and other numbers to doubleValue. Values that aren't
+// plain JSON types, such as time.Time, are converted as they marshal to JSON.
+//
+// The OTLP/JSON encoding can be sent to a collector as is, or loaded into
+// pdata with its JSON unmarshalers, without this package depending on the
+// OpenTelemetry modules.
+func ToOTLPAnyValue(v Value) (*Object, error) {
+	return toOTLP(v.V)
+}
+
+// ToOTLPAttributes converts the entries of o to OpenTelemetry attributes in
+// their OTLP/JSON encoding: an array of {"key": "k", "value": AnyValue}
+// Objects, in o's key order.
+func ToOTLPAttributes(o *Object) ([]interface{}, error) {
+	attrs := make([]interface{}, 0, len(o.entries))
+	for _, kv := range o.entries {
+		v, err := toOTLP(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, NewObject().SetAndReturn("key", kv.Key).SetAndReturn("value", v))
+	}
+	return attrs, nil
+}
+
+func toOTLP(v interface{}) (*Object, error) {
+	av := NewObject()
+	switch v := v.(type) {
+	case nil:
+	case string:
+		av.Set("stringValue", v)
+	case bool:
+		av.Set("boolValue", v)
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("unsupported value %v", v)
+		}
+		if i, ok := exactInt64(v); ok {
+			// OTLP/JSON encodes 64-bit integers as strings.
+			av.Set("intValue", strconv.FormatInt(i, 10))
+		} else {
+			av.Set("doubleValue", v)
+		}
+	case int64:
+		av.Set("intValue", strconv.FormatInt(v, 10))
+	case *Object:
+		if v == nil {
+			break
+		}
+		values, err := ToOTLPAttributes(v)
+		if err != nil {
+			return nil, err
+		}
+		av.Set("kvlistValue", NewObject().SetAndReturn("values", values))
+	case []interface{}:
+		if v == nil {
+			break
+		}
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			var err error
+			if values[i], err = toOTLP(e); err != nil {
+				return nil, err
+			}
+		}
+		av.Set("arrayValue", NewObject().SetAndReturn("values", values))
+	default:
+		// Convert anything else, including other number types, as it
+		// marshals to JSON.
+		b, err := MarshalOptions{}.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		plain, err := NewValueFromJSON(string(b))
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				plain.V = i
+			}
+		}
+		return toOTLP(plain.V)
+	}
+	return av, nil
+}
+
+// FromOTLPAnyValue converts an OpenTelemetry AnyValue in its OTLP/JSON
+// encoding, as produced by ToOTLPAnyValue, back to a Value. Integers are
+// converted to float64, as when decoding JSON, and bytesValue is kept as its
+// base64 string.
+func FromOTLPAnyValue(av *Object) (Value, error) {
+	v, err := fromOTLP(av)
+	return Value{V: v}, err
+}
+
+// FromOTLPAttributes converts OpenTelemetry attributes in their OTLP/JSON
+// encoding, as produced by ToOTLPAttributes, back to an Object, in order.
+func FromOTLPAttributes(attrs []interface{}) (*Object, error) {
+	obj := NewObjectWithCapacity(len(attrs))
+	for _, a := range attrs {
+		kv, ok := a.(*Object)
+		if !ok || kv == nil {
+			return nil, errors.New("otlp attribute must be an object")
+		}
+		kval, _ := kv.Get("key")
+		k, ok := kval.(string)
+		if !ok {
+			return nil, errors.New(`otlp attribute "key" must be a string`)
+		}
+		vval, _ := kv.Get("value")
+		av, _ := vval.(*Object)
+		v, err := fromOTLP(av)
+		if err != nil {
+			return nil, fmt.Errorf("otlp attribute %q: %w", k, err)
+		}
+		obj.Set(k, v)
+	}
+	return obj, nil
+}
+
+func fromOTLP(av *Object) (interface{}, error) {
+	if av == nil || len(av.entries) == 0 {
+		return nil, nil
+	}
+	if len(av.entries) > 1 {
+		return nil, fmt.Errorf("otlp value has more than one field: %q", av.KeyOrder())
+	}
+	k, v := av.GetAt(0)
+	switch k {
+	case "stringValue", "bytesValue":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "boolValue":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "intValue", "doubleValue":
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case string:
+			if f, err := strconv.ParseFloat(n, 64); err == nil {
+				return f, nil
+			}
+		}
+	case "arrayValue":
+		values, err := otlpValues(v)
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]interface{}, len(values))
+		for i, e := range values {
+			e, _ := e.(*Object)
+			if arr[i], err = fromOTLP(e); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case "kvlistValue":
+		values, err := otlpValues(v)
+		if err != nil {
+			return nil, err
+		}
+		return FromOTLPAttributes(values)
+	default:
+		return nil, fmt.Errorf("unknown otlp value field %q", k)
+	}
+	return nil, fmt.Errorf("invalid otlp %s", k)
+}
+
+// otlpValues returns the "values" of an arrayValue or kvlistValue, which may
+// be omitted when empty.
+func otlpValues(v interface{}) ([]interface{}, error) {
+	obj, ok := v.(*Object)
+	if !ok || obj == nil {
+		return nil, errors.New("otlp array or kvlist must be an object")
+	}
+	values, ok := obj.Get("values")
+	if !ok {
+		return []interface{}{}, nil
+	}
+	arr, ok := values.([]interface{})
+	if !ok {
+		return nil, errors.New(`otlp "values" must be an array`)
+	}
+	return arr, nil
+}
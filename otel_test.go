@@ -0,0 +1,68 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLP(tt *testing.T) {
+	tt.Run("any value", func(t *testing.T) {
+		require := require.New(t)
+		const in = `{"z":"s","y":[1,2.5,true,null],"x":{}}`
+		v := MustNewValueFromJSON(in)
+		av, err := ToOTLPAnyValue(v)
+		require.NoError(err)
+		b, err := json.Marshal(av)
+		require.NoError(err)
+		require.Equal(`{"kvlistValue":{"values":[`+
+			`{"key":"z","value":{"stringValue":"s"}},`+
+			`{"key":"y","value":{"arrayValue":{"values":[{"intValue":"1"},{"doubleValue":2.5},{"boolValue":true},{}]}}},`+
+			`{"key":"x","value":{"kvlistValue":{"values":[]}}}]}}`, string(b))
+
+		// Round trip through the encoding, as a collector would.
+		back, err := FromOTLPAnyValue(MustNewValueFromJSON(string(b)).V.(*Object))
+		require.NoError(err)
+		b, err = json.Marshal(back)
+		require.NoError(err)
+		require.Equal(in, string(b))
+	})
+
+	tt.Run("attributes", func(t *testing.T) {
+		require := require.New(t)
+		at := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		o := NewObject().
+			SetAndReturn("http.status_code", json.Number("200")).
+			SetAndReturn("at", at).
+			SetAndReturn("ratio", 0.5)
+		attrs, err := ToOTLPAttributes(o)
+		require.NoError(err)
+		b, err := json.Marshal(attrs)
+		require.NoError(err)
+		require.Equal(`[{"key":"http.status_code","value":{"intValue":"200"}},`+
+			`{"key":"at","value":{"stringValue":"2021-01-02T03:04:05Z"}},`+
+			`{"key":"ratio","value":{"doubleValue":0.5}}]`, string(b))
+
+		back, err := FromOTLPAttributes(MustNewValueFromJSON(string(b)).V.([]interface{}))
+		require.NoError(err)
+		b, err = json.Marshal(back)
+		require.NoError(err)
+		require.Equal(`{"http.status_code":200,"at":"2021-01-02T03:04:05Z","ratio":0.5}`, string(b))
+	})
+
+	tt.Run("invalid", func(t *testing.T) {
+		for _, in := range []string{
+			`{"stringValue":1}`,
+			`{"intValue":"x"}`,
+			`{"stringValue":"a","boolValue":true}`,
+			`{"mapValue":{}}`,
+			`{"arrayValue":{"values":1}}`,
+			`{"kvlistValue":{"values":[{"key":1}]}}`,
+		} {
+			_, err := FromOTLPAnyValue(MustNewValueFromJSON(in).V.(*Object))
+			require.Error(t, err, in)
+		}
+	})
+}
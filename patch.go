@@ -0,0 +1,580 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PatchOp is a single operation of a JSON Patch (RFC 6902).
+type PatchOp struct {
+	// Op is the operation: "add", "remove", "replace", "move", "copy" or
+	// "test".
+	Op string
+	// Path is the JSON Pointer of the location the operation targets.
+	Path string
+	// From is the JSON Pointer of the source location of "move" and "copy".
+	From string
+	// Value is the value of "add", "replace" and "test".
+	Value interface{}
+}
+
+// Patch is a JSON Patch (RFC 6902): a sequence of operations that are
+// applied in order.
+type Patch []PatchOp
+
+var _ json.Marshaler = PatchOp{}
+var _ json.Unmarshaler = &PatchOp{}
+
+// MarshalJSON encodes the operation with only the members it uses.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	obj := NewObject().
+		SetAndReturn("op", op.Op).
+		SetAndReturn("path", op.Path)
+	switch op.Op {
+	case "move", "copy":
+		obj.Set("from", op.From)
+	case "add", "replace", "test":
+		obj.Set("value", op.Value)
+	}
+	return obj.MarshalJSON()
+}
+
+// UnmarshalJSON decodes an operation, with any objects in its value decoded
+// as *Object.
+func (op *PatchOp) UnmarshalJSON(b []byte) error {
+	var v Value
+	if err := v.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	obj, ok := v.V.(*Object)
+	if !ok || obj == nil {
+		return errors.New("patch operation must be an object")
+	}
+	member := func(k string) (string, error) {
+		v, _ := obj.Get(k)
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("patch operation %q must be a string", k)
+		}
+		return s, nil
+	}
+	*op = PatchOp{}
+	var err error
+	if op.Op, err = member("op"); err != nil {
+		return err
+	}
+	if op.Path, err = member("path"); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "remove":
+	case "move", "copy":
+		if op.From, err = member("from"); err != nil {
+			return err
+		}
+	case "add", "replace", "test":
+		if op.Value, ok = obj.Get("value"); !ok {
+			return fmt.Errorf("patch operation %q is missing \"value\"", op.Op)
+		}
+	default:
+		return fmt.Errorf("unknown patch operation %q", op.Op)
+	}
+	return nil
+}
+
+// PatchError is returned when an operation of a Patch can't be applied.
+type PatchError struct {
+	// Index is the position of the operation in the Patch.
+	Index int
+	Op    PatchOp
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s %q): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errPatchNotFound   = errors.New("path not found")
+	errPatchIndex      = errors.New("array index out of range")
+	errPatchParent     = errors.New("parent is not an object or array")
+	errPatchRemoveRoot = errors.New("cannot remove the whole document")
+	errPatchTestFailed = errors.New("test failed")
+)
+
+// ApplyPatch returns the result of applying patch to doc. If an operation
+// fails, it returns a *PatchError. doc itself is not modified, and the result
+// does not share any Objects or arrays with doc or patch.
+func ApplyPatch(doc Value, patch Patch) (Value, error) {
+	v := copyValue(doc.V)
+	for i, op := range patch {
+		var err error
+		if v, err = applyPatchOp(v, op); err != nil {
+			return Value{}, &PatchError{Index: i, Op: op, Err: err}
+		}
+	}
+	return Value{V: v}, nil
+}
+
+// applyPatchOp applies op to root, modifying it, and returns the new root.
+func applyPatchOp(root interface{}, op PatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add":
+		return patchAdd(root, path, copyValue(op.Value))
+	case "remove":
+		root, _, err := patchRemove(root, path)
+		return root, err
+	case "replace":
+		return patchReplace(root, path, copyValue(op.Value))
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.From == op.Path {
+			if _, ok := resolvePointer(root, from); !ok {
+				return nil, errPatchNotFound
+			}
+			return root, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		root, v, err := patchRemove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(root, path, v)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := resolvePointer(root, from)
+		if !ok {
+			return nil, errPatchNotFound
+		}
+		return patchAdd(root, path, copyValue(v))
+	case "test":
+		v, ok := resolvePointer(root, path)
+		if !ok {
+			return nil, errPatchNotFound
+		}
+		if CompareValues(v, op.Value) != 0 {
+			return nil, errPatchTestFailed
+		}
+		return root, nil
+	default:
+		return nil, fmt.Errorf("unknown patch operation %q", op.Op)
+	}
+}
+
+func patchAdd(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return modifyParent(root, path, func(parent interface{}, t string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case *Object:
+			parent.Set(t, v)
+			return parent, nil
+		case []interface{}:
+			if t == "-" {
+				return append(parent, v), nil
+			}
+			i, ok := parseArrayIndex(t)
+			if !ok || i > len(parent) {
+				return nil, errPatchIndex
+			}
+			parent = append(parent, nil)
+			copy(parent[i+1:], parent[i:])
+			parent[i] = v
+			return parent, nil
+		default:
+			return nil, errPatchParent
+		}
+	})
+}
+
+// patchRemove removes the value at path, returning the new root and the
+// removed value.
+func patchRemove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errPatchRemoveRoot
+	}
+	var removed interface{}
+	root, err := modifyParent(root, path, func(parent interface{}, t string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case *Object:
+			v, ok := parent.Get(t)
+			if !ok {
+				return nil, errPatchNotFound
+			}
+			removed = v
+			parent.Delete(t)
+			return parent, nil
+		case []interface{}:
+			i, ok := parseArrayIndex(t)
+			if !ok || i >= len(parent) {
+				return nil, errPatchIndex
+			}
+			removed = parent[i]
+			return append(parent[:i], parent[i+1:]...), nil
+		default:
+			return nil, errPatchParent
+		}
+	})
+	return root, removed, err
+}
+
+func patchReplace(root interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return modifyParent(root, path, func(parent interface{}, t string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case *Object:
+			if !parent.Has(t) {
+				return nil, errPatchNotFound
+			}
+			parent.Set(t, v)
+			return parent, nil
+		case []interface{}:
+			i, ok := parseArrayIndex(t)
+			if !ok || i >= len(parent) {
+				return nil, errPatchIndex
+			}
+			parent[i] = v
+			return parent, nil
+		default:
+			return nil, errPatchParent
+		}
+	})
+}
+
+// modifyParent replaces the Object or array containing the last token of
+// path with the result of calling fn on it, and returns the new root. Arrays
+// may be reallocated by fn, so the new array is stored back into its own
+// parent.
+func modifyParent(root interface{}, path []string, fn func(parent interface{}, t string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(root, path[0])
+	}
+	t := path[0]
+	switch c := root.(type) {
+	case *Object:
+		if c == nil {
+			return nil, errPatchNotFound
+		}
+		i := c.find(t)
+		if i < 0 {
+			return nil, errPatchNotFound
+		}
+		child, err := modifyParent(c.entries[i].Value, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c.entries[i].Value = child
+		return c, nil
+	case []interface{}:
+		i, ok := parseArrayIndex(t)
+		if !ok || i >= len(c) {
+			return nil, errPatchNotFound
+		}
+		child, err := modifyParent(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	default:
+		return nil, errPatchNotFound
+	}
+}
+
+// ComposePatches concatenates patches into a single Patch, squashing
+// operations that are made redundant by later ones: consecutive writes to
+// the same location are merged, writes inside a value that was just added or
+// replaced are folded into that value, and writes that are later replaced or
+// removed wholesale are dropped. The result has the same effect as applying
+// the patches in turn.
+//
+// Squashing assumes that the patches apply successfully, and that an "add"
+// of an object member adds a new member rather than replacing an existing
+// one, as is the case for patches produced by diffing, and that tokens that
+// are array indexes, such as 0, refer to array elements. Only operations whose
+// locations can be seen to be independent of everything in between are
+// squashed, so "move", "copy" and "test" operations are kept as they are.
+func ComposePatches(patches ...Patch) Patch {
+	var out Patch
+	for _, patch := range patches {
+		for _, op := range patch {
+			out = composeOp(out, op)
+		}
+	}
+	if out == nil {
+		out = Patch{}
+	}
+	return out
+}
+
+// composeOp appends op to out, squashing it with earlier operations where
+// possible.
+func composeOp(out Patch, op PatchOp) Patch {
+	switch op.Op {
+	case "add", "replace", "remove":
+	default:
+		return append(out, op)
+	}
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return append(out, op)
+	}
+	for {
+		j := lastRelatedOp(out, path)
+		if j < 0 {
+			return append(out, op)
+		}
+		prev := &out[j]
+		prevPath, _ := parsePointer(prev.Path)
+		if prev.Op != "add" && prev.Op != "replace" && prev.Op != "remove" {
+			return append(out, op)
+		}
+		switch {
+		case !hasPointerPrefix(prevPath, path) && !hasPointerPrefix(path, prevPath):
+			// The locations differ at an array index.
+			return append(out, op)
+
+		case len(prevPath) == len(path):
+			// Both write to the same location.
+			last := path[len(path)-1:]
+			member := len(path) > 0 && !isArrayToken(last[0])
+			switch {
+			case prev.Op == "remove" && op.Op == "add" && len(path) > 0 && !member && last[0] != "-":
+				// Re-adding an array element puts it back where it was, but
+				// re-adding an object member moves it to the end, which
+				// "replace" wouldn't.
+				*prev = PatchOp{Op: "replace", Path: prev.Path, Value: op.Value}
+			case prev.Op == "remove":
+				return append(out, op)
+			case op.Op == "replace" || op.Op == "add" && (member || len(path) == 0):
+				prev.Value = op.Value
+			case op.Op == "remove" && prev.Op == "replace":
+				*prev = op
+			case op.Op == "remove" && prev.Op == "add" && (len(path) == 0 || last[0] != "-"):
+				return append(out[:j], out[j+1:]...)
+			default:
+				return append(out, op)
+			}
+			return out
+
+		case hasPointerPrefix(path, prevPath):
+			// op writes inside the value prev wrote.
+			if prev.Op == "remove" {
+				return append(out, op)
+			}
+			rel := op
+			rel.Path = Pointer(path[len(prevPath):]).String()
+			v, err := applyPatchOp(copyValue(prev.Value), rel)
+			if err != nil {
+				return append(out, op)
+			}
+			prev.Value = v
+			return out
+
+		default:
+			// op overwrites the value prev wrote inside of.
+			if op.Op == "add" {
+				return append(out, op)
+			}
+			out = append(out[:j], out[j+1:]...)
+		}
+	}
+}
+
+// lastRelatedOp returns the index of the last operation in ops that may
+// affect or be affected by a write to path, or -1 if there is none.
+func lastRelatedOp(ops Patch, path []string) int {
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if pointersRelated(op.Path, path) || (op.Op == "move" || op.Op == "copy") && pointersRelated(op.From, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pointersRelated reports whether the locations p and q may overlap or
+// affect each other. Locations that differ at an array index are assumed to
+// be related, since inserting or removing an element shifts its siblings.
+func pointersRelated(p string, q []string) bool {
+	tokens, err := parsePointer(p)
+	if err != nil {
+		return true
+	}
+	for i := 0; i < len(tokens) && i < len(q); i++ {
+		if tokens[i] != q[i] {
+			return isArrayToken(tokens[i]) || isArrayToken(q[i])
+		}
+	}
+	return true
+}
+
+// hasPointerPrefix reports whether the location p is within, or the same as,
+// the location prefix.
+func hasPointerPrefix(p, prefix []string) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i, t := range prefix {
+		if p[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// isArrayToken reports whether the reference token t may refer to an array
+// element.
+func isArrayToken(t string) bool {
+	if t == "-" {
+		return true
+	}
+	_, ok := parseArrayIndex(t)
+	return ok
+}
+
+// InvertPatch returns the patch that undoes patch, given the document it is
+// applied to: applying patch to doc and then the inverse gives back doc. If
+// an operation of patch fails, it returns a *PatchError.
+//
+// JSON Patch can't express the position of an object member, so members
+// that patch removes or moves away are restored at the end of their object.
+func InvertPatch(doc Value, patch Patch) (Patch, error) {
+	v := copyValue(doc.V)
+	undo := make([]Patch, 0, len(patch))
+	for i, op := range patch {
+		var inv Patch
+		var err error
+		if v, inv, err = invertPatchOp(v, op); err != nil {
+			return nil, &PatchError{Index: i, Op: op, Err: err}
+		}
+		undo = append(undo, inv)
+	}
+	inverse := Patch{}
+	for i := len(undo) - 1; i >= 0; i-- {
+		inverse = append(inverse, undo[i]...)
+	}
+	return inverse, nil
+}
+
+// invertPatchOp applies op to root, like applyPatchOp, and also returns the
+// operations that undo it.
+func invertPatchOp(root interface{}, op PatchOp) (interface{}, Patch, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var undo Patch
+	switch op.Op {
+	case "add", "copy":
+		undo, _ = undoAdd(root, path)
+	case "remove", "replace":
+		if old, ok := resolvePointer(root, path); ok {
+			inv := "replace"
+			if op.Op == "remove" {
+				inv = "add"
+			}
+			undo = Patch{{Op: inv, Path: op.Path, Value: old}}
+		}
+	case "move":
+		if op.From == op.Path {
+			break
+		}
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, nil, errors.New("cannot move a value into itself")
+		}
+		// Apply the move in two steps, since the target is only known once
+		// the value has been removed from its source.
+		root, v, err := patchRemove(root, from)
+		if err != nil {
+			return nil, nil, err
+		}
+		restore, target := undoAdd(root, path)
+		if root, err = patchAdd(root, path, v); err != nil {
+			return nil, nil, err
+		}
+		undo = Patch{{Op: "move", From: target, Path: op.From}}
+		if len(restore) > 0 && restore[0].Op == "replace" {
+			undo = append(undo, PatchOp{Op: "add", Path: target, Value: restore[0].Value})
+		}
+		return root, undo, nil
+	}
+	root, err = applyPatchOp(root, op)
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, undo, nil
+}
+
+// undoAdd returns the operations that undo adding a value at path to root,
+// along with the location the value is added at, which differs from path
+// when appending to an array with "-".
+func undoAdd(root interface{}, path []string) (Patch, string) {
+	p := Pointer(path).String()
+	if len(path) == 0 {
+		return Patch{{Op: "replace", Path: p, Value: root}}, p
+	}
+	parent, _ := resolvePointer(root, path[:len(path)-1])
+	t := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *Object:
+		if old, ok := parent.Get(t); ok {
+			return Patch{{Op: "replace", Path: p, Value: old}}, p
+		}
+	case []interface{}:
+		if t == "-" {
+			p = Pointer(path).Parent().AppendIndex(len(parent)).String()
+		}
+	}
+	return Patch{{Op: "remove", Path: p}}, p
+}
+
+// TestPatch reports whether patch would apply to doc, without modifying doc.
+// It evaluates every operation, including "test" operations, and returns an
+// error for each one that would fail, in order; it returns nil if the whole
+// patch would apply. Operations after a failed one are evaluated as if the
+// failed one had been skipped, so later errors may be a consequence of
+// earlier ones.
+func TestPatch(doc Value, patch Patch) []*PatchError {
+	var errs []*PatchError
+	v := copyValue(doc.V)
+	for i, op := range patch {
+		// A move can fail after removing its value, so it is tried on a copy.
+		target := v
+		if op.Op == "move" {
+			target = copyValue(v)
+		}
+		result, err := applyPatchOp(target, op)
+		if err != nil {
+			errs = append(errs, &PatchError{Index: i, Op: op, Err: err})
+			continue
+		}
+		v = result
+	}
+	return errs
+}
@@ -0,0 +1,337 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustPatch(s string) Patch {
+	var p Patch
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestPatchJSON(tt *testing.T) {
+	require := require.New(tt)
+	const in = `[{"op":"add","path":"/a","value":{"z":1,"y":null}},{"op":"remove","path":"/b"},{"op":"move","path":"/c","from":"/d"},{"op":"test","path":"/e","value":null}]`
+	p := mustPatch(in)
+	require.Len(p, 4)
+	b, err := json.Marshal(p)
+	require.NoError(err)
+	require.Equal(in, string(b))
+
+	for _, bad := range []string{
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"copy","path":"/a"}]`,
+		`[{"path":"/a"}]`,
+		`[1]`,
+	} {
+		var p Patch
+		require.Error(json.Unmarshal([]byte(bad), &p), bad)
+	}
+}
+
+func TestApplyPatch(tt *testing.T) {
+	for _, test := range []struct {
+		name  string
+		doc   string
+		patch string
+		out   string
+		err   string
+	}{
+		{
+			name:  "add member",
+			doc:   `{"b":1}`,
+			patch: `[{"op":"add","path":"/a","value":2},{"op":"add","path":"/b","value":3}]`,
+			out:   `{"b":3,"a":2}`,
+		},
+		{
+			name:  "add element",
+			doc:   `{"a":[1,2]}`,
+			patch: `[{"op":"add","path":"/a/1","value":"x"},{"op":"add","path":"/a/-","value":"y"},{"op":"add","path":"/a/0","value":"z"}]`,
+			out:   `{"a":["z",1,"x",2,"y"]}`,
+		},
+		{
+			name:  "remove",
+			doc:   `{"a":[1,2,3],"b":{"c":1,"d":2}}`,
+			patch: `[{"op":"remove","path":"/a/1"},{"op":"remove","path":"/b/c"}]`,
+			out:   `{"a":[1,3],"b":{"d":2}}`,
+		},
+		{
+			name:  "replace",
+			doc:   `{"a":[1,2],"b":1,"c":2}`,
+			patch: `[{"op":"replace","path":"/a/0","value":9},{"op":"replace","path":"/b","value":{"x":true}}]`,
+			out:   `{"a":[9,2],"b":{"x":true},"c":2}`,
+		},
+		{
+			name:  "move and copy",
+			doc:   `{"a":{"b":1},"c":[1,2]}`,
+			patch: `[{"op":"move","path":"/d","from":"/a/b"},{"op":"copy","path":"/c/0","from":"/d"},{"op":"move","path":"/c/-","from":"/c/0"}]`,
+			out:   `{"a":{},"c":[1,2,1],"d":1}`,
+		},
+		{
+			name:  "test",
+			doc:   `{"a":{"x":1,"y":[2]}}`,
+			patch: `[{"op":"test","path":"/a","value":{"y":[2],"x":1}}]`,
+			out:   `{"a":{"x":1,"y":[2]}}`,
+		},
+		{
+			name:  "root",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"","value":[1]}]`,
+			out:   `[1]`,
+		},
+		{
+			name:  "failed test",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`,
+			err:   `patch operation 1 (test "/a"): test failed`,
+		},
+		{
+			name:  "missing",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"/b"}]`,
+			err:   `patch operation 0 (remove "/b"): path not found`,
+		},
+		{
+			name:  "missing parent",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b/c","value":1}]`,
+			err:   `patch operation 0 (add "/b/c"): path not found`,
+		},
+		{
+			name:  "index out of range",
+			doc:   `[1]`,
+			patch: `[{"op":"add","path":"/2","value":1}]`,
+			err:   `patch operation 0 (add "/2"): array index out of range`,
+		},
+		{
+			name:  "move into itself",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"move","path":"/a/b/c","from":"/a"}]`,
+			err:   `patch operation 0 (move "/a/b/c"): cannot move a value into itself`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			doc := MustNewValueFromJSON(test.doc)
+			v, err := ApplyPatch(doc, mustPatch(test.patch))
+			if test.err != "" {
+				require.EqualError(err, test.err)
+				var perr *PatchError
+				require.True(errors.As(err, &perr))
+				return
+			}
+			require.NoError(err)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// The document is unchanged.
+			b, err = json.Marshal(doc)
+			require.NoError(err)
+			require.Equal(test.doc, string(b))
+		})
+	}
+}
+
+func TestComposePatches(tt *testing.T) {
+	for _, test := range []struct {
+		name    string
+		doc     string
+		patches []string
+		out     string
+	}{
+		{
+			name:    "replace then replace",
+			doc:     `{"a":1,"b":1}`,
+			patches: []string{`[{"op":"replace","path":"/a","value":2}]`, `[{"op":"replace","path":"/b","value":2},{"op":"replace","path":"/a","value":3}]`},
+			out:     `[{"op":"replace","path":"/a","value":3},{"op":"replace","path":"/b","value":2}]`,
+		},
+		{
+			name:    "add then remove",
+			doc:     `{"a":1}`,
+			patches: []string{`[{"op":"add","path":"/b","value":2},{"op":"replace","path":"/a","value":2}]`, `[{"op":"remove","path":"/b"}]`},
+			out:     `[{"op":"replace","path":"/a","value":2}]`,
+		},
+		{
+			name:    "add then replace",
+			doc:     `{}`,
+			patches: []string{`[{"op":"add","path":"/b","value":2}]`, `[{"op":"replace","path":"/b","value":3}]`},
+			out:     `[{"op":"add","path":"/b","value":3}]`,
+		},
+		{
+			name:    "remove then add element",
+			doc:     `[1,2,3]`,
+			patches: []string{`[{"op":"remove","path":"/1"}]`, `[{"op":"add","path":"/1","value":4}]`},
+			out:     `[{"op":"replace","path":"/1","value":4}]`,
+		},
+		{
+			name:    "remove then add member",
+			doc:     `{"a":1,"b":2}`,
+			patches: []string{`[{"op":"remove","path":"/a"}]`, `[{"op":"add","path":"/a","value":3}]`},
+			out:     `[{"op":"remove","path":"/a"},{"op":"add","path":"/a","value":3}]`,
+		},
+		{
+			name:    "replace then remove",
+			doc:     `{"a":1}`,
+			patches: []string{`[{"op":"replace","path":"/a","value":2}]`, `[{"op":"remove","path":"/a"}]`},
+			out:     `[{"op":"remove","path":"/a"}]`,
+		},
+		{
+			name:    "write inside added value",
+			doc:     `{}`,
+			patches: []string{`[{"op":"add","path":"/a","value":{"x":[1]}}]`, `[{"op":"add","path":"/a/y","value":2},{"op":"add","path":"/a/x/-","value":3}]`},
+			out:     `[{"op":"add","path":"/a","value":{"x":[1,3],"y":2}}]`,
+		},
+		{
+			name:    "overwrite earlier nested writes",
+			doc:     `{"a":{"x":1}}`,
+			patches: []string{`[{"op":"replace","path":"/a/x","value":2},{"op":"add","path":"/a/y","value":2}]`, `[{"op":"replace","path":"/a","value":null}]`},
+			out:     `[{"op":"replace","path":"/a","value":null}]`,
+		},
+		{
+			name:    "array siblings are kept",
+			doc:     `[1,2,3]`,
+			patches: []string{`[{"op":"remove","path":"/0"}]`, `[{"op":"replace","path":"/1","value":9},{"op":"remove","path":"/0"}]`},
+			out:     `[{"op":"remove","path":"/0"},{"op":"replace","path":"/1","value":9},{"op":"remove","path":"/0"}]`,
+		},
+		{
+			name:    "test is a barrier",
+			doc:     `{"a":1}`,
+			patches: []string{`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":2}]`, `[{"op":"replace","path":"/a","value":3}]`},
+			out:     `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":2},{"op":"replace","path":"/a","value":3}]`,
+		},
+		{
+			name:    "move is a barrier",
+			doc:     `{"a":1}`,
+			patches: []string{`[{"op":"add","path":"/b","value":2},{"op":"move","path":"/c","from":"/b"}]`, `[{"op":"add","path":"/b","value":3}]`},
+			out:     `[{"op":"add","path":"/b","value":2},{"op":"move","path":"/c","from":"/b"},{"op":"add","path":"/b","value":3}]`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			var patches []Patch
+			for _, p := range test.patches {
+				patches = append(patches, mustPatch(p))
+			}
+			composed := ComposePatches(patches...)
+			b, err := json.Marshal(composed)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// Applying the composed patch is the same as applying each in
+			// turn, down to the order of object members.
+			want := MustNewValueFromJSON(test.doc)
+			for _, p := range patches {
+				want, err = ApplyPatch(want, p)
+				require.NoError(err)
+			}
+			got, err := ApplyPatch(MustNewValueFromJSON(test.doc), composed)
+			require.NoError(err)
+			wantJSON, err := json.Marshal(want)
+			require.NoError(err)
+			gotJSON, err := json.Marshal(got)
+			require.NoError(err)
+			require.Equal(string(wantJSON), string(gotJSON))
+		})
+	}
+
+	tt.Run("empty", func(t *testing.T) {
+		b, err := json.Marshal(ComposePatches())
+		require.NoError(t, err)
+		require.Equal(t, `[]`, string(b))
+	})
+}
+
+func TestInvertPatch(tt *testing.T) {
+	for _, test := range []struct {
+		name    string
+		doc     string
+		patch   string
+		inverse string
+	}{
+		{
+			name:    "add and remove",
+			doc:     `{"a":1,"b":[1,2]}`,
+			patch:   `[{"op":"add","path":"/c","value":3},{"op":"add","path":"/a","value":2},{"op":"remove","path":"/b/0"},{"op":"add","path":"/b/-","value":4}]`,
+			inverse: `[{"op":"remove","path":"/b/1"},{"op":"add","path":"/b/0","value":1},{"op":"replace","path":"/a","value":1},{"op":"remove","path":"/c"}]`,
+		},
+		{
+			name:    "replace",
+			doc:     `{"a":{"x":1}}`,
+			patch:   `[{"op":"replace","path":"/a","value":null},{"op":"replace","path":"","value":[]}]`,
+			inverse: `[{"op":"replace","path":"","value":{"a":null}},{"op":"replace","path":"/a","value":{"x":1}}]`,
+		},
+		{
+			name:    "move",
+			doc:     `{"a":[1,2],"b":{"x":1,"y":2}}`,
+			patch:   `[{"op":"move","path":"/a/-","from":"/a/0"},{"op":"move","path":"/b/y","from":"/b/x"}]`,
+			inverse: `[{"op":"move","path":"/b/x","from":"/b/y"},{"op":"add","path":"/b/y","value":2},{"op":"move","path":"/a/0","from":"/a/1"}]`,
+		},
+		{
+			name:    "copy and test",
+			doc:     `{"a":[1]}`,
+			patch:   `[{"op":"test","path":"/a/0","value":1},{"op":"copy","path":"/a/0","from":"/a"}]`,
+			inverse: `[{"op":"remove","path":"/a/0"}]`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			doc := MustNewValueFromJSON(test.doc)
+			patch := mustPatch(test.patch)
+			inverse, err := InvertPatch(doc, patch)
+			require.NoError(err)
+			b, err := json.Marshal(inverse)
+			require.NoError(err)
+			require.Equal(test.inverse, string(b))
+
+			patched, err := ApplyPatch(doc, patch)
+			require.NoError(err)
+			restored, err := ApplyPatch(patched, inverse)
+			require.NoError(err)
+			require.Equal(0, CompareValues(doc.V, restored.V))
+		})
+	}
+
+	tt.Run("error", func(t *testing.T) {
+		_, err := InvertPatch(MustNewValueFromJSON(`{}`), mustPatch(`[{"op":"remove","path":"/a"}]`))
+		require.EqualError(t, err, `patch operation 0 (remove "/a"): path not found`)
+	})
+}
+
+func TestTestPatch(tt *testing.T) {
+	require := require.New(tt)
+	const in = `{"a":1,"b":[1]}`
+	doc := MustNewValueFromJSON(in)
+
+	require.Nil(TestPatch(doc, mustPatch(`[{"op":"test","path":"/a","value":1},{"op":"add","path":"/b/-","value":2},{"op":"remove","path":"/b/1"}]`)))
+
+	errs := TestPatch(doc, mustPatch(`[
+		{"op":"test","path":"/a","value":2},
+		{"op":"move","path":"/missing/x","from":"/a"},
+		{"op":"replace","path":"/a","value":3},
+		{"op":"remove","path":"/b/1"},
+		{"op":"test","path":"/a","value":3}
+	]`))
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	require.Equal([]string{
+		`patch operation 0 (test "/a"): test failed`,
+		`patch operation 1 (move "/missing/x"): path not found`,
+		`patch operation 3 (remove "/b/1"): array index out of range`,
+	}, msgs)
+
+	// The document is unchanged.
+	b, err := json.Marshal(doc)
+	require.NoError(err)
+	require.Equal(in, string(b))
+}
@@ -0,0 +1,208 @@
+package ojson
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pointer is a JSON Pointer (RFC 6901), held as its unescaped reference
+// tokens. The empty Pointer refers to the whole document.
+type Pointer []string
+
+// ParsePointer parses the string form of a JSON Pointer, such as "/a/0/b~1c".
+func ParsePointer(s string) (Pointer, error) {
+	tokens, err := parsePointer(s)
+	return Pointer(tokens), err
+}
+
+// Append returns a new Pointer with the given unescaped tokens added. p
+// itself is not modified.
+func (p Pointer) Append(tokens ...string) Pointer {
+	return append(p[:len(p):len(p)], tokens...)
+}
+
+// AppendIndex returns a new Pointer with the array index i added.
+func (p Pointer) AppendIndex(i int) Pointer {
+	return p.Append(strconv.Itoa(i))
+}
+
+// Parent returns the Pointer to the object or array containing the value p
+// refers to. The parent of the empty Pointer is itself.
+func (p Pointer) Parent() Pointer {
+	if len(p) == 0 {
+		return p
+	}
+	return p[: len(p)-1 : len(p)-1]
+}
+
+// String returns the string form of p, with each token escaped.
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, t := range p {
+		b.WriteByte('/')
+		b.WriteString(escapePointerToken(t))
+	}
+	return b.String()
+}
+
+// Resolve returns the value within v that p refers to.
+func (p Pointer) Resolve(v Value) (interface{}, bool) {
+	return resolvePointer(v.V, p)
+}
+
+// escapePointerToken escapes a reference token for use in a JSON Pointer.
+func escapePointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// unescapePointerToken reverses escapePointerToken.
+func unescapePointerToken(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference
+// tokens. The empty pointer refers to the whole document and has no tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, errors.New("json pointer must be empty or start with /")
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = unescapePointerToken(t)
+	}
+	return tokens, nil
+}
+
+// parseArrayIndex parses a reference token as an index into an array,
+// rejecting leading zeros and signs as required by RFC 6901.
+func parseArrayIndex(t string) (int, bool) {
+	if t == "" || (len(t) > 1 && t[0] == '0') {
+		return 0, false
+	}
+	n := 0
+	for _, c := range []byte(t) {
+		if !isDigit(c) {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+		if n < 0 {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+// resolvePointer returns the value within v at the location identified by
+// tokens.
+func resolvePointer(v interface{}, tokens []string) (interface{}, bool) {
+	for _, t := range tokens {
+		switch x := v.(type) {
+		case *Object:
+			if x == nil {
+				return nil, false
+			}
+			var ok bool
+			if v, ok = x.Get(t); !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, ok := parseArrayIndex(t)
+			if !ok || i >= len(x) {
+				return nil, false
+			}
+			v = x[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// ResolveRelativePointer evaluates the Relative JSON Pointer rel, such as
+// "1/name" or "0#", against the value in root at the JSON Pointer location,
+// such as the Path of a PathedValue. It supports index manipulation, as in
+// "0+1" for the next element of an array.
+//
+// A pointer ending in "#" returns the key of the value it refers to, as a
+// string, or its index in its array, as a float64. Otherwise it returns the
+// value itself.
+func ResolveRelativePointer(root Value, location, rel string) (interface{}, error) {
+	tokens, err := parsePointer(location)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resolvePointer(root.V, tokens); !ok {
+		return nil, fmt.Errorf("location %q not found", location)
+	}
+
+	// The pointer starts with the number of levels to go up.
+	i := 0
+	for i < len(rel) && isDigit(rel[i]) {
+		i++
+	}
+	up, ok := parseArrayIndex(rel[:i])
+	if !ok {
+		return nil, fmt.Errorf("invalid relative json pointer %q", rel)
+	}
+	if up > len(tokens) {
+		return nil, fmt.Errorf("relative json pointer %q goes above the root", rel)
+	}
+	tokens = tokens[:len(tokens)-up]
+
+	// Then optionally an offset to apply to an array index.
+	if i < len(rel) && (rel[i] == '+' || rel[i] == '-') {
+		j := i + 1
+		for j < len(rel) && isDigit(rel[j]) {
+			j++
+		}
+		delta, ok := parseArrayIndex(rel[i+1 : j])
+		if !ok {
+			return nil, fmt.Errorf("invalid relative json pointer %q", rel)
+		}
+		if rel[i] == '-' {
+			delta = -delta
+		}
+		var arr []interface{}
+		if len(tokens) > 0 {
+			parent, _ := resolvePointer(root.V, tokens[:len(tokens)-1])
+			arr, _ = parent.([]interface{})
+		}
+		if arr == nil {
+			return nil, fmt.Errorf("relative json pointer %q adjusts the index of a value not in an array", rel)
+		}
+		index, _ := parseArrayIndex(tokens[len(tokens)-1])
+		if index += delta; index < 0 || index >= len(arr) {
+			return nil, fmt.Errorf("relative json pointer %q refers to index %d, out of range", rel, index)
+		}
+		tokens = append(tokens[:len(tokens)-1:len(tokens)-1], strconv.Itoa(index))
+		i = j
+	}
+
+	// And finally "#", or a JSON Pointer relative to that value.
+	if rel[i:] == "#" {
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("relative json pointer %q refers to the key of the root", rel)
+		}
+		parent, _ := resolvePointer(root.V, tokens[:len(tokens)-1])
+		last := tokens[len(tokens)-1]
+		if _, ok := parent.([]interface{}); ok {
+			index, _ := parseArrayIndex(last)
+			return float64(index), nil
+		}
+		return last, nil
+	}
+	rest, err := parsePointer(rel[i:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid relative json pointer %q: %w", rel, err)
+	}
+	v, ok := resolvePointer(root.V, append(tokens, rest...))
+	if !ok {
+		return nil, fmt.Errorf("relative json pointer %q not found", rel)
+	}
+	return v, nil
+}
@@ -0,0 +1,95 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRelativePointer(tt *testing.T) {
+	root := MustNewValueFromJSON(`{"foo":["bar","baz"],"highly":{"nested":{"objects":true}}}`)
+	for _, test := range []struct {
+		location string
+		rel      string
+		out      interface{}
+		err      string
+	}{
+		// The examples from the Relative JSON Pointer draft.
+		{location: "/foo/1", rel: "0", out: "baz"},
+		{location: "/foo/1", rel: "1/0", out: "bar"},
+		{location: "/foo/1", rel: "0-1", out: "bar"},
+		{location: "/foo/1", rel: "2/highly/nested/objects", out: true},
+		{location: "/foo/1", rel: "0#", out: 1.0},
+		{location: "/foo/1", rel: "0-1#", out: 0.0},
+		{location: "/foo/1", rel: "1#", out: "foo"},
+		{location: "/highly/nested", rel: "0/objects", out: true},
+		{location: "/highly/nested", rel: "1/nested/objects", out: true},
+		{location: "/highly/nested", rel: "2/foo/0", out: "bar"},
+		{location: "/highly/nested", rel: "0#", out: "nested"},
+		{location: "/highly/nested", rel: "1#", out: "highly"},
+
+		{location: "/foo/1", rel: "3", err: `relative json pointer "3" goes above the root`},
+		{location: "/foo/1", rel: "0+1", err: `relative json pointer "0+1" refers to index 2, out of range`},
+		{location: "/highly", rel: "0+1", err: `relative json pointer "0+1" adjusts the index of a value not in an array`},
+		{location: "/foo", rel: "1#", err: `relative json pointer "1#" refers to the key of the root`},
+		{location: "/foo", rel: "01", err: `invalid relative json pointer "01"`},
+		{location: "/foo", rel: "0/2", err: `relative json pointer "0/2" not found`},
+		{location: "/missing", rel: "0", err: `location "/missing" not found`},
+	} {
+		tt.Run(test.location+" "+test.rel, func(t *testing.T) {
+			require := require.New(t)
+			v, err := ResolveRelativePointer(root, test.location, test.rel)
+			if test.err != "" {
+				require.EqualError(err, test.err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(test.out, v)
+		})
+	}
+}
+
+func TestPointer(tt *testing.T) {
+	tt.Run("build", func(t *testing.T) {
+		require := require.New(t)
+		p := Pointer{}.Append("a/b", "m~n").AppendIndex(0)
+		require.Equal("/a~1b/m~0n/0", p.String())
+		require.Equal("/a~1b/m~0n", p.Parent().String())
+		require.Equal("", Pointer{}.String())
+		require.Equal("", Pointer{}.Parent().String())
+
+		// Appending to a shared prefix doesn't clobber other pointers.
+		base := Pointer{"x"}.Append("y")
+		a, b := base.Append("a"), base.Append("b")
+		require.Equal("/x/y/a", a.String())
+		require.Equal("/x/y/b", b.String())
+		c, d := b.Parent().Append("c"), b.Parent().Append("d")
+		require.Equal("/x/y/c", c.String())
+		require.Equal("/x/y/d", d.String())
+	})
+
+	tt.Run("parse", func(t *testing.T) {
+		require := require.New(t)
+		for _, s := range []string{"", "/", "/a~1b/m~0n/0", "/~01"} {
+			p, err := ParsePointer(s)
+			require.NoError(err)
+			require.Equal(s, p.String())
+		}
+		p, err := ParsePointer("/~01")
+		require.NoError(err)
+		require.Equal(Pointer{"~1"}, p)
+
+		_, err = ParsePointer("a")
+		require.Error(err)
+	})
+
+	tt.Run("resolve", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"a/b":[{"c":1}]}`)
+		got, ok := Pointer{"a/b"}.AppendIndex(0).Append("c").Resolve(v)
+		require.True(ok)
+		require.Equal(1.0, got)
+		_, ok = Pointer{"a/b"}.AppendIndex(1).Resolve(v)
+		require.False(ok)
+	})
+}
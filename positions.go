@@ -0,0 +1,88 @@
+package ojson
+
+// Position is a location in the input a value was decoded from.
+type Position struct {
+	// Offset is the byte offset, starting at 0.
+	Offset int
+	// Line and Column start at 1. Column counts bytes, not characters.
+	Line   int
+	Column int
+}
+
+// positions records where the values of a decoded document appeared in its
+// input. It is shared by every Object in the document.
+type positions struct {
+	// entries is keyed by JSON Pointer from the document's root.
+	entries map[string]*entryPosition
+}
+
+type entryPosition struct {
+	key, value Position
+	hasKey     bool
+}
+
+// objectPositions locates an Object within a document's positions.
+type objectPositions struct {
+	*positions
+	pointer string
+}
+
+// Position returns where the value at the JSON Pointer, relative to o,
+// started in the input, if o was decoded with DecodeOptions.Positions.
+// Positions are not updated when the Object is modified.
+func (o *Object) Position(pointer string) (Position, bool) {
+	e, ok := o.entryPosition(pointer)
+	if !ok {
+		return Position{}, false
+	}
+	return e.value, true
+}
+
+// KeyPosition returns where the key of the object member at the JSON
+// Pointer, relative to o, started in the input, if o was decoded with
+// DecodeOptions.Positions. It returns false for array elements.
+func (o *Object) KeyPosition(pointer string) (Position, bool) {
+	e, ok := o.entryPosition(pointer)
+	if !ok || !e.hasKey {
+		return Position{}, false
+	}
+	return e.key, true
+}
+
+func (o *Object) entryPosition(pointer string) (*entryPosition, bool) {
+	if o.positions == nil {
+		return nil, false
+	}
+	if _, err := parsePointer(pointer); err != nil {
+		return nil, false
+	}
+	e, ok := o.positions.entries[o.positions.pointer+pointer]
+	return e, ok
+}
+
+// lineCounter converts increasing byte offsets in data to Positions.
+type lineCounter struct {
+	data      []byte
+	off       int
+	line      int
+	lineStart int
+}
+
+func (c *lineCounter) position(off int) Position {
+	if c.line == 0 {
+		c.line = 1
+	}
+	for ; c.off < off && c.off < len(c.data); c.off++ {
+		if c.data[c.off] == '\n' {
+			c.line++
+			c.lineStart = c.off + 1
+		}
+	}
+	return Position{Offset: off, Line: c.line, Column: off - c.lineStart + 1}
+}
+
+// positionOf returns the Position of offset off in data.
+func positionOf(data []byte, off int) Position {
+	c := lineCounter{data: data}
+	return c.position(off)
+}
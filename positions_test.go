@@ -0,0 +1,58 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositions(tt *testing.T) {
+	const in = "{\n  \"a\": 1,\n  \"b\": [true, {\"c\": null}],\n  \"d~/\" : \"x\"\n}"
+	v, err := DecodeOptions{Positions: true}.Unmarshal([]byte(in))
+	require.NoError(tt, err)
+	obj := v.V.(*Object)
+
+	for _, test := range []struct {
+		pointer string
+		key     Position
+		value   Position
+	}{
+		{pointer: "", value: Position{Offset: 0, Line: 1, Column: 1}},
+		{pointer: "/a", key: Position{Offset: 4, Line: 2, Column: 3}, value: Position{Offset: 9, Line: 2, Column: 8}},
+		{pointer: "/b", key: Position{Offset: 14, Line: 3, Column: 3}, value: Position{Offset: 19, Line: 3, Column: 8}},
+		{pointer: "/b/0", value: Position{Offset: 20, Line: 3, Column: 9}},
+		{pointer: "/b/1", value: Position{Offset: 26, Line: 3, Column: 15}},
+		{pointer: "/b/1/c", key: Position{Offset: 27, Line: 3, Column: 16}, value: Position{Offset: 32, Line: 3, Column: 21}},
+		{pointer: "/d~0~1", key: Position{Offset: 42, Line: 4, Column: 3}, value: Position{Offset: 50, Line: 4, Column: 11}},
+	} {
+		tt.Run(test.pointer, func(t *testing.T) {
+			require := require.New(t)
+			pos, ok := obj.Position(test.pointer)
+			require.True(ok)
+			require.Equal(test.value, pos)
+
+			key, ok := obj.KeyPosition(test.pointer)
+			require.Equal(test.key != Position{}, ok)
+			require.Equal(test.key, key)
+		})
+	}
+
+	tt.Run("nested objects", func(t *testing.T) {
+		require := require.New(t)
+		arr, _ := obj.Get("b")
+		nested := arr.([]interface{})[1].(*Object)
+		pos, ok := nested.Position("/c")
+		require.True(ok)
+		require.Equal(Position{Offset: 32, Line: 3, Column: 21}, pos)
+	})
+
+	tt.Run("missing", func(t *testing.T) {
+		require := require.New(t)
+		for _, pointer := range []string{"/z", "/b/2", "a"} {
+			_, ok := obj.Position(pointer)
+			require.False(ok, pointer)
+		}
+		_, ok := MustNewValueFromJSON(`{"a":1}`).V.(*Object).Position("/a")
+		require.False(ok)
+	})
+}
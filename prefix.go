@@ -0,0 +1,132 @@
+package ojson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrefixOptions configures AddKeyPrefix and StripKeyPrefix.
+type PrefixOptions struct {
+	// Recursive also renames the keys of objects nested at any depth,
+	// including inside arrays.
+	Recursive bool
+	// Path is the JSON Pointer of the object whose keys are renamed. It
+	// defaults to the top-level value.
+	Path string
+}
+
+// AddKeyPrefix prepends prefix to the keys of the object selected by opts,
+// in place. Keys keep their positions.
+func AddKeyPrefix(v Value, prefix string, opts PrefixOptions) error {
+	return renameKeysAt(v, opts, func(k string) string {
+		return prefix + k
+	})
+}
+
+// StripKeyPrefix removes prefix from the keys of the object selected by
+// opts that have it, in place. Keys keep their positions.
+func StripKeyPrefix(v Value, prefix string, opts PrefixOptions) error {
+	return renameKeysAt(v, opts, func(k string) string {
+		return strings.TrimPrefix(k, prefix)
+	})
+}
+
+func renameKeysAt(v Value, opts PrefixOptions, rename func(string) string) error {
+	tokens, err := parsePointer(opts.Path)
+	if err != nil {
+		return err
+	}
+	target, ok := resolvePointer(v.V, tokens)
+	if !ok {
+		return fmt.Errorf("no value at %q", opts.Path)
+	}
+	o, ok := target.(*Object)
+	if !ok || o == nil {
+		return ErrNotObject
+	}
+	if opts.Recursive {
+		return renameKeysRecursive(o, rename)
+	}
+	return o.renameKeys(rename)
+}
+
+// renameKeysRecursive renames the keys of every object in v. The new keys of
+// all of them are worked out before any are renamed, so that if two keys of
+// any object would collide, v is left unchanged.
+func renameKeysRecursive(v interface{}, rename func(string) string) error {
+	var renames []objectRename
+	if err := collectRenames(v, rename, make(map[*Object]struct{}), &renames); err != nil {
+		return err
+	}
+	for _, r := range renames {
+		r.obj.setEntries(r.entries)
+	}
+	return nil
+}
+
+// objectRename is an Object along with its entries after renaming.
+type objectRename struct {
+	obj     *Object
+	entries []Entry
+}
+
+// collectRenames appends the renamed entries of each object in v to
+// renames. seen holds the objects already visited, so that an object that
+// appears more than once is only renamed once.
+func collectRenames(v interface{}, rename func(string) string, seen map[*Object]struct{}, renames *[]objectRename) error {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return nil
+		}
+		if _, ok := seen[v]; ok {
+			return nil
+		}
+		seen[v] = struct{}{}
+		entries, err := v.renamedEntries(rename)
+		if err != nil {
+			return err
+		}
+		*renames = append(*renames, objectRename{obj: v, entries: entries})
+		for _, kv := range v.entries {
+			if err := collectRenames(kv.Value, rename, seen, renames); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := collectRenames(e, rename, seen, renames); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renameKeys replaces each key k of o with rename(k), keeping its position.
+// If two keys would end up with the same name, o is left unchanged.
+func (o *Object) renameKeys(rename func(string) string) error {
+	entries, err := o.renamedEntries(rename)
+	if err != nil {
+		return err
+	}
+	o.setEntries(entries)
+	return nil
+}
+
+// renamedEntries returns the entries of o with each key k replaced by
+// rename(k), in the same order, without modifying o. It fails if two keys
+// would end up with the same name.
+func (o *Object) renamedEntries(rename func(string) string) ([]Entry, error) {
+	entries := make([]Entry, 0, len(o.entries))
+	seen := make(map[string]struct{}, len(o.entries))
+	for _, e := range o.entries {
+		nk := rename(e.Key)
+		if _, ok := seen[nk]; ok {
+			return nil, fmt.Errorf("renaming key %q would duplicate key %q", e.Key, nk)
+		}
+		seen[nk] = struct{}{}
+		entries = append(entries, Entry{Key: nk, Value: e.Value})
+	}
+	return entries, nil
+}
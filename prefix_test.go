@@ -0,0 +1,86 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPrefix(tt *testing.T) {
+	const in = `{"b":1,"a":{"c":[{"d":2}]},"e":{"f":3}}`
+	for _, test := range []struct {
+		name string
+		fn   func(v Value) error
+		out  string
+	}{
+		{
+			name: "add",
+			fn:   func(v Value) error { return AddKeyPrefix(v, "legacy_", PrefixOptions{}) },
+			out:  `{"legacy_b":1,"legacy_a":{"c":[{"d":2}]},"legacy_e":{"f":3}}`,
+		},
+		{
+			name: "add recursive",
+			fn:   func(v Value) error { return AddKeyPrefix(v, "x_", PrefixOptions{Recursive: true}) },
+			out:  `{"x_b":1,"x_a":{"x_c":[{"x_d":2}]},"x_e":{"x_f":3}}`,
+		},
+		{
+			name: "add at path",
+			fn:   func(v Value) error { return AddKeyPrefix(v, "x_", PrefixOptions{Path: "/a", Recursive: true}) },
+			out:  `{"b":1,"a":{"x_c":[{"x_d":2}]},"e":{"f":3}}`,
+		},
+		{
+			name: "add then strip",
+			fn: func(v Value) error {
+				if err := AddKeyPrefix(v, "x_", PrefixOptions{Recursive: true}); err != nil {
+					return err
+				}
+				return StripKeyPrefix(v, "x_", PrefixOptions{Recursive: true})
+			},
+			out: in,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v := MustNewValueFromJSON(in)
+			require.NoError(test.fn(v))
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("collision", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"x_a":1,"a":2}`)
+		require.Error(StripKeyPrefix(v, "x_", PrefixOptions{}))
+		require.Equal([]string{"x_a", "a"}, v.V.(*Object).KeyOrder())
+	})
+
+	tt.Run("recursive collision", func(t *testing.T) {
+		require := require.New(t)
+		const in = `{"x_a":{"x_b":1,"c":[{"x_d":2}]},"list":[{"x_e":1,"e":2}]}`
+		v := MustNewValueFromJSON(in)
+		require.Error(StripKeyPrefix(v, "x_", PrefixOptions{Recursive: true}))
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(in, string(b))
+	})
+
+	tt.Run("shared object", func(t *testing.T) {
+		require := require.New(t)
+		shared := NewObject().SetAndReturn("a", 1.0)
+		v := Value{V: NewObject().SetAndReturn("b", shared).SetAndReturn("c", []interface{}{shared})}
+		require.NoError(AddKeyPrefix(v, "x_", PrefixOptions{Recursive: true}))
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"x_b":{"x_a":1},"x_c":[{"x_a":1}]}`, string(b))
+	})
+
+	tt.Run("not an object", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"a":[]}`)
+		require.Error(AddKeyPrefix(v, "x_", PrefixOptions{Path: "/a"}))
+		require.Error(AddKeyPrefix(v, "x_", PrefixOptions{Path: "/missing"}))
+	})
+}
@@ -0,0 +1,138 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+)
+
+// PreviewLimits configures MarshalPreview. Zero fields mean no limit.
+type PreviewLimits struct {
+	// MaxStringLen is the number of characters after which strings are cut
+	// off.
+	MaxStringLen int
+	// MaxArrayLen is the number of elements after which arrays are cut off.
+	MaxArrayLen int
+	// MaxBytes is the output size after which no more object members or
+	// array elements are written. Since the output is kept valid, it can
+	// exceed MaxBytes by the size of one member or element and the
+	// annotations and closing delimiters that follow.
+	MaxBytes int
+}
+
+// MarshalPreview encodes v as JSON for logging, cutting it down to size
+// according to limits. The output is always valid JSON, with every cut
+// annotated in place: strings end with "… (+N chars)", arrays end with a
+// "… (+N items)" element, and objects end with a "…": "+N keys" member.
+func MarshalPreview(v Value, limits PreviewLimits) ([]byte, error) {
+	p := previewer{
+		limits:   limits,
+		visiting: make(map[*Object]struct{}),
+	}
+	if err := p.write(v.V); err != nil {
+		return nil, err
+	}
+	return p.Bytes(), nil
+}
+
+type previewer struct {
+	bytes.Buffer
+	limits   PreviewLimits
+	visiting map[*Object]struct{}
+}
+
+// full reports whether the output has reached MaxBytes.
+func (p *previewer) full() bool {
+	return p.limits.MaxBytes > 0 && p.Len() >= p.limits.MaxBytes
+}
+
+func (p *previewer) write(v interface{}) error {
+	switch v := v.(type) {
+	case *Object:
+		if v != nil {
+			return p.writeObject(v)
+		}
+	case Object:
+		return p.writeObject(&v)
+	case []interface{}:
+		if v != nil {
+			return p.writeArray(v)
+		}
+	case string:
+		if n := utf8.RuneCountInString(v); p.limits.MaxStringLen > 0 && n > p.limits.MaxStringLen {
+			i := 0
+			for j := range v {
+				if i == p.limits.MaxStringLen {
+					v = v[:j]
+					break
+				}
+				i++
+			}
+			v += "… (+" + strconv.Itoa(n-p.limits.MaxStringLen) + " chars)"
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		p.Write(b)
+		return nil
+	case Value:
+		return p.write(v.V)
+	}
+
+	e := newEncodeState()
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	p.Write(e.Bytes())
+	return nil
+}
+
+func (p *previewer) writeObject(o *Object) error {
+	if _, ok := p.visiting[o]; ok {
+		return ErrCycle
+	}
+	p.visiting[o] = struct{}{}
+	defer delete(p.visiting, o)
+
+	p.WriteString("{")
+	for i, kv := range o.entries {
+		if i > 0 {
+			p.WriteString(",")
+		}
+		if p.full() {
+			p.WriteString(`"…":"+` + strconv.Itoa(len(o.entries)-i) + ` keys"`)
+			break
+		}
+		b, err := json.Marshal(kv.Key)
+		if err != nil {
+			return err
+		}
+		p.Write(b)
+		p.WriteString(":")
+		if err := p.write(kv.Value); err != nil {
+			return err
+		}
+	}
+	p.WriteString("}")
+	return nil
+}
+
+func (p *previewer) writeArray(arr []interface{}) error {
+	p.WriteString("[")
+	for i, v := range arr {
+		if i > 0 {
+			p.WriteString(",")
+		}
+		if p.full() || (p.limits.MaxArrayLen > 0 && i >= p.limits.MaxArrayLen) {
+			p.WriteString(`"… (+` + strconv.Itoa(len(arr)-i) + ` items)"`)
+			break
+		}
+		if err := p.write(v); err != nil {
+			return err
+		}
+	}
+	p.WriteString("]")
+	return nil
+}
@@ -0,0 +1,48 @@
+package ojson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalPreview(tt *testing.T) {
+	v := MustNewValueFromJSON(`{"id":7,"body":"` + strings.Repeat("é", 30) + `","items":[1,2,3,4,5],"meta":{"a":"x","b":"y","c":"z"}}`)
+	for _, test := range []struct {
+		name   string
+		limits PreviewLimits
+		out    string
+	}{
+		{
+			name: "no limits",
+			out:  `{"id":7,"body":"` + strings.Repeat("é", 30) + `","items":[1,2,3,4,5],"meta":{"a":"x","b":"y","c":"z"}}`,
+		},
+		{
+			name:   "strings and arrays",
+			limits: PreviewLimits{MaxStringLen: 4, MaxArrayLen: 2},
+			out:    `{"id":7,"body":"éééé… (+26 chars)","items":[1,2,"… (+3 items)"],"meta":{"a":"x","b":"y","c":"z"}}`,
+		},
+		{
+			name:   "bytes",
+			limits: PreviewLimits{MaxStringLen: 4, MaxBytes: 51},
+			out:    `{"id":7,"body":"éééé… (+26 chars)","items":[1,"… (+4 items)"],"…":"+1 keys"}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			b, err := MarshalPreview(v, test.limits)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+			require.True(json.Valid(b))
+		})
+	}
+
+	tt.Run("cycle", func(t *testing.T) {
+		o := NewObject()
+		o.Set("self", o)
+		_, err := MarshalPreview(Value{V: o}, PreviewLimits{})
+		require.Equal(t, ErrCycle, err)
+	})
+}
@@ -0,0 +1,69 @@
+package ojson
+
+// PruneOptions selects which values Prune removes.
+type PruneOptions struct {
+	// Nulls removes null values.
+	Nulls bool
+	// EmptyObjects removes objects with no keys, including ones that become
+	// empty because all of their values were pruned.
+	EmptyObjects bool
+	// EmptyArrays removes arrays with no elements, including ones that become
+	// empty because all of their elements were pruned.
+	EmptyArrays bool
+	// ArrayElements also applies the options above to array elements. By
+	// default only object values are removed, so that array indices are
+	// unchanged.
+	ArrayElements bool
+}
+
+// Prune returns a copy of v with the values selected by opts removed at every
+// depth. The order of the remaining keys and elements is preserved. The
+// top-level value is never removed, and v itself is not modified.
+func Prune(v Value, opts PruneOptions) Value {
+	return Value{V: prune(v.V, opts)}
+}
+
+func prune(v interface{}, opts PruneOptions) interface{} {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return v
+		}
+		obj := NewObject()
+		for _, kv := range v.entries {
+			e := prune(kv.Value, opts)
+			if !opts.remove(e) {
+				obj.Set(kv.Key, e)
+			}
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		arr := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			e = prune(e, opts)
+			if !opts.ArrayElements || !opts.remove(e) {
+				arr = append(arr, e)
+			}
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// remove reports whether the already-pruned value v should be removed.
+func (opts PruneOptions) remove(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return opts.Nulls
+	case *Object:
+		return v == nil && opts.Nulls || v != nil && len(v.entries) == 0 && opts.EmptyObjects
+	case []interface{}:
+		return v == nil && opts.Nulls || v != nil && len(v) == 0 && opts.EmptyArrays
+	default:
+		return false
+	}
+}
@@ -0,0 +1,62 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(tt *testing.T) {
+	const in = `{"z":null,"y":{"a":null,"b":{}},"x":[null,[],{}],"w":[],"v":1}`
+	for _, test := range []struct {
+		name string
+		opts PruneOptions
+		out  string
+	}{
+		{
+			name: "nothing",
+			out:  in,
+		},
+		{
+			name: "nulls",
+			opts: PruneOptions{Nulls: true},
+			out:  `{"y":{"b":{}},"x":[null,[],{}],"w":[],"v":1}`,
+		},
+		{
+			name: "nulls and empty objects",
+			opts: PruneOptions{Nulls: true, EmptyObjects: true},
+			out:  `{"x":[null,[],{}],"w":[],"v":1}`,
+		},
+		{
+			name: "everything",
+			opts: PruneOptions{Nulls: true, EmptyObjects: true, EmptyArrays: true},
+			out:  `{"x":[null,[],{}],"v":1}`,
+		},
+		{
+			name: "everything including array elements",
+			opts: PruneOptions{Nulls: true, EmptyObjects: true, EmptyArrays: true, ArrayElements: true},
+			out:  `{"v":1}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v := MustNewValueFromJSON(in)
+			b, err := json.Marshal(Prune(v, test.opts))
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// The input is unchanged.
+			b, err = json.Marshal(v)
+			require.NoError(err)
+			require.Equal(in, string(b))
+		})
+	}
+
+	tt.Run("root", func(t *testing.T) {
+		require := require.New(t)
+		b, err := json.Marshal(Prune(MustNewValueFromJSON(`{"a":null}`), PruneOptions{Nulls: true, EmptyObjects: true}))
+		require.NoError(err)
+		require.Equal(`{}`, string(b))
+	})
+}
@@ -0,0 +1,234 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errRawNotFound is returned by findRaw when the path doesn't exist.
+var errRawNotFound = errors.New("path not found")
+
+// GetBytes returns the value at the given JSON Pointer in the JSON text data,
+// decoding only that value. The objects and arrays along the path are
+// scanned to find it, but nothing else is decoded, which is much faster than
+// decoding the whole text to read a single field. It returns false if the
+// path doesn't exist, or if data is malformed within those objects and
+// arrays.
+func GetBytes(data []byte, path string) (Value, bool) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return Value{}, false
+	}
+	start, end, err := findRaw(data, tokens)
+	if err != nil {
+		return Value{}, false
+	}
+	v, err := DecodeOptions{}.Unmarshal(data[start:end])
+	if err != nil {
+		return Value{}, false
+	}
+	return v, true
+}
+
+// findRaw returns the span of the value at the location identified by tokens
+// in the JSON text b.
+func findRaw(b []byte, tokens []string) (int, int, error) {
+	i := skipSpace(b, 0)
+	for _, t := range tokens {
+		if i >= len(b) {
+			return 0, 0, syntaxError(b, i, "unexpected end of input")
+		}
+		if b[i] != '{' && b[i] != '[' {
+			return 0, 0, errRawNotFound
+		}
+		e, err := rawLookup(b, i, t)
+		if err != nil {
+			return 0, 0, err
+		}
+		if e == nil {
+			return 0, 0, errRawNotFound
+		}
+		i = e.valueStart
+	}
+	end, err := scanValue(b, i)
+	if err != nil {
+		return 0, 0, err
+	}
+	return i, end, nil
+}
+
+// rawEntry is the span of an object member or array element in JSON text.
+type rawEntry struct {
+	// keyStart is the offset of the member's quoted key, or -1 for an array
+	// element.
+	keyStart   int
+	valueStart int
+	valueEnd   int
+}
+
+// rawEntries scans the object or array starting at b[i] and returns the
+// spans of its entries, along with the offset of its closing delimiter.
+func rawEntries(b []byte, i int) ([]rawEntry, int, error) {
+	var entries []rawEntry
+	closing := byte(']')
+	entry := func(i int) (int, error) {
+		e := rawEntry{keyStart: -1, valueStart: i}
+		end, err := scanValue(b, i)
+		e.valueEnd = end
+		entries = append(entries, e)
+		return end, err
+	}
+	if b[i] == '{' {
+		closing = '}'
+		entry = func(i int) (int, error) {
+			e := rawEntry{keyStart: i}
+			var err error
+			if e.valueStart, err = scanKey(b, i); err != nil {
+				return 0, err
+			}
+			if e.valueEnd, err = scanValue(b, e.valueStart); err != nil {
+				return 0, err
+			}
+			entries = append(entries, e)
+			return e.valueEnd, nil
+		}
+	}
+	end, err := scanContainer(b, i, closing, entry)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, end - 1, nil
+}
+
+// rawLookup returns the entry for the reference token t in the object or
+// array starting at b[i], or nil if there is none. If an object has the key
+// more than once, the last one is returned, as when decoding.
+func rawLookup(b []byte, i int, t string) (*rawEntry, error) {
+	entries, _, err := rawEntries(b, i)
+	if err != nil {
+		return nil, err
+	}
+	if b[i] == '[' {
+		index, ok := parseArrayIndex(t)
+		if !ok || index >= len(entries) {
+			return nil, nil
+		}
+		return &entries[index], nil
+	}
+	for j := len(entries) - 1; j >= 0; j-- {
+		e := &entries[j]
+		keyEnd, _ := scanString(b, e.keyStart)
+		if rawKeyEquals(b[e.keyStart:keyEnd], t) {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// rawKeyEquals reports whether the quoted string q decodes to k.
+func rawKeyEquals(q []byte, k string) bool {
+	if bytes.IndexByte(q, '\\') < 0 {
+		return string(q[1:len(q)-1]) == k
+	}
+	var s string
+	return json.Unmarshal(q, &s) == nil && s == k
+}
+
+// SetBytes returns a copy of the JSON text data with the value at the given
+// JSON Pointer set to v, leaving every other byte unchanged, so that
+// formatting, key order and the exact text of other values are preserved. If
+// the path doesn't exist but its parent does, v is added as a new last
+// member of an object, with the same spacing around its ':' as the first,
+// or appended to an array when the last token is "-" or the array's length,
+// separated from the entry before it in the same way as existing entries.
+func SetBytes(data []byte, path string, v interface{}) ([]byte, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := MarshalOptions{}.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		start := skipSpace(data, 0)
+		end, err := scanValue(data, start)
+		if err != nil {
+			return nil, err
+		}
+		return splice(data, start, end, value), nil
+	}
+
+	parent, _, err := findRaw(data, tokens[:len(tokens)-1])
+	if err == errRawNotFound {
+		return nil, fmt.Errorf("path %q not found", Pointer(tokens[:len(tokens)-1]))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data[parent] != '{' && data[parent] != '[' {
+		return nil, fmt.Errorf("cannot set a value inside a scalar at %q", Pointer(tokens[:len(tokens)-1]))
+	}
+	last := tokens[len(tokens)-1]
+	e, err := rawLookup(data, parent, last)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return splice(data, e.valueStart, e.valueEnd, value), nil
+	}
+
+	entries, closing, err := rawEntries(data, parent)
+	if err != nil {
+		return nil, err
+	}
+	entry := value
+	if data[parent] == '[' {
+		if index, ok := parseArrayIndex(last); last != "-" && (!ok || index != len(entries)) {
+			return nil, fmt.Errorf("array index %q out of range at %q", last, Pointer(tokens[:len(tokens)-1]))
+		}
+	} else {
+		key, err := MarshalOptions{}.Marshal(last)
+		if err != nil {
+			return nil, err
+		}
+		colon := []byte(":")
+		if len(entries) > 0 {
+			keyEnd, _ := scanString(data, entries[0].keyStart)
+			colon = data[keyEnd:entries[0].valueStart]
+		}
+		entry = append(append(key, colon...), value...)
+	}
+	if len(entries) == 0 {
+		return splice(data, closing, closing, entry), nil
+	}
+	// Separate the new entry from the last one the same way as the last two
+	// entries, or else with the whitespace before the first one.
+	var insert []byte
+	if n := len(entries); n > 1 {
+		insert = append(insert, data[entries[n-2].valueEnd:entryStart(entries[n-1])]...)
+	} else {
+		insert = append([]byte(","), data[parent+1:entryStart(entries[0])]...)
+	}
+	insert = append(insert, entry...)
+	end := entries[len(entries)-1].valueEnd
+	return splice(data, end, end, insert), nil
+}
+
+// entryStart returns the offset at which e starts.
+func entryStart(e rawEntry) int {
+	if e.keyStart >= 0 {
+		return e.keyStart
+	}
+	return e.valueStart
+}
+
+// splice returns a copy of b with b[start:end] replaced by s.
+func splice(b []byte, start, end int, s []byte) []byte {
+	out := make([]byte, 0, len(b)-(end-start)+len(s))
+	out = append(out, b[:start]...)
+	out = append(out, s...)
+	return append(out, b[end:]...)
+}
@@ -0,0 +1,188 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBytes(tt *testing.T) {
+	data := []byte(` {"type":"order", "items":[{"sku":"a","qty":1}, {"sku":"b","meta":{"z":1,"y":2}}],
+		"dup":1, "dup":2, "esc\u0061ped":true, "a/b":{"~":null}} `)
+	for _, test := range []struct {
+		path string
+		out  string
+	}{
+		{path: "/type", out: `"order"`},
+		{path: "/items/1/meta", out: `{"z":1,"y":2}`},
+		{path: "/items/0/qty", out: `1`},
+		{path: "/dup", out: `2`},
+		{path: "/escaped", out: `true`},
+		{path: "/a~1b/~0", out: `null`},
+		{path: "", out: `{"type":"order","items":[{"sku":"a","qty":1},{"sku":"b","meta":{"z":1,"y":2}}],"dup":2,"escaped":true,"a/b":{"~":null}}`},
+	} {
+		tt.Run(test.path, func(t *testing.T) {
+			require := require.New(t)
+			v, ok := GetBytes(data, test.path)
+			require.True(ok)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("not found", func(t *testing.T) {
+		for _, path := range []string{"/missing", "/items/2", "/items/x", "/type/x", "type"} {
+			_, ok := GetBytes(data, path)
+			require.False(t, ok, path)
+		}
+		for _, data := range []string{`{"a":1,}`, `{"a":[1 2]}`, `{"a"}`, ``} {
+			_, ok := GetBytes([]byte(data), "/a")
+			require.False(t, ok, data)
+		}
+	})
+}
+
+func TestRawDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 1 << 20
+	deep := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+
+	// Skipping over the nested value to reach later members must not
+	// recurse once per level.
+	data := []byte(`{"a":` + deep + `,"b":1}`)
+	v, ok := GetBytes(data, "/b")
+	require.True(ok)
+	require.Equal(1.0, v.V)
+	out, err := SetBytes(data, "/b", 2.0)
+	require.NoError(err)
+	require.Equal(`{"a":`+deep+`,"b":2}`, string(out))
+
+	_, ok = GetBytes([]byte(`{"a":`+strings.Repeat("[", depth)+`,"b":1}`), "/b")
+	require.False(ok)
+	_, err = SetBytes([]byte(strings.Repeat("[", depth)), "", nil)
+	require.Error(err)
+}
+
+func TestSetBytes(tt *testing.T) {
+	const pretty = `{
+  "name": "svc",
+  "limits": {"cpu": 1.50, "mem": "1Gi"},
+  "ports": [80, 443]
+}
+`
+	for _, test := range []struct {
+		name string
+		data string
+		path string
+		v    interface{}
+		out  string
+	}{
+		{
+			name: "replace",
+			data: pretty,
+			path: "/limits/cpu",
+			v:    2.0,
+			out: `{
+  "name": "svc",
+  "limits": {"cpu": 2, "mem": "1Gi"},
+  "ports": [80, 443]
+}
+`,
+		},
+		{
+			name: "replace object",
+			data: pretty,
+			path: "/limits",
+			v:    NewObject().SetAndReturn("z", 1.0).SetAndReturn("a", nil),
+			out: `{
+  "name": "svc",
+  "limits": {"z":1,"a":null},
+  "ports": [80, 443]
+}
+`,
+		},
+		{
+			name: "add member",
+			data: pretty,
+			path: "/replicas",
+			v:    3.0,
+			out: `{
+  "name": "svc",
+  "limits": {"cpu": 1.50, "mem": "1Gi"},
+  "ports": [80, 443],
+  "replicas": 3
+}
+`,
+		},
+		{
+			name: "add nested member",
+			data: pretty,
+			path: "/limits/a~1b",
+			v:    "x",
+			out: `{
+  "name": "svc",
+  "limits": {"cpu": 1.50, "mem": "1Gi", "a/b": "x"},
+  "ports": [80, 443]
+}
+`,
+		},
+		{
+			name: "append",
+			data: pretty,
+			path: "/ports/-",
+			v:    8080.0,
+			out: `{
+  "name": "svc",
+  "limits": {"cpu": 1.50, "mem": "1Gi"},
+  "ports": [80, 443, 8080]
+}
+`,
+		},
+		{
+			name: "empty containers",
+			data: `{"a":{},"b":[ ]}`,
+			path: "/a/k",
+			v:    true,
+			out:  `{"a":{"k":true},"b":[ ]}`,
+		},
+		{
+			name: "empty array",
+			data: `{"a":{},"b":[ ]}`,
+			path: "/b/0",
+			v:    true,
+			out:  `{"a":{},"b":[ true]}`,
+		},
+		{
+			name: "root",
+			data: " [1] \n",
+			path: "",
+			v:    "x",
+			out:  " \"x\" \n",
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			out, err := SetBytes([]byte(test.data), test.path, test.v)
+			require.NoError(err)
+			require.Equal(test.out, string(out))
+		})
+	}
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		_, err := SetBytes([]byte(pretty), "/missing/x", 1)
+		require.EqualError(err, `path "/missing" not found`)
+		_, err = SetBytes([]byte(pretty), "/name/x", 1)
+		require.EqualError(err, `cannot set a value inside a scalar at "/name"`)
+		_, err = SetBytes([]byte(pretty), "/ports/3", 1)
+		require.EqualError(err, `array index "3" out of range at "/ports"`)
+		_, err = SetBytes([]byte(`{"a":`), "/a", 1)
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr), "%v", err)
+		require.Equal(Position{Offset: 5, Line: 1, Column: 6}, syntaxErr.Position)
+	})
+}
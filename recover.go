@@ -0,0 +1,446 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError describes malformed JSON input and where it was found.
+type SyntaxError struct {
+	Msg string
+	Position
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d", e.Msg, e.Line, e.Column)
+}
+
+// UnmarshalRecover decodes as much of b as it can. When it finds a syntax
+// error, it records it and skips ahead to the next object member or array
+// element that it can parse, dropping the malformed one. It returns the
+// partial Value along with the errors, in input order; the Value is complete
+// if there are none.
+func UnmarshalRecover(b []byte) (Value, []*SyntaxError) {
+	p := &lenientParser{data: b}
+	v := p.parse()
+	return Value{V: v}, p.errs
+}
+
+// lenientParser is a byte-level parser that keeps going after errors.
+type lenientParser struct {
+	data []byte
+	i    int
+	errs []*SyntaxError
+
+	// repair fixes common malformations instead of treating them as errors,
+	// and records them in fixes.
+	repair bool
+	fixes  []Fix
+	// comma is the offset of the last ',' consumed by separator.
+	comma int
+
+	// truncate treats the end of the input as the input having been cut
+	// off, rather than as an error, and records it in truncated.
+	truncate  bool
+	truncated bool
+
+	// lines holds the offset of the start of each line, once position has
+	// been called.
+	lines []int
+}
+
+// lenientFrame is an object or array that the lenientParser is inside.
+type lenientFrame struct {
+	obj     *Object
+	arr     []interface{}
+	closing byte
+	// started is whether the first member or element has been looked for.
+	started bool
+	// key is the key of the object member whose value is being parsed.
+	key string
+}
+
+func (f *lenientFrame) value() interface{} {
+	if f.obj != nil {
+		return f.obj
+	}
+	return f.arr
+}
+
+func (p *lenientParser) parse() interface{} {
+	p.skipSpace()
+	if p.i >= len(p.data) {
+		p.unexpectedEOF()
+		return nil
+	}
+	v, _ := p.value()
+	if p.skipSpace(); p.i < len(p.data) {
+		p.errorf("invalid character %q after top-level value", p.data[p.i])
+	}
+	return v
+}
+
+func (p *lenientParser) errorf(format string, args ...interface{}) {
+	p.errs = append(p.errs, &SyntaxError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: p.position(p.i),
+	})
+}
+
+// position returns the Position of offset off. Unlike positionOf, it doesn't
+// rescan the input on every call, since an error or fix may be recorded for
+// every open object and array.
+func (p *lenientParser) position(off int) Position {
+	if p.lines == nil {
+		p.lines = []int{0}
+		for i, c := range p.data {
+			if c == '\n' {
+				p.lines = append(p.lines, i+1)
+			}
+		}
+	}
+	line := sort.SearchInts(p.lines, off+1) - 1
+	return Position{Offset: off, Line: line + 1, Column: off - p.lines[line] + 1}
+}
+
+// unexpectedEOF handles the input ending where more was expected.
+func (p *lenientParser) unexpectedEOF() {
+	if p.truncate {
+		p.truncated = true
+		return
+	}
+	p.errorf("unexpected end of input")
+}
+
+func (p *lenientParser) fix(msg string) {
+	p.fixAt(p.i, msg)
+}
+
+func (p *lenientParser) fixAt(off int, msg string) {
+	p.fixes = append(p.fixes, Fix{Msg: msg, Position: p.position(off)})
+}
+
+func (p *lenientParser) skipSpace() {
+	p.i = skipSpace(p.data, p.i)
+}
+
+// value parses the value at p.i. It returns false, having recorded an error,
+// if there is no valid value there. Objects and arrays are always valid, as
+// malformed members and elements are dropped from them.
+//
+// Nested objects and arrays are tracked on an explicit stack rather than by
+// recursion, so that deeply nested input can't overflow the goroutine stack.
+func (p *lenientParser) value() (interface{}, bool) {
+	var stack []*lenientFrame
+	for {
+		if p.i < len(p.data) && (p.data[p.i] == '{' || p.data[p.i] == '[') {
+			f := &lenientFrame{closing: closingDelim(p.data[p.i])}
+			if f.closing == '}' {
+				f.obj = NewObject()
+			} else {
+				f.arr = make([]interface{}, 0)
+			}
+			p.i++
+			stack = append(stack, f)
+		} else {
+			v, ok := p.scalar()
+			if len(stack) == 0 {
+				return v, ok
+			}
+			p.add(stack[len(stack)-1], v, ok)
+		}
+
+		// Move on to the next member or element, closing the objects and
+		// arrays that end first.
+		for {
+			f := stack[len(stack)-1]
+			if f.started && !p.separator(f.closing) || !p.next(f.closing, !f.started) {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return f.value(), true
+				}
+				p.add(stack[len(stack)-1], f.value(), true)
+				continue
+			}
+			f.started = true
+			if f.obj == nil {
+				break
+			}
+			k, ok := p.key()
+			if ok {
+				f.key = k
+				break
+			}
+			p.skipToSibling()
+		}
+	}
+}
+
+// add adds a member or element to f, or skips past it if it wasn't valid.
+func (p *lenientParser) add(f *lenientFrame, v interface{}, ok bool) {
+	switch {
+	case !ok:
+		p.skipToSibling()
+	case f.obj != nil:
+		f.obj.Set(f.key, v)
+	default:
+		f.arr = append(f.arr, v)
+	}
+}
+
+// scalar parses the string, number or literal at p.i.
+func (p *lenientParser) scalar() (interface{}, bool) {
+	if p.i >= len(p.data) {
+		p.unexpectedEOF()
+		return nil, false
+	}
+	switch c := p.data[p.i]; {
+	case c == '"' || c == '\'' && p.repair:
+		return p.string()
+	case c == '-' || isDigit(c):
+		end, err := scanNumber(p.data, p.i)
+		if err != nil && p.truncate && isNumberPrefix(p.data[p.i:]) {
+			// The number was cut off before it became valid.
+			p.i = len(p.data)
+			p.truncated = true
+			return nil, false
+		}
+		if err != nil {
+			p.errorf("invalid number")
+			return nil, false
+		}
+		f, err := strconv.ParseFloat(string(p.data[p.i:end]), 64)
+		if err != nil {
+			p.errorf("invalid number")
+			return nil, false
+		}
+		p.i = end
+		return f, true
+	case c == 't':
+		return p.literal("true", true)
+	case c == 'f':
+		return p.literal("false", false)
+	case c == 'n':
+		return p.literal("null", nil)
+	default:
+		p.errorf("invalid character %q", c)
+		return nil, false
+	}
+}
+
+// string parses the string at p.i. When repairing, it also accepts strings
+// in single quotes, and raw control characters such as newlines.
+func (p *lenientParser) string() (interface{}, bool) {
+	start := p.i
+	quote := p.data[p.i]
+	if quote == '\'' {
+		p.fix("replaced single quotes with double quotes")
+	}
+	// Build the string's JSON encoding, and let encoding/json decode it.
+	buf := []byte{'"'}
+	fixed := false
+	i := p.i + 1
+	for {
+		if i >= len(p.data) && p.truncate {
+			// Keep what there is of the string, without any incomplete
+			// escape sequence.
+			if j := bytes.LastIndex(buf, []byte(`\u`)); j >= 0 && j >= len(buf)-5 && (j == 0 || buf[j-1] != '\\') {
+				buf = buf[:j]
+			}
+			p.i = len(p.data)
+			p.truncated = true
+			var s string
+			if err := json.Unmarshal(append(buf, '"'), &s); err != nil {
+				return nil, false
+			}
+			return s, true
+		}
+		if i >= len(p.data) {
+			p.errorf("unterminated string")
+			return nil, false
+		}
+		c := p.data[i]
+		switch {
+		case c == quote:
+			buf = append(buf, '"')
+			var s string
+			if err := json.Unmarshal(buf, &s); err != nil {
+				p.errorf("invalid escape sequence in string")
+				return nil, false
+			}
+			p.i = i + 1
+			return s, true
+		case c == '\\':
+			if i+1 < len(p.data) && p.data[i+1] == '\'' && quote == '\'' {
+				buf = append(buf, '\'')
+			} else if i+1 < len(p.data) {
+				buf = append(buf, c, p.data[i+1])
+			}
+			i += 2
+			continue
+		case c == '"':
+			buf = append(buf, '\\', '"')
+		case c < 0x20:
+			if !p.repair {
+				p.i = i
+				p.errorf("invalid control character in string")
+				p.i = start
+				return nil, false
+			}
+			if !fixed {
+				fixed = true
+				p.i = i
+				p.fix("escaped control character in string")
+				p.i = start
+			}
+			b, _ := json.Marshal(string(c))
+			buf = append(buf, b[1:len(b)-1]...)
+		default:
+			buf = append(buf, c)
+		}
+		i++
+	}
+}
+
+func (p *lenientParser) literal(lit string, v interface{}) (interface{}, bool) {
+	end, err := scanLiteral(p.data, p.i, lit)
+	if err != nil && p.truncate && strings.HasPrefix(lit, string(p.data[p.i:])) {
+		p.i = len(p.data)
+		p.truncated = true
+		return nil, false
+	}
+	if err != nil {
+		p.errorf("invalid literal")
+		return nil, false
+	}
+	p.i = end
+	return v, true
+}
+
+// key parses an object member's key at p.i, along with the ':' after it.
+func (p *lenientParser) key() (string, bool) {
+	if c := p.data[p.i]; c != '"' && !(c == '\'' && p.repair) {
+		p.errorf("expected string key in object")
+		return "", false
+	}
+	k, ok := p.string()
+	if !ok {
+		return "", false
+	}
+	if p.skipSpace(); p.i >= len(p.data) {
+		p.unexpectedEOF()
+		return "", false
+	}
+	if p.data[p.i] != ':' {
+		p.errorf("expected : after object key")
+		return "", false
+	}
+	p.i++
+	p.skipSpace()
+	return k.(string), true
+}
+
+// next advances to the next object member or array element. It returns false
+// if the container ended instead, consuming its closing delimiter. first is
+// whether this is the first member or element, as opposed to following a
+// ','.
+func (p *lenientParser) next(closing byte, first bool) bool {
+	p.skipSpace()
+	if p.i >= len(p.data) {
+		if p.repair {
+			if !first {
+				p.fixAt(p.comma, "removed trailing comma")
+			}
+			p.fix(fmt.Sprintf("added missing %c", closing))
+		} else {
+			p.unexpectedEOF()
+		}
+		return false
+	}
+	if p.data[p.i] != closing {
+		return true
+	}
+	if !first {
+		if p.repair {
+			p.fixAt(p.comma, "removed trailing comma")
+		} else {
+			p.errorf("unexpected %c after ,", closing)
+		}
+	}
+	p.i++
+	return false
+}
+
+// separator consumes the ',' after an object member or array element, or the
+// closing delimiter of the container. It returns false if the container
+// ended.
+func (p *lenientParser) separator(closing byte) bool {
+	for {
+		p.skipSpace()
+		if p.i >= len(p.data) {
+			if p.repair {
+				p.fix(fmt.Sprintf("added missing %c", closing))
+			} else {
+				p.unexpectedEOF()
+			}
+			return false
+		}
+		switch p.data[p.i] {
+		case ',':
+			p.comma = p.i
+			p.i++
+			return true
+		case closing:
+			p.i++
+			return false
+		case '}', ']':
+			// Leave a mismatched delimiter to close an enclosing container.
+			p.errorf("expected , or %c", closing)
+			return false
+		}
+		p.errorf("expected , or %c", closing)
+		p.skipToSibling()
+	}
+}
+
+// isNumberPrefix reports whether b, which runs to the end of the input, could
+// be the start of a number.
+func isNumberPrefix(b []byte) bool {
+	for _, c := range b {
+		if !isDigit(c) && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			return false
+		}
+	}
+	return true
+}
+
+// skipToSibling advances to the next ',' or closing delimiter that is not
+// nested inside another value or a string, or to the end of the input. It
+// always makes progress unless it is already at one.
+func (p *lenientParser) skipToSibling() {
+	depth := 0
+	for ; p.i < len(p.data); p.i++ {
+		switch p.data[p.i] {
+		case '"':
+			// Skip over a malformed string's quote alone.
+			if end, err := scanString(p.data, p.i); err == nil {
+				p.i = end - 1
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
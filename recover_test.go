@@ -0,0 +1,142 @@
+package ojson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRecover(tt *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		out  string
+		errs []string
+	}{
+		{
+			name: "valid",
+			in:   `{"b":[1,{"c":null}],"a":"x"}`,
+			out:  `{"b":[1,{"c":null}],"a":"x"}`,
+		},
+		{
+			name: "bad member value",
+			in:   `{"a":1,"b":tru,"c":{"d":[1,2]},"e":3}`,
+			out:  `{"a":1,"c":{"d":[1,2]},"e":3}`,
+			errs: []string{"invalid literal at line 1, column 12"},
+		},
+		{
+			name: "bad nested value is skipped as a whole",
+			in:   `{"a":{"x":[1,@]},"b":2}`,
+			out:  `{"a":{"x":[1]},"b":2}`,
+			errs: []string{"invalid character '@' at line 1, column 14"},
+		},
+		{
+			name: "bad elements",
+			in:   "[1,\n  -,\n  \"ok\",\n  01]",
+			out:  `[1,"ok",0]`,
+			errs: []string{
+				"invalid number at line 2, column 3",
+				"expected , or ] at line 4, column 4",
+			},
+		},
+		{
+			name: "missing colon and key",
+			in:   `{"a" 1,2:3,"b":{"c":"d"}}`,
+			out:  `{"b":{"c":"d"}}`,
+			errs: []string{
+				"expected : after object key at line 1, column 6",
+				"expected string key in object at line 1, column 8",
+			},
+		},
+		{
+			name: "mismatched delimiter",
+			in:   `{"a":[1,2},"b":3}`,
+			out:  `{"a":[1,2]}`,
+			errs: []string{
+				"expected , or ] at line 1, column 10",
+				"invalid character ',' after top-level value at line 1, column 11",
+			},
+		},
+		{
+			name: "unterminated",
+			in:   `{"a":[1,{"b":`,
+			out:  `{"a":[1,{}]}`,
+			errs: []string{
+				"unexpected end of input at line 1, column 14",
+				"unexpected end of input at line 1, column 14",
+				"unexpected end of input at line 1, column 14",
+				"unexpected end of input at line 1, column 14",
+			},
+		},
+		{
+			name: "trailing comma",
+			in:   `{"a":[1,],"b":2,}`,
+			out:  `{"a":[1],"b":2}`,
+			errs: []string{
+				"unexpected ] after , at line 1, column 9",
+				"unexpected } after , at line 1, column 17",
+			},
+		},
+		{
+			name: "control character in string",
+			in:   "[\"a\nb\",2]",
+			out:  `[2]`,
+			errs: []string{"invalid control character in string at line 1, column 4"},
+		},
+		{
+			name: "trailing data",
+			in:   `[1] x`,
+			out:  `[1]`,
+			errs: []string{"invalid character 'x' after top-level value at line 1, column 5"},
+		},
+		{
+			name: "empty",
+			in:   ` `,
+			out:  `null`,
+			errs: []string{"unexpected end of input at line 1, column 2"},
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v, errs := UnmarshalRecover([]byte(test.in))
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			var msgs []string
+			for _, err := range errs {
+				msgs = append(msgs, err.Error())
+			}
+			require.Equal(test.errs, msgs)
+		})
+	}
+}
+
+func TestUnmarshalRecoverDeepNesting(tt *testing.T) {
+	const depth = 1 << 20
+
+	tt.Run("balanced", func(t *testing.T) {
+		require := require.New(t)
+		v, errs := UnmarshalRecover([]byte(strings.Repeat(`{"a":[`, depth) + strings.Repeat("]}", depth)))
+		require.Empty(errs)
+		n := 1
+		for obj := v.V.(*Object); ; n++ {
+			a, _ := obj.Get("a")
+			arr := a.([]interface{})
+			if len(arr) == 0 {
+				break
+			}
+			obj = arr[0].(*Object)
+		}
+		require.Equal(depth, n)
+	})
+
+	tt.Run("unterminated", func(t *testing.T) {
+		require := require.New(t)
+		_, errs := UnmarshalRecover([]byte(strings.Repeat("[", depth)))
+		require.Len(errs, depth)
+		require.Equal("unexpected end of input at line 1, column 1048577", errs[0].Error())
+	})
+}
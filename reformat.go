@@ -0,0 +1,161 @@
+package ojson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Reformat copies the JSON values read from src to dst, re-indented
+// according to f. It works one token at a time, so it never holds more than
+// a single token of the input in memory, and it preserves key order and
+// number literals exactly. Consecutive top-level values are separated by
+// newlines.
+func Reformat(dst io.Writer, src io.Reader, f Format) error {
+	dec := json.NewDecoder(src)
+	dec.UseNumber()
+	r := &reformatter{
+		w:      bufio.NewWriter(dst),
+		indent: f.Indent,
+		eol:    "\n",
+	}
+	if f.CRLF {
+		r.eol = "\r\n"
+	}
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.writeToken(t); err != nil {
+			return err
+		}
+	}
+	if r.values > 0 && f.TrailingNewline {
+		r.w.WriteString(r.eol)
+	}
+	return r.w.Flush()
+}
+
+type reformatter struct {
+	w      *bufio.Writer
+	indent string
+	// eol is the line ending.
+	eol   string
+	stack []reformatFrame
+	// values is the number of top-level values written.
+	values int
+	str    bytes.Buffer
+}
+
+// reformatFrame tracks an open object or array.
+type reformatFrame struct {
+	object bool
+	// n is the number of entries written so far.
+	n int
+	// key is whether an object key has been written whose value hasn't.
+	key bool
+}
+
+func (r *reformatter) writeToken(t json.Token) error {
+	if d, ok := t.(json.Delim); ok && (d == '}' || d == ']') {
+		f := r.stack[len(r.stack)-1]
+		r.stack = r.stack[:len(r.stack)-1]
+		if f.n > 0 {
+			r.newline()
+		}
+		r.w.WriteByte(byte(d))
+		r.endValue()
+		return nil
+	}
+
+	if s, ok := t.(string); ok && len(r.stack) > 0 {
+		if top := &r.stack[len(r.stack)-1]; top.object && !top.key {
+			r.separate(top)
+			if err := r.writeString(s); err != nil {
+				return err
+			}
+			r.w.WriteByte(':')
+			if r.indent != "" {
+				r.w.WriteByte(' ')
+			}
+			top.key = true
+			return nil
+		}
+	}
+
+	if len(r.stack) == 0 {
+		if r.values > 0 {
+			r.w.WriteString(r.eol)
+		}
+	} else if top := &r.stack[len(r.stack)-1]; !top.object {
+		r.separate(top)
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		r.w.WriteByte(byte(t))
+		r.stack = append(r.stack, reformatFrame{object: t == '{'})
+		return nil
+	case string:
+		if err := r.writeString(t); err != nil {
+			return err
+		}
+	case json.Number:
+		r.w.WriteString(t.String())
+	case bool:
+		if t {
+			r.w.WriteString("true")
+		} else {
+			r.w.WriteString("false")
+		}
+	case nil:
+		r.w.WriteString("null")
+	}
+	r.endValue()
+	return nil
+}
+
+// separate writes the separator before the next entry of f.
+func (r *reformatter) separate(f *reformatFrame) {
+	if f.n > 0 {
+		r.w.WriteByte(',')
+	}
+	r.newline()
+}
+
+func (r *reformatter) newline() {
+	if r.indent == "" {
+		return
+	}
+	r.w.WriteString(r.eol)
+	r.w.WriteString(strings.Repeat(r.indent, len(r.stack)))
+}
+
+// endValue records that a complete value has been written.
+func (r *reformatter) endValue() {
+	if len(r.stack) == 0 {
+		r.values++
+		return
+	}
+	top := &r.stack[len(r.stack)-1]
+	top.n++
+	top.key = false
+}
+
+func (r *reformatter) writeString(s string) error {
+	r.str.Reset()
+	enc := json.NewEncoder(&r.str)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	// Encode terminates each value with a newline.
+	r.w.Write(bytes.TrimSuffix(r.str.Bytes(), []byte("\n")))
+	return nil
+}
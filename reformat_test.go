@@ -0,0 +1,74 @@
+package ojson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReformat(tt *testing.T) {
+	const in = `{"b": [1, 2.50, {}], "a": {"<c>": null, "d": []}, "e": true}`
+	for _, test := range []struct {
+		name   string
+		in     string
+		format Format
+		out    string
+	}{
+		{
+			name:   "compact",
+			in:     in,
+			format: Format{},
+			out:    `{"b":[1,2.50,{}],"a":{"<c>":null,"d":[]},"e":true}`,
+		},
+		{
+			name:   "indented",
+			in:     in,
+			format: Format{Indent: "  ", TrailingNewline: true},
+			out: `{
+  "b": [
+    1,
+    2.50,
+    {}
+  ],
+  "a": {
+    "<c>": null,
+    "d": []
+  },
+  "e": true
+}
+`,
+		},
+		{
+			name:   "multiple values",
+			in:     "1 [\"x\"]\n{\"a\":\n1}",
+			format: Format{TrailingNewline: true},
+			out:    "1\n[\"x\"]\n{\"a\":1}\n",
+		},
+		{
+			name:   "crlf",
+			in:     "1 [\"x\"]",
+			format: Format{Indent: "\t", TrailingNewline: true, CRLF: true},
+			out:    "1\r\n[\r\n\t\"x\"\r\n]\r\n",
+		},
+		{
+			name:   "empty",
+			in:     " ",
+			format: Format{TrailingNewline: true},
+			out:    "",
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			var b bytes.Buffer
+			require.NoError(Reformat(&b, strings.NewReader(test.in), test.format))
+			require.Equal(test.out, b.String())
+		})
+	}
+
+	tt.Run("invalid", func(t *testing.T) {
+		var b bytes.Buffer
+		require.Error(t, Reformat(&b, strings.NewReader(`{"a":1,}`), Format{}))
+	})
+}
@@ -0,0 +1,223 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrRefCycle is returned when resolving a "$ref" requires resolving itself.
+var ErrRefCycle = errors.New("$ref refers to itself")
+
+// RefOptions configures ResolveRefs.
+type RefOptions struct {
+	// Lazy replaces each reference with a *Ref, which is resolved on demand,
+	// instead of a copy of its target. Unlike inlining, this allows a
+	// reference to occur within its own target, as in recursive schemas.
+	Lazy bool
+}
+
+// Ref is a reference left in place by ResolveRefs when RefOptions.Lazy is
+// set. It marshals back to the reference object it replaced.
+type Ref struct {
+	// Ref is the value of the "$ref" member, such as "#/definitions/user".
+	Ref string
+
+	root interface{}
+}
+
+var _ json.Marshaler = &Ref{}
+
+// Resolve returns the value that r refers to. If that value is itself a
+// reference, it is followed in turn.
+func (r *Ref) Resolve() (interface{}, error) {
+	seen := make(map[*Ref]bool)
+	for {
+		if seen[r] {
+			return nil, fmt.Errorf("%w: %q", ErrRefCycle, r.Ref)
+		}
+		seen[r] = true
+		v, err := lookupRef(r.root, r.Ref)
+		if err != nil {
+			return nil, err
+		}
+		next, ok := v.(*Ref)
+		if !ok {
+			return v, nil
+		}
+		r = next
+	}
+}
+
+func (r *Ref) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"$ref": r.Ref})
+}
+
+// ResolveRefs expands the internal JSON References in v: objects with a
+// "$ref" member whose value is a URI fragment holding a JSON Pointer, such as
+// {"$ref": "#/definitions/user"}. By default each reference is replaced with
+// its target, with any references within that resolved in turn, and
+// ErrRefCycle is returned if a target contains a reference to itself.
+// Each target is expanded once and shared by every reference to it, so that
+// documents with chains of references resolve in linear time; the result
+// may therefore hold the same Object or array in several places, and
+// modifying one occurrence modifies them all. Other members of reference
+// objects are ignored, and pointers are resolved against the document as
+// written. v itself is not modified. References to other documents are not
+// supported and are reported as errors.
+func ResolveRefs(v Value, opts RefOptions) (Value, error) {
+	if opts.Lazy {
+		var refs []*Ref
+		root := lazyRefs(v.V, &refs)
+		for _, r := range refs {
+			r.root = root
+		}
+		for _, r := range refs {
+			if _, err := r.Resolve(); err != nil {
+				return Value{}, err
+			}
+		}
+		return Value{V: root}, nil
+	}
+	r := &refResolver{
+		root:      v.V,
+		resolving: make(map[string]bool),
+		resolved:  make(map[string]interface{}),
+	}
+	out, err := r.expand(v.V)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{V: out}, nil
+}
+
+// refString returns the value of v's "$ref" member, if v is a reference.
+func refString(v interface{}) (string, bool) {
+	obj, ok := v.(*Object)
+	if !ok || obj == nil {
+		return "", false
+	}
+	rv, _ := obj.Get("$ref")
+	ref, ok := rv.(string)
+	return ref, ok
+}
+
+// lookupRef returns the value within root that ref refers to.
+func lookupRef(root interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only references within the document are supported", ref)
+	}
+	p, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	tokens, err := parsePointer(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	v, ok := resolvePointer(root, tokens)
+	if !ok {
+		return nil, fmt.Errorf("$ref %q not found", ref)
+	}
+	return v, nil
+}
+
+// refResolver inlines references.
+type refResolver struct {
+	root interface{}
+	// resolving holds the references whose targets are being expanded.
+	resolving map[string]bool
+	// resolved holds expanded targets, by reference. They are shared by all
+	// the references to them, rather than copied for each.
+	resolved map[string]interface{}
+}
+
+func (r *refResolver) expand(v interface{}) (interface{}, error) {
+	if ref, ok := refString(v); ok {
+		return r.inline(ref)
+	}
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return v, nil
+		}
+		obj := NewObjectWithCapacity(len(v.entries))
+		for _, kv := range v.entries {
+			e, err := r.expand(kv.Value)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(kv.Key, e)
+		}
+		return obj, nil
+	case []interface{}:
+		if v == nil {
+			return v, nil
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			var err error
+			if arr[i], err = r.expand(e); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	default:
+		return v, nil
+	}
+}
+
+func (r *refResolver) inline(ref string) (interface{}, error) {
+	if v, ok := r.resolved[ref]; ok {
+		return v, nil
+	}
+	if r.resolving[ref] {
+		return nil, fmt.Errorf("%w: %q", ErrRefCycle, ref)
+	}
+	target, err := lookupRef(r.root, ref)
+	if err != nil {
+		return nil, err
+	}
+	r.resolving[ref] = true
+	v, err := r.expand(target)
+	delete(r.resolving, ref)
+	if err != nil {
+		return nil, err
+	}
+	r.resolved[ref] = v
+	return v, nil
+}
+
+// lazyRefs returns a copy of v with references replaced by *Refs, which it
+// appends to refs.
+func lazyRefs(v interface{}, refs *[]*Ref) interface{} {
+	if ref, ok := refString(v); ok {
+		r := &Ref{Ref: ref}
+		*refs = append(*refs, r)
+		return r
+	}
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return v
+		}
+		obj := NewObjectWithCapacity(len(v.entries))
+		for _, kv := range v.entries {
+			obj.Set(kv.Key, lazyRefs(kv.Value, refs))
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = lazyRefs(e, refs)
+		}
+		return arr
+	default:
+		return v
+	}
+}
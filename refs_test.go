@@ -0,0 +1,124 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRefs(tt *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{
+		{
+			name: "inline",
+			in:   `{"defs":{"id":{"type":"string"}},"props":{"a":{"$ref":"#/defs/id"},"b":[{"$ref":"#/defs/id"}]}}`,
+			out:  `{"defs":{"id":{"type":"string"}},"props":{"a":{"type":"string"},"b":[{"type":"string"}]}}`,
+		},
+		{
+			name: "chained",
+			in:   `{"a":{"$ref":"#/b"},"b":{"$ref":"#/c"},"c":1}`,
+			out:  `{"a":1,"b":1,"c":1}`,
+		},
+		{
+			name: "escaped",
+			in:   `{"x/y":{"a b":true},"r":{"$ref":"#/x~1y/a%20b"}}`,
+			out:  `{"x/y":{"a b":true},"r":true}`,
+		},
+		{
+			name: "cycle",
+			in:   `{"node":{"next":{"$ref":"#/node"}}}`,
+			err:  `$ref refers to itself: "#/node"`,
+		},
+		{
+			name: "missing",
+			in:   `{"a":{"$ref":"#/b"}}`,
+			err:  `$ref "#/b" not found`,
+		},
+		{
+			name: "external",
+			in:   `{"a":{"$ref":"other.json#/b"}}`,
+			err:  `unsupported $ref "other.json#/b": only references within the document are supported`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			in := MustNewValueFromJSON(test.in)
+			v, err := ResolveRefs(in, RefOptions{})
+			if test.err != "" {
+				require.EqualError(err, test.err)
+				return
+			}
+			require.NoError(err)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// The input is unchanged.
+			b, err = json.Marshal(in)
+			require.NoError(err)
+			require.Equal(test.in, string(b))
+		})
+	}
+
+	tt.Run("targets are shared", func(t *testing.T) {
+		require := require.New(t)
+		v, err := ResolveRefs(MustNewValueFromJSON(`{"d":{"x":1},"a":{"$ref":"#/d"},"b":{"$ref":"#/d"}}`), RefOptions{})
+		require.NoError(err)
+		a, _ := v.V.(*Object).Get("a")
+		b, _ := v.V.(*Object).Get("b")
+		require.Same(a, b)
+		a.(*Object).Set("x", 2.0)
+		out, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"d":{"x":1},"a":{"x":2},"b":{"x":2}}`, string(out))
+	})
+
+	tt.Run("chained references", func(t *testing.T) {
+		require := require.New(t)
+		// Each definition refers to the previous one twice, so copying
+		// targets would take 2^64 nodes.
+		var sb strings.Builder
+		sb.WriteString(`{"a0":1`)
+		for i := 1; i < 64; i++ {
+			fmt.Fprintf(&sb, `,"a%d":[{"$ref":"#/a%d"},{"$ref":"#/a%d"}]`, i, i-1, i-1)
+		}
+		sb.WriteString(`}`)
+		v, err := ResolveRefs(MustNewValueFromJSON(sb.String()), RefOptions{})
+		require.NoError(err)
+		a63, _ := v.V.(*Object).Get("a63")
+		arr := a63.([]interface{})
+		require.Len(arr, 2)
+		require.Equal(reflect.ValueOf(arr[0]).Pointer(), reflect.ValueOf(arr[1]).Pointer())
+	})
+}
+
+func TestResolveRefsLazy(tt *testing.T) {
+	require := require.New(tt)
+	const in = `{"node":{"value":1,"next":{"$ref":"#/node"}},"alias":{"$ref":"#/node/next"}}`
+	v, err := ResolveRefs(MustNewValueFromJSON(in), RefOptions{Lazy: true})
+	require.NoError(err)
+
+	b, err := json.Marshal(v)
+	require.NoError(err)
+	require.Equal(in, string(b))
+
+	alias, _ := v.V.(*Object).Get("alias")
+	node, err := alias.(*Ref).Resolve()
+	require.NoError(err)
+	next, _ := node.(*Object).Get("next")
+	again, err := next.(*Ref).Resolve()
+	require.NoError(err)
+	require.Same(node, again)
+
+	_, err = ResolveRefs(MustNewValueFromJSON(`{"a":{"$ref":"#/b"},"b":{"$ref":"#/a"}}`), RefOptions{Lazy: true})
+	require.True(errors.Is(err, ErrRefCycle))
+}
@@ -0,0 +1,26 @@
+package ojson
+
+// Fix describes a change Repair made to its input.
+type Fix struct {
+	Msg string
+	Position
+}
+
+// Repair decodes b, fixing common malformations found in JSON written by
+// hand, by language models, or by legacy systems:
+//
+//   - trailing commas in objects and arrays
+//   - strings, including keys, in single quotes
+//   - raw newlines and other control characters in strings
+//   - missing closing braces and brackets at the end of the input
+//
+// It returns the fixes it made, in input order. Other malformations are
+// returned as a *SyntaxError.
+func Repair(b []byte) (Value, []Fix, error) {
+	p := &lenientParser{data: b, repair: true}
+	v := p.parse()
+	if len(p.errs) > 0 {
+		return Value{}, p.fixes, p.errs[0]
+	}
+	return Value{V: v}, p.fixes, nil
+}
@@ -0,0 +1,94 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepair(tt *testing.T) {
+	for _, test := range []struct {
+		name  string
+		in    string
+		out   string
+		fixes []string
+	}{
+		{
+			name: "valid",
+			in:   `{"b":[1,{"c":null}],"a":"it's"}`,
+			out:  `{"b":[1,{"c":null}],"a":"it's"}`,
+		},
+		{
+			name:  "trailing commas",
+			in:    "{\n  \"a\": [1, 2,],\n  \"b\": {},\n}",
+			out:   `{"a":[1,2],"b":{}}`,
+			fixes: []string{"removed trailing comma at 2:13", "removed trailing comma at 3:10"},
+		},
+		{
+			name:  "single quotes",
+			in:    `{'a': 'say "hi"', 'b': 'it\'s', "c": ['\n']}`,
+			out:   `{"a":"say \"hi\"","b":"it's","c":["\n"]}`,
+			fixes: []string{"replaced single quotes with double quotes at 1:2", "replaced single quotes with double quotes at 1:7", "replaced single quotes with double quotes at 1:19", "replaced single quotes with double quotes at 1:24", "replaced single quotes with double quotes at 1:39"},
+		},
+		{
+			name:  "control characters",
+			in:    "{\"a\": \"line 1\nline 2\tend\n\"}",
+			out:   `{"a":"line 1\nline 2\tend\n"}`,
+			fixes: []string{"escaped control character in string at 1:14"},
+		},
+		{
+			name:  "missing closing delimiters",
+			in:    `{"a": [1, {"b": 2`,
+			out:   `{"a":[1,{"b":2}]}`,
+			fixes: []string{"added missing } at 1:18", "added missing ] at 1:18", "added missing } at 1:18"},
+		},
+		{
+			name:  "trailing comma and missing delimiter",
+			in:    `[1,`,
+			out:   `[1]`,
+			fixes: []string{"removed trailing comma at 1:3", "added missing ] at 1:4"},
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v, fixes, err := Repair([]byte(test.in))
+			require.NoError(err)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			var msgs []string
+			for _, f := range fixes {
+				msgs = append(msgs, fmt.Sprintf("%s at %d:%d", f.Msg, f.Line, f.Column))
+			}
+			require.Equal(test.fixes, msgs)
+		})
+	}
+
+	tt.Run("unrepairable", func(t *testing.T) {
+		require := require.New(t)
+		_, _, err := Repair([]byte(`{"a": nope}`))
+		require.EqualError(err, "invalid literal at line 1, column 7")
+		var syntaxErr *SyntaxError
+		require.True(errors.As(err, &syntaxErr))
+		require.Equal(6, syntaxErr.Offset)
+	})
+}
+
+func TestRepairDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 1 << 20
+	v, fixes, err := Repair([]byte(strings.Repeat("[", depth)))
+	require.NoError(err)
+	require.Len(fixes, depth)
+	require.Equal(Fix{Msg: "added missing ]", Position: Position{Offset: depth, Line: 1, Column: depth + 1}}, fixes[0])
+	n := 0
+	for arr := v.V.([]interface{}); len(arr) > 0; n++ {
+		arr = arr[0].([]interface{})
+	}
+	require.Equal(depth-1, n)
+}
@@ -0,0 +1,284 @@
+package ojson
+
+import (
+	"fmt"
+)
+
+// This file contains a minimal byte-level JSON scanner. Unlike json.Decoder,
+// it reports the exact byte spans of the values it scans, which is needed by
+// features that edit or inspect the source text rather than just its decoded
+// value.
+
+// syntaxError returns a *SyntaxError describing a problem at offset off in
+// b.
+func syntaxError(b []byte, off int, format string, args ...interface{}) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Position: positionOf(b, off)}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// skipSpace returns the index of the first non-whitespace byte in b at or
+// after i.
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
+	return i
+}
+
+// skipSpaceAndComments is like skipSpace, but also skips "//" line comments
+// and "/* */" block comments.
+func skipSpaceAndComments(b []byte, i int) (int, error) {
+	for {
+		i = skipSpace(b, i)
+		if i+1 >= len(b) || b[i] != '/' {
+			return i, nil
+		}
+		switch b[i+1] {
+		case '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+		case '*':
+			start := i
+			i += 2
+			for {
+				if i+1 >= len(b) {
+					return 0, syntaxError(b, start, "unterminated comment")
+				}
+				if b[i] == '*' && b[i+1] == '/' {
+					i += 2
+					break
+				}
+				i++
+			}
+		default:
+			return i, nil
+		}
+	}
+}
+
+// scanString scans the string literal starting at b[i], which must be '"',
+// and returns the index just past its closing quote.
+func scanString(b []byte, i int) (int, error) {
+	start := i
+	i++
+	for i < len(b) {
+		switch c := b[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\':
+			if i+1 >= len(b) {
+				return 0, syntaxError(b, start, "unterminated string")
+			}
+			switch b[i+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				i += 2
+			case 'u':
+				if i+6 > len(b) {
+					return 0, syntaxError(b, start, "unterminated string")
+				}
+				for _, h := range b[i+2 : i+6] {
+					if !isHex(h) {
+						return 0, syntaxError(b, i, "invalid escape sequence in string")
+					}
+				}
+				i += 6
+			default:
+				return 0, syntaxError(b, i, "invalid escape sequence in string")
+			}
+		case c < 0x20:
+			return 0, syntaxError(b, i, "invalid control character in string")
+		default:
+			i++
+		}
+	}
+	return 0, syntaxError(b, start, "unterminated string")
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// scanNumber scans the number literal starting at b[i] and returns the index
+// just past its end.
+func scanNumber(b []byte, i int) (int, error) {
+	start := i
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && isDigit(b[i]):
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	default:
+		return 0, syntaxError(b, start, "invalid number")
+	}
+	if i < len(b) && b[i] == '.' {
+		i++
+		if i >= len(b) || !isDigit(b[i]) {
+			return 0, syntaxError(b, start, "invalid number")
+		}
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if i >= len(b) || !isDigit(b[i]) {
+			return 0, syntaxError(b, start, "invalid number")
+		}
+		for i < len(b) && isDigit(b[i]) {
+			i++
+		}
+	}
+	return i, nil
+}
+
+// scanLiteral checks that b[i:] starts with lit and returns the index just
+// past it.
+func scanLiteral(b []byte, i int, lit string) (int, error) {
+	if len(b)-i < len(lit) || string(b[i:i+len(lit)]) != lit {
+		return 0, syntaxError(b, i, "invalid literal")
+	}
+	return i + len(lit), nil
+}
+
+// scanValue scans the value starting at b[i], which must not be whitespace,
+// and returns the index just past its end. Nested objects and arrays are
+// tracked with an explicit stack rather than by recursion, so that deeply
+// nested input can't overflow the goroutine's stack.
+func scanValue(b []byte, i int) (int, error) {
+	// open holds the offsets of the objects and arrays being scanned,
+	// innermost last.
+	var open []int
+	for {
+		if i >= len(b) {
+			return 0, syntaxError(b, i, "unexpected end of input")
+		}
+		var err error
+		switch c := b[i]; {
+		case c == '{' || c == '[':
+			start := i
+			if i = skipSpace(b, i+1); i < len(b) && b[i] == closingDelim(c) {
+				i++
+				break
+			}
+			open = append(open, start)
+			if c == '{' {
+				if i, err = scanKey(b, i); err != nil {
+					return 0, err
+				}
+			}
+			continue
+		case c == '"':
+			i, err = scanString(b, i)
+		case c == '-' || isDigit(c):
+			i, err = scanNumber(b, i)
+		case c == 't':
+			i, err = scanLiteral(b, i, "true")
+		case c == 'f':
+			i, err = scanLiteral(b, i, "false")
+		case c == 'n':
+			i, err = scanLiteral(b, i, "null")
+		default:
+			return 0, syntaxError(b, i, "invalid character %q looking for beginning of value", c)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// A value has ended: close the containers it ends, then move on to
+		// the next entry of the innermost one still open.
+		for {
+			if len(open) == 0 {
+				return i, nil
+			}
+			start := open[len(open)-1]
+			closing := closingDelim(b[start])
+			if i = skipSpace(b, i); i >= len(b) {
+				return 0, syntaxError(b, start, "unexpected end of input")
+			}
+			if b[i] == closing {
+				open = open[:len(open)-1]
+				i++
+				continue
+			}
+			if b[i] != ',' {
+				return 0, syntaxError(b, i, "expected , or %c", closing)
+			}
+			i = skipSpace(b, i+1)
+			if closing == '}' {
+				if i, err = scanKey(b, i); err != nil {
+					return 0, err
+				}
+			}
+			break
+		}
+	}
+}
+
+// closingDelim returns the delimiter that closes an object or array opened
+// with c.
+func closingDelim(c byte) byte {
+	if c == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+// scanKey scans the object key starting at b[i] and the ':' after it, and
+// returns the index of the member's value.
+func scanKey(b []byte, i int) (int, error) {
+	if i >= len(b) || b[i] != '"' {
+		return 0, syntaxError(b, i, "expected string key in object")
+	}
+	i, err := scanString(b, i)
+	if err != nil {
+		return 0, err
+	}
+	if i = skipSpace(b, i); i >= len(b) || b[i] != ':' {
+		return 0, syntaxError(b, i, "expected : after object key")
+	}
+	return skipSpace(b, i+1), nil
+}
+
+// scanContainer scans the object or array starting at b[i], using entry to
+// scan each of its entries, and returns the index just past its closing
+// delimiter.
+func scanContainer(b []byte, i int, closing byte, entry func(i int) (int, error)) (int, error) {
+	start := i
+	i = skipSpace(b, i+1)
+	if i < len(b) && b[i] == closing {
+		return i + 1, nil
+	}
+	for {
+		var err error
+		if i, err = entry(i); err != nil {
+			return 0, err
+		}
+		if i = skipSpace(b, i); i >= len(b) {
+			return 0, syntaxError(b, start, "unexpected end of input")
+		}
+		switch b[i] {
+		case ',':
+			i = skipSpace(b, i+1)
+		case closing:
+			return i + 1, nil
+		default:
+			return 0, syntaxError(b, i, "expected , or %c", closing)
+		}
+	}
+}
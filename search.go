@@ -0,0 +1,43 @@
+package ojson
+
+import "strconv"
+
+// PathedValue is a value found within a document, along with its location.
+type PathedValue struct {
+	// Path is the JSON Pointer (RFC 6901) of the value.
+	Path  string
+	Value Value
+}
+
+// FindKey returns every value in v whose key is name, at any depth, in
+// document order. A match nested inside another match's value is also
+// returned, after it.
+func FindKey(v Value, name string) []PathedValue {
+	var found []PathedValue
+	walk(v.V, "", func(path string, k string, v interface{}) {
+		if k == name {
+			found = append(found, PathedValue{Path: path, Value: Value{V: v}})
+		}
+	})
+	return found
+}
+
+// walk calls fn for every object member beneath v, in document order, with
+// the member's JSON Pointer, key, and value.
+func walk(v interface{}, path string, fn func(path string, k string, v interface{})) {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return
+		}
+		for _, kv := range v.entries {
+			p := path + "/" + escapePointerToken(kv.Key)
+			fn(p, kv.Key, kv.Value)
+			walk(kv.Value, p, fn)
+		}
+	case []interface{}:
+		for i, e := range v {
+			walk(e, path+"/"+strconv.Itoa(i), fn)
+		}
+	}
+}
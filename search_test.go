@@ -0,0 +1,38 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindKey(tt *testing.T) {
+	require := require.New(tt)
+	v := MustNewValueFromJSON(`{
+		"email": "a@example.com",
+		"users": [
+			{"name": "b", "email": "b@example.com"},
+			{"contact": {"email": {"email": "c@example.com"}}}
+		],
+		"a/b": {"email": null}
+	}`)
+
+	var paths []string
+	var values []interface{}
+	for _, pv := range FindKey(v, "email") {
+		paths = append(paths, pv.Path)
+		values = append(values, pv.Value.V)
+	}
+	require.Equal([]string{
+		"/email",
+		"/users/0/email",
+		"/users/1/contact/email",
+		"/users/1/contact/email/email",
+		"/a~1b/email",
+	}, paths)
+	require.Equal("a@example.com", values[0])
+	require.Equal("c@example.com", values[3])
+	require.Nil(values[4])
+
+	require.Empty(FindKey(v, "missing"))
+}
@@ -0,0 +1,86 @@
+package ojson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// recordSeparator is the ASCII RS character that begins each record of a JSON
+// text sequence.
+const recordSeparator = 0x1E
+
+// SeqReader reads Values from a JSON text sequence (RFC 7464), as used by the
+// application/json-seq media type.
+type SeqReader struct {
+	// Options configures how each record is decoded.
+	Options DecodeOptions
+
+	r *bufio.Reader
+}
+
+// NewSeqReader returns a SeqReader that reads from r.
+func NewSeqReader(r io.Reader) *SeqReader {
+	return &SeqReader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record in the sequence, or io.EOF if there are no
+// more records. Empty records are skipped.
+//
+// If a record can't be decoded, including if it appears to have been
+// truncated, Read returns an error for it. Subsequent calls continue with
+// the next record, as recommended by RFC 7464.
+func (s *SeqReader) Read() (Value, error) {
+	for {
+		rec, err := s.r.ReadBytes(recordSeparator)
+		if err != nil && err != io.EOF {
+			return Value{}, err
+		}
+		rec = bytes.TrimSuffix(rec, []byte{recordSeparator})
+		if len(bytes.TrimLeft(rec, " \t\r\n")) > 0 {
+			return s.decode(rec)
+		}
+		if err == io.EOF {
+			return Value{}, io.EOF
+		}
+	}
+}
+
+func (s *SeqReader) decode(rec []byte) (Value, error) {
+	// A top-level number, true, false, or null can't be told apart from a
+	// truncated one unless it's followed by whitespace.
+	start := skipSpace(rec, 0)
+	if c := rec[start]; c != '{' && c != '[' && c != '"' && !isSpace(rec[len(rec)-1]) {
+		return Value{}, errors.New("json-seq record may be truncated")
+	}
+	v, err := s.Options.Unmarshal(rec)
+	if err == io.EOF {
+		// Don't let an incomplete record be mistaken for the end of the
+		// sequence.
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+// SeqWriter writes Values as a JSON text sequence (RFC 7464).
+type SeqWriter struct {
+	w io.Writer
+}
+
+// NewSeqWriter returns a SeqWriter that writes to w.
+func NewSeqWriter(w io.Writer) *SeqWriter {
+	return &SeqWriter{w: w}
+}
+
+// Write writes v as the next record in the sequence.
+func (s *SeqWriter) Write(v interface{}) error {
+	e := newEncodeState()
+	e.WriteByte(recordSeparator)
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	e.WriteByte('\n')
+	_, err := s.w.Write(e.Bytes())
+	return err
+}
@@ -0,0 +1,55 @@
+package ojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeqReader(tt *testing.T) {
+	require := require.New(tt)
+	in := "\x1e{\"b\":1,\"a\":2}\n\x1e\x1e[true]\n\x1e12\x1e\"x\"\n\x1e{\"a\":\n\x1enull\n"
+	r := NewSeqReader(strings.NewReader(in))
+
+	var got []string
+	var errs int
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs++
+			continue
+		}
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		got = append(got, string(b))
+	}
+	// "12" may have been truncated and `{"a":` is incomplete.
+	require.Equal(2, errs)
+	require.Equal([]string{`{"b":1,"a":2}`, `[true]`, `"x"`, `null`}, got)
+}
+
+func TestSeqWriter(tt *testing.T) {
+	require := require.New(tt)
+	var b bytes.Buffer
+	w := NewSeqWriter(&b)
+	require.NoError(w.Write(MustNewValueFromJSON(`{"b":1,"a":2}`)))
+	require.NoError(w.Write(1.5))
+	require.Equal("\x1e{\"b\":1,\"a\":2}\n\x1e1.5\n", b.String())
+
+	r := NewSeqReader(&b)
+	v, err := r.Read()
+	require.NoError(err)
+	require.Equal([]string{"b", "a"}, v.V.(*Object).KeyOrder())
+	v, err = r.Read()
+	require.NoError(err)
+	require.Equal(1.5, v.V)
+	_, err = r.Read()
+	require.Equal(io.EOF, err)
+}
@@ -0,0 +1,194 @@
+package ojson
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrInvalidSignature is returned when a signature doesn't match the signed
+// JSON.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signer signs the canonical form of a JSON document.
+type Signer interface {
+	Sign(canonical []byte) ([]byte, error)
+}
+
+// Verifier checks a signature of the canonical form of a JSON document,
+// returning ErrInvalidSignature if it doesn't match.
+type Verifier interface {
+	Verify(canonical, sig []byte) error
+}
+
+// HMACSHA256 is a shared secret key that signs and verifies with
+// HMAC-SHA256.
+type HMACSHA256 []byte
+
+var _ Signer = HMACSHA256(nil)
+var _ Verifier = HMACSHA256(nil)
+
+func (k HMACSHA256) Sign(canonical []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k)
+	mac.Write(canonical)
+	return mac.Sum(nil), nil
+}
+
+func (k HMACSHA256) Verify(canonical, sig []byte) error {
+	want, _ := k.Sign(canonical)
+	if !hmac.Equal(want, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Ed25519Signer signs with an Ed25519 private key.
+type Ed25519Signer ed25519.PrivateKey
+
+var _ Signer = Ed25519Signer(nil)
+
+func (k Ed25519Signer) Sign(canonical []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(k), canonical), nil
+}
+
+// Ed25519Verifier verifies with an Ed25519 public key.
+type Ed25519Verifier ed25519.PublicKey
+
+var _ Verifier = Ed25519Verifier(nil)
+
+func (k Ed25519Verifier) Verify(canonical, sig []byte) error {
+	if !ed25519.Verify(ed25519.PublicKey(k), canonical, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignJSON returns the base64-encoded signature of the canonical form (see
+// Canonicalize) of the JSON document body.
+func SignJSON(body []byte, s Signer) (string, error) {
+	canonical, err := canonicalizeJSON(body)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.Sign(canonical)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyJSON checks that sig, as returned by SignJSON, is a valid signature
+// of the JSON document body. Since the canonical form is signed, the body
+// may differ from the one that was signed in key order and formatting.
+func VerifyJSON(body []byte, sig string, v Verifier) error {
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	canonical, err := canonicalizeJSON(body)
+	if err != nil {
+		return err
+	}
+	return v.Verify(canonical, raw)
+}
+
+// canonicalizeJSON returns the canonical form of body. As RFC 8785 requires,
+// body must be I-JSON, so objects with duplicate keys are rejected: a parser
+// that keeps the first value of a key could otherwise read a different
+// document from the one that was signed.
+func canonicalizeJSON(body []byte) ([]byte, error) {
+	v, err := DecodeOptions{DuplicateKeys: DuplicateKeysError}.Unmarshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(v)
+}
+
+// SignRequest signs the JSON body of req with s and sets the signature in
+// the given header. The body remains readable afterwards.
+func SignRequest(req *http.Request, header string, s Signer) error {
+	body, err := readBody(req, 0)
+	if err != nil {
+		return err
+	}
+	sig, err := SignJSON(body, s)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(header, sig)
+	return nil
+}
+
+// DefaultMaxSignedBodySize is the largest request body, in bytes, that
+// VerifyRequests reads.
+const DefaultMaxSignedBodySize = 10 << 20
+
+// errBodyTooLarge is returned by readBody for a body over its limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+// VerifyRequests returns middleware that checks the signature in the given
+// header of each request against its JSON body, as signed by SignRequest.
+// Requests with a missing or invalid signature are rejected with 401
+// Unauthorized; others are passed to the next handler with their body
+// intact. Bodies larger than DefaultMaxSignedBodySize are rejected with 413
+// Request Entity Too Large.
+func VerifyRequests(header string, v Verifier) func(http.Handler) http.Handler {
+	return VerifyRequestsLimit(header, v, DefaultMaxSignedBodySize)
+}
+
+// VerifyRequestsLimit is like VerifyRequests, but rejects bodies larger than
+// maxBytes, without reading any further, instead. If maxBytes is not
+// positive, bodies of any size are read.
+func VerifyRequestsLimit(header string, v Verifier, maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sig := r.Header.Get(header)
+			if sig == "" {
+				http.Error(w, "missing signature", http.StatusUnauthorized)
+				return
+			}
+			body, err := readBody(r, maxBytes)
+			if err == errBodyTooLarge {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "reading body", http.StatusBadRequest)
+				return
+			}
+			if err := VerifyJSON(body, sig, v); err != nil {
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// readBody reads the whole body of req and replaces it with a copy so that
+// it can be read again. If limit is positive and the body is larger, it
+// returns errBodyTooLarge after reading just past the limit.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	var r io.Reader = req.Body
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	body, err := io.ReadAll(r)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, errBodyTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
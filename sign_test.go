@@ -0,0 +1,116 @@
+package ojson
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignJSON(tt *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(tt, err)
+
+	for _, test := range []struct {
+		name     string
+		signer   Signer
+		verifier Verifier
+	}{
+		{name: "hmac", signer: HMACSHA256("secret"), verifier: HMACSHA256("secret")},
+		{name: "ed25519", signer: Ed25519Signer(priv), verifier: Ed25519Verifier(pub)},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			sig, err := SignJSON([]byte(`{"b":1,"a":[true]}`), test.signer)
+			require.NoError(err)
+
+			// Key order and whitespace don't affect the signature.
+			require.NoError(VerifyJSON([]byte(`{ "a": [true], "b": 1.0 }`), sig, test.verifier))
+			require.Equal(ErrInvalidSignature, VerifyJSON([]byte(`{"a":[true],"b":2}`), sig, test.verifier))
+			require.Equal(ErrInvalidSignature, VerifyJSON([]byte(`{"a":[true],"b":1}`), "not base64!", test.verifier))
+		})
+	}
+
+	tt.Run("duplicate keys", func(t *testing.T) {
+		require := require.New(t)
+		_, err := SignJSON([]byte(`{"a":1,"a":2}`), HMACSHA256("secret"))
+		require.True(errors.Is(err, ErrDuplicateKey))
+
+		sig, err := SignJSON([]byte(`{"a":2}`), HMACSHA256("secret"))
+		require.NoError(err)
+		require.Error(VerifyJSON([]byte(`{"a":1,"a":2}`), sig, HMACSHA256("secret")))
+	})
+
+	tt.Run("wrong key", func(t *testing.T) {
+		require := require.New(t)
+		sig, err := SignJSON([]byte(`{}`), HMACSHA256("secret"))
+		require.NoError(err)
+		require.Equal(ErrInvalidSignature, VerifyJSON([]byte(`{}`), sig, HMACSHA256("other")))
+	})
+}
+
+func TestSignedRequests(tt *testing.T) {
+	key := HMACSHA256("secret")
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	handler := VerifyRequests("X-Signature", key)(echo)
+
+	for _, test := range []struct {
+		name   string
+		sign   bool
+		body   string
+		status int
+	}{
+		{name: "signed", sign: true, body: `{"b":1,"a":2}`, status: http.StatusOK},
+		{name: "unsigned", body: `{"b":1,"a":2}`, status: http.StatusUnauthorized},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			req := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+			if test.sign {
+				require.NoError(SignRequest(req, "X-Signature", key))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(test.status, rec.Code)
+			if test.status == http.StatusOK {
+				require.Equal(test.body, rec.Body.String())
+			}
+		})
+	}
+
+	tt.Run("tampered", func(t *testing.T) {
+		require := require.New(t)
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1}`))
+		require.NoError(SignRequest(req, "X-Signature", key))
+		req.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(http.StatusUnauthorized, rec.Code)
+	})
+
+	tt.Run("too large", func(t *testing.T) {
+		require := require.New(t)
+		handler := VerifyRequestsLimit("X-Signature", key, 8)(echo)
+		for _, test := range []struct {
+			body   string
+			status int
+		}{
+			{body: `{"a":123}`, status: http.StatusRequestEntityTooLarge},
+			{body: `{"a":12}`, status: http.StatusOK},
+		} {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+			require.NoError(SignRequest(req, "X-Signature", key))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(test.status, rec.Code, test.body)
+		}
+	})
+}
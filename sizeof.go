@@ -0,0 +1,97 @@
+package ojson
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+	"unsafe"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sizes used by SizeOf, on the current platform.
+const (
+	interfaceSize = int(unsafe.Sizeof(interface{}(nil)))
+	stringSize    = int(unsafe.Sizeof(""))
+	sliceSize     = int(unsafe.Sizeof([]interface{}(nil)))
+	wordSize      = int(unsafe.Sizeof(uintptr(0)))
+	// mapEntryOverhead approximates the per-entry bookkeeping of a Go map,
+	// beyond its keys and values, including the room kept free by its load
+	// factor.
+	mapEntryOverhead = 2 * wordSize
+	// mapHeaderSize approximates the fixed size of a Go map.
+	mapHeaderSize = 6 * wordSize
+)
+
+// SizeOf estimates the number of heap bytes retained by v: its Objects, with
+// their keys and maps, its arrays, and the strings and other values held in
+// them. Values reachable more than once through the same Object are only
+// counted once. Memory shared with other data, such as string contents, is
+// counted as if it weren't. The estimate is for budgeting, such as limiting
+// the size of a cache, and isn't exact.
+func SizeOf(v Value) int {
+	return sizeOf(v.V, make(map[*Object]struct{}))
+}
+
+// sizeOf returns the size of v when held in an interface, not including the
+// interface itself.
+func sizeOf(v interface{}, seen map[*Object]struct{}) int {
+	switch v := v.(type) {
+	case nil, bool:
+		// Held directly in the interface, or shared.
+		return 0
+	case string:
+		return stringSize + len(v)
+	case RawString:
+		return stringSize + len(v.Raw)
+	case json.Number:
+		return stringSize + len(v)
+	case float64, int64, int, uint64:
+		return 8
+	case time.Time:
+		return int(unsafe.Sizeof(v))
+	case *big.Int:
+		if v == nil {
+			return 0
+		}
+		return int(unsafe.Sizeof(*v)) + cap(v.Bits())*wordSize
+	case *big.Float:
+		if v == nil {
+			return 0
+		}
+		return int(unsafe.Sizeof(*v)) + int(v.MinPrec()+7)/8
+	case decimal.Decimal:
+		return int(unsafe.Sizeof(v)) + int(unsafe.Sizeof(big.Int{})) + cap(v.Coefficient().Bits())*wordSize
+	case *Object:
+		if v == nil {
+			return 0
+		}
+		if _, ok := seen[v]; ok {
+			return 0
+		}
+		seen[v] = struct{}{}
+		n := int(unsafe.Sizeof(*v)) + cap(v.entries)*(stringSize+interfaceSize)
+		if v.index != nil {
+			// The index's keys share their bytes with the entries'.
+			n += mapHeaderSize + len(v.index)*(stringSize+wordSize+mapEntryOverhead)
+		}
+		for _, kv := range v.entries {
+			n += len(kv.Key) + sizeOf(kv.Value, seen)
+		}
+		return n
+	case []interface{}:
+		if v == nil {
+			return 0
+		}
+		n := sliceSize + cap(v)*interfaceSize
+		for _, e := range v {
+			n += sizeOf(e, seen)
+		}
+		return n
+	case Value:
+		return interfaceSize + sizeOf(v.V, seen)
+	default:
+		// A rough guess for values of other types.
+		return 2 * wordSize
+	}
+}
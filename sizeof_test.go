@@ -0,0 +1,29 @@
+package ojson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeOf(tt *testing.T) {
+	require := require.New(tt)
+
+	small := SizeOf(MustNewValueFromJSON(`{"a":"x"}`))
+	require.Greater(small, 0)
+
+	// Longer strings and keys, and more entries, take more space.
+	require.Equal(small+99, SizeOf(MustNewValueFromJSON(`{"a":"`+strings.Repeat("x", 100)+`"}`)))
+	require.Equal(small+9, SizeOf(MustNewValueFromJSON(`{"aaaaaaaaaa":"x"}`)))
+	require.Greater(SizeOf(MustNewValueFromJSON(`{"a":"x","b":"y"}`)), small)
+	require.Greater(SizeOf(MustNewValueFromJSON(`[1,2,3,4]`)), SizeOf(MustNewValueFromJSON(`[1]`)))
+	require.Equal(0, SizeOf(MustNewValueFromJSON(`null`)))
+
+	// Shared and cyclic Objects are counted once.
+	o := NewObject().SetAndReturn("k", "v")
+	one := SizeOf(Value{V: []interface{}{o}})
+	require.Equal(one+interfaceSize, SizeOf(Value{V: []interface{}{o, o}}))
+	o.Set("self", o)
+	require.Greater(SizeOf(Value{V: o}), 0)
+}
@@ -0,0 +1,108 @@
+package ojson
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortKeysOptions configures SortKeys.
+type SortKeysOptions struct {
+	// Compare returns a negative number if a sorts before b, a positive
+	// number if it sorts after, and zero if they are equal. If nil, keys are
+	// sorted by their bytes.
+	Compare func(a, b string) int
+	// Recursive also sorts the keys of nested objects, including objects in
+	// arrays.
+	Recursive bool
+}
+
+// SortKeys sorts the keys of the object v, in place. Keys that compare equal
+// keep their relative order. It does nothing if v isn't an object.
+func SortKeys(v Value, opts SortKeysOptions) {
+	if opts.Compare == nil {
+		opts.Compare = strings.Compare
+	}
+	sortKeys(v.V, opts)
+}
+
+func sortKeys(v interface{}, opts SortKeysOptions) {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return
+		}
+		sort.SliceStable(v.entries, func(i, j int) bool {
+			return opts.Compare(v.entries[i].Key, v.entries[j].Key) < 0
+		})
+		v.reindex(0)
+		if opts.Recursive {
+			for _, kv := range v.entries {
+				sortKeys(kv.Value, opts)
+			}
+		}
+	case []interface{}:
+		if opts.Recursive {
+			for _, e := range v {
+				sortKeys(e, opts)
+			}
+		}
+	}
+}
+
+// Collator compares strings according to the rules of a language, such as
+// *collate.Collator from golang.org/x/text/collate.
+type Collator interface {
+	CompareString(a, b string) int
+}
+
+// Collate returns a comparison function, for SortKeysOptions.Compare, that
+// orders keys with c. Collators are not safe for concurrent use, so neither
+// is the returned function.
+func Collate(c Collator) func(a, b string) int {
+	return c.CompareString
+}
+
+// NaturalCompare compares a and b in natural order, treating runs of digits
+// as numbers, so that "item2" sorts before "item10". Everything else is
+// compared by its bytes. It can be used for SortKeysOptions.Compare and
+// MarshalOptions.SortKeys.
+func NaturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the numbers, ignoring leading zeros.
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na := strings.TrimLeft(a[si:i], "0")
+			nb := strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				if len(na) < len(nb) {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			if a[i] < b[j] {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+	if c := strings.Compare(a[i:], b[j:]); c != 0 {
+		return c
+	}
+	// Break ties between numbers written with different leading zeros.
+	return strings.Compare(a, b)
+}
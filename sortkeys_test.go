@@ -0,0 +1,115 @@
+package ojson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+func TestSortKeys(tt *testing.T) {
+	const in = `{"b":1,"a":{"d":[{"z":1,"y":2}],"c":3},"B":4}`
+	for _, test := range []struct {
+		name string
+		opts SortKeysOptions
+		out  string
+	}{
+		{
+			name: "bytes",
+			out:  `{"B":4,"a":{"d":[{"z":1,"y":2}],"c":3},"b":1}`,
+		},
+		{
+			name: "recursive",
+			opts: SortKeysOptions{Recursive: true},
+			out:  `{"B":4,"a":{"c":3,"d":[{"y":2,"z":1}]},"b":1}`,
+		},
+		{
+			name: "reversed",
+			opts: SortKeysOptions{Compare: func(a, b string) int { return strings.Compare(b, a) }},
+			out:  `{"b":1,"a":{"d":[{"z":1,"y":2}],"c":3},"B":4}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v := MustNewValueFromJSON(in)
+			SortKeys(v, test.opts)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("collated", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"zebra":1,"Äpfel":2,"apple":3,"Zoo":4,"über":5}`)
+		SortKeys(v, SortKeysOptions{Compare: Collate(collate.New(language.German))})
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"Äpfel":2,"apple":3,"über":5,"zebra":1,"Zoo":4}`, string(b))
+
+		SortKeys(v, SortKeysOptions{})
+		b, err = json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"Zoo":4,"apple":3,"zebra":1,"Äpfel":2,"über":5}`, string(b))
+	})
+
+	tt.Run("not an object", func(t *testing.T) {
+		v := MustNewValueFromJSON(`[{"b":1,"a":2}]`)
+		SortKeys(v, SortKeysOptions{})
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.Equal(t, `[{"b":1,"a":2}]`, string(b))
+	})
+}
+
+func TestNaturalCompare(tt *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{a: "item2", b: "item10", want: -1},
+		{a: "item10", b: "item2", want: 1},
+		{a: "item2", b: "item2", want: 0},
+		{a: "item02", b: "item2", want: -1},
+		{a: "item2a", b: "item2b", want: -1},
+		{a: "item2", b: "item2a", want: -1},
+		{a: "a10b2", b: "a10b10", want: -1},
+		{a: "10", b: "9", want: 1},
+		{a: "x", b: "10", want: 1},
+		{a: "", b: "0", want: -1},
+		{a: "item99999999999999999999", b: "item100000000000000000000", want: -1},
+	} {
+		tt.Run(test.a+" "+test.b, func(t *testing.T) {
+			require.Equal(t, test.want, NaturalCompare(test.a, test.b))
+		})
+	}
+
+	tt.Run("sort", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"item10":1,"item2":2,"item1":3,"other":4}`)
+		SortKeys(v, SortKeysOptions{Compare: NaturalCompare})
+		b, err := json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"item1":3,"item2":2,"item10":1,"other":4}`, string(b))
+	})
+
+	tt.Run("marshal", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"item10":{"b":1,"a":2},"item2":[{"y10":1,"y9":2}]}`)
+		b, err := MarshalOptions{SortKeys: NaturalCompare}.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"item2":[{"y9":2,"y10":1}],"item10":{"a":2,"b":1}}`, string(b))
+
+		b, err = MarshalOptions{SortKeys: strings.Compare}.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"item10":{"a":2,"b":1},"item2":[{"y10":1,"y9":2}]}`, string(b))
+
+		// The Value itself is unchanged.
+		b, err = json.Marshal(v)
+		require.NoError(err)
+		require.Equal(`{"item10":{"b":1,"a":2},"item2":[{"y10":1,"y9":2}]}`, string(b))
+	})
+}
@@ -0,0 +1,89 @@
+package ojson
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// ValueStats describes the shape of a Value.
+type ValueStats struct {
+	// Objects, Arrays, Strings, Numbers, Bools, and Nulls count the values
+	// of each type, at every depth, including the root.
+	Objects int
+	Arrays  int
+	Strings int
+	Numbers int
+	Bools   int
+	Nulls   int
+	// Other counts values of other Go types, such as structs.
+	Other int
+
+	// MaxDepth is the depth of the most deeply nested value, where the root
+	// is at depth 0.
+	MaxDepth int
+	// Keys is the total number of object members.
+	Keys int
+	// LargestArray is the length of the longest array.
+	LargestArray int
+	// KeyCounts is the number of times each key appears.
+	KeyCounts map[string]int
+}
+
+// Stats returns statistics about the values in v. An Object that contains
+// itself is counted where it recurs, but not descended into again.
+func Stats(v Value) ValueStats {
+	s := ValueStats{KeyCounts: make(map[string]int)}
+	s.add(v.V, 0, make(map[*Object]struct{}))
+	return s
+}
+
+func (s *ValueStats) add(v interface{}, depth int, visiting map[*Object]struct{}) {
+	if depth > s.MaxDepth {
+		s.MaxDepth = depth
+	}
+	switch v := v.(type) {
+	case nil:
+		s.Nulls++
+	case *Object:
+		if v == nil {
+			s.Nulls++
+			return
+		}
+		s.Objects++
+		if _, ok := visiting[v]; ok {
+			return
+		}
+		visiting[v] = struct{}{}
+		defer delete(visiting, v)
+		s.Keys += len(v.entries)
+		for _, kv := range v.entries {
+			s.KeyCounts[kv.Key]++
+			s.add(kv.Value, depth+1, visiting)
+		}
+	case []interface{}:
+		if v == nil {
+			s.Nulls++
+			return
+		}
+		s.Arrays++
+		if len(v) > s.LargestArray {
+			s.LargestArray = len(v)
+		}
+		for _, e := range v {
+			s.add(e, depth+1, visiting)
+		}
+	case string, RawString:
+		s.Strings++
+	case bool:
+		s.Bools++
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		json.Number, *big.Int, *big.Float, decimal.Decimal:
+		s.Numbers++
+	case Value:
+		s.add(v.V, depth, visiting)
+	default:
+		s.Other++
+	}
+}
@@ -0,0 +1,32 @@
+package ojson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(tt *testing.T) {
+	require := require.New(tt)
+	v := MustNewValueFromJSON(`{"id":1,"tags":["a","b",null],"items":[{"id":2,"ok":true},{"id":3,"sub":{"x":[]}}]}`)
+	require.Equal(ValueStats{
+		Objects:      4,
+		Arrays:       3,
+		Strings:      2,
+		Numbers:      3,
+		Bools:        1,
+		Nulls:        1,
+		MaxDepth:     4,
+		Keys:         8,
+		LargestArray: 3,
+		KeyCounts:    map[string]int{"id": 3, "tags": 1, "items": 1, "ok": 1, "sub": 1, "x": 1},
+	}, Stats(v))
+
+	require.Equal(ValueStats{Strings: 1, KeyCounts: map[string]int{}}, Stats(MustNewValueFromJSON(`"x"`)))
+
+	o := NewObject()
+	o.Set("self", o)
+	s := Stats(Value{V: o})
+	require.Equal(2, s.Objects)
+	require.Equal(1, s.Keys)
+}
@@ -0,0 +1,64 @@
+package ojson
+
+import "strconv"
+
+// Summarize returns a copy of v cut down for previews. Objects and arrays
+// nested more than maxDepth levels deep are replaced by markers such as
+// {"…": "12 keys"} and ["… 3 items"]. Objects with more than maxKeys keys
+// keep the first maxKeys, followed by a marker member such as
+// "…": "12 more keys", and arrays likewise keep their first maxKeys
+// elements, followed by a marker element such as "… 12 more items". Zero
+// or negative limits mean no limit. v itself is not modified.
+func Summarize(v Value, maxDepth, maxKeys int) Value {
+	return Value{V: summarize(v.V, 0, maxDepth, maxKeys)}
+}
+
+func summarize(v interface{}, depth, maxDepth, maxKeys int) interface{} {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return v
+		}
+		obj := NewObject()
+		n := len(v.entries)
+		if maxDepth > 0 && depth >= maxDepth && n > 0 {
+			obj.Set("…", plural(n, "key"))
+			return obj
+		}
+		for i, kv := range v.entries {
+			if maxKeys > 0 && i >= maxKeys {
+				obj.Set("…", plural(n-i, "more key"))
+				break
+			}
+			obj.Set(kv.Key, summarize(kv.Value, depth+1, maxDepth, maxKeys))
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		if maxDepth > 0 && depth >= maxDepth && len(v) > 0 {
+			return []interface{}{"… " + plural(len(v), "item")}
+		}
+		arr := make([]interface{}, 0, len(v))
+		for i, e := range v {
+			if maxKeys > 0 && i >= maxKeys {
+				arr = append(arr, "… "+plural(len(v)-i, "more item"))
+				break
+			}
+			arr = append(arr, summarize(e, depth+1, maxDepth, maxKeys))
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// plural returns n followed by noun, pluralized if n isn't 1.
+func plural(n int, noun string) string {
+	s := strconv.Itoa(n) + " " + noun
+	if n != 1 {
+		s += "s"
+	}
+	return s
+}
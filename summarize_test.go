@@ -0,0 +1,57 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(tt *testing.T) {
+	const in = `{"a":1,"b":{"c":{"d":1},"e":[1,2,3]},"f":[],"g":{},"h":"x"}`
+	for _, test := range []struct {
+		name     string
+		maxDepth int
+		maxKeys  int
+		out      string
+	}{
+		{
+			name: "no limits",
+			out:  in,
+		},
+		{
+			name:     "depth",
+			maxDepth: 1,
+			out:      `{"a":1,"b":{"…":"2 keys"},"f":[],"g":{},"h":"x"}`,
+		},
+		{
+			name:     "deeper",
+			maxDepth: 2,
+			out:      `{"a":1,"b":{"c":{"…":"1 key"},"e":["… 3 items"]},"f":[],"g":{},"h":"x"}`,
+		},
+		{
+			name:    "keys",
+			maxKeys: 2,
+			out:     `{"a":1,"b":{"c":{"d":1},"e":[1,2,"… 1 more item"]},"…":"3 more keys"}`,
+		},
+		{
+			name:     "root",
+			maxDepth: -1,
+			maxKeys:  1,
+			out:      `{"a":1,"…":"4 more keys"}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			v := MustNewValueFromJSON(in)
+			b, err := json.Marshal(Summarize(v, test.maxDepth, test.maxKeys))
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+
+			// The input is unchanged.
+			b, err = json.Marshal(v)
+			require.NoError(err)
+			require.Equal(in, string(b))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package ojson
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ExpandTemplates returns a copy of v with every string value, at any depth,
+// replaced by the result of executing it as a text/template with data and
+// funcs. Keys are not expanded, and since each string is expanded on its
+// own, template output can't affect the document's structure. Strings
+// without actions are copied as they are.
+func ExpandTemplates(v Value, data interface{}, funcs template.FuncMap) (Value, error) {
+	var b strings.Builder
+	e, err := mapStrings(v.V, "", func(path string, s string) (interface{}, error) {
+		if !strings.Contains(s, "{{") {
+			return s, nil
+		}
+		t, err := template.New(path).Funcs(funcs).Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template at %q: %w", path, err)
+		}
+		b.Reset()
+		if err := t.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("executing template at %q: %w", path, err)
+		}
+		return b.String(), nil
+	})
+	return Value{V: e}, err
+}
@@ -0,0 +1,57 @@
+package ojson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTemplates(tt *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "nightly",
+		"Steps": []string{"build", "test"},
+	}
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+
+	tt.Run("expands strings", func(t *testing.T) {
+		require := require.New(t)
+		in := `{"name":"{{.Name}}","cmd":["run","{{range $i, $s := .Steps}}{{if $i}}; {{end}}{{$s}}{{end}}"],"{{.Name}}":"{{upper .Name}}","n":1}`
+		v := MustNewValueFromJSON(in)
+		out, err := ExpandTemplates(v, data, funcs)
+		require.NoError(err)
+		b, err := json.Marshal(out)
+		require.NoError(err)
+		require.Equal(`{"name":"nightly","cmd":["run","build; test"],"{{.Name}}":"NIGHTLY","n":1}`, string(b))
+
+		// The input is unchanged.
+		b, err = json.Marshal(v)
+		require.NoError(err)
+		require.JSONEq(in, string(b))
+	})
+
+	tt.Run("output containing braces stays a string", func(t *testing.T) {
+		require := require.New(t)
+		out, err := ExpandTemplates(MustNewValueFromJSON(`{"a":"{{.}}"}`), `{"x":[1}`, nil)
+		require.NoError(err)
+		b, err := json.Marshal(out)
+		require.NoError(err)
+		require.Equal(`{"a":"{\"x\":[1}"}`, string(b))
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		_, err := ExpandTemplates(MustNewValueFromJSON(`{"a":["{{.Name"]}`), data, nil)
+		require.Error(err)
+		require.Contains(err.Error(), `parsing template at "/a/0"`)
+
+		_, err = ExpandTemplates(MustNewValueFromJSON(`{"a":"{{nope}}"}`), data, nil)
+		require.Error(err)
+
+		_, err = ExpandTemplates(MustNewValueFromJSON(`{"b":"{{.Name.Missing}}"}`), data, nil)
+		require.Error(err)
+		require.Contains(err.Error(), `executing template at "/b"`)
+	})
+}
@@ -0,0 +1,43 @@
+package ojson
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetTime returns the value of k as a time. A time.Time value is returned
+// as-is; a string value is parsed with each of the given layouts in turn,
+// defaulting to RFC 3339.
+func (o *Object) GetTime(k string, layouts ...string) (time.Time, error) {
+	v, ok := o.Get(k)
+	if !ok {
+		return time.Time{}, fmt.Errorf("key %q not found", k)
+	}
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if len(layouts) == 0 {
+			layouts = []string{time.RFC3339Nano}
+		}
+		var err error
+		for _, layout := range layouts {
+			var t time.Time
+			if t, err = time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("parsing value of key %q as time: %w", k, err)
+	default:
+		return time.Time{}, fmt.Errorf("value of key %q is a %T, not a time", k, v)
+	}
+}
+
+// SetTime sets k to t formatted with layout, defaulting to RFC 3339 with
+// fractional seconds.
+func (o *Object) SetTime(k string, t time.Time, layout string) {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	o.Set(k, t.Format(layout))
+}
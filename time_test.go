@@ -0,0 +1,65 @@
+package ojson
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSetTime(tt *testing.T) {
+	want := time.Date(2021, 12, 14, 10, 30, 0, 0, time.UTC)
+
+	tt.Run("round trip", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		o.SetTime("at", want, "")
+		v, _ := o.Get("at")
+		require.Equal("2021-12-14T10:30:00Z", v)
+
+		got, err := o.GetTime("at")
+		require.NoError(err)
+		require.True(want.Equal(got))
+	})
+
+	tt.Run("layouts", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject().SetAndReturn("at", "2021-12-14 10:30")
+		_, err := o.GetTime("at")
+		require.Error(err)
+
+		got, err := o.GetTime("at", time.RFC3339, "2006-01-02 15:04")
+		require.NoError(err)
+		require.True(want.Equal(got))
+	})
+
+	tt.Run("errors", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject().SetAndReturn("n", 1.0)
+		_, err := o.GetTime("missing")
+		require.Error(err)
+		_, err = o.GetTime("n")
+		require.Error(err)
+	})
+}
+
+func TestDecodeTimes(tt *testing.T) {
+	require := require.New(tt)
+	in := `{"at":"2021-12-14T11:30:00+01:00","name":"2021-12-14","n":[1]}`
+	v, err := DecodeOptions{Times: true, TimeLocation: time.UTC}.Unmarshal([]byte(in))
+	require.NoError(err)
+
+	o := v.V.(*Object)
+	at, _ := o.Get("at")
+	require.Equal(time.Date(2021, 12, 14, 10, 30, 0, 0, time.UTC), at)
+	name, _ := o.Get("name")
+	require.Equal("2021-12-14", name)
+
+	got, err := o.GetTime("at")
+	require.NoError(err)
+	require.Equal(at, got)
+
+	b, err := v.MarshalJSON()
+	require.NoError(err)
+	require.Equal(`{"at":"2021-12-14T10:30:00Z","name":"2021-12-14","n":[1]}`, string(b))
+}
@@ -0,0 +1,121 @@
+package ojson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTreeValueLen is the length beyond which DumpTree truncates values.
+const maxTreeValueLen = 40
+
+// DumpTree returns an indented tree view of v for debugging, like the output
+// of tree(1), with keys in order. Each line shows a key or index, the type of
+// the value, and scalar values as JSON, truncated if long. Objects that
+// contain themselves are marked rather than followed.
+func DumpTree(v Value) string {
+	var b strings.Builder
+	t := treeDumper{b: &b, visiting: make(map[*Entry]struct{})}
+	t.value(v.V, "")
+	return b.String()
+}
+
+type treeDumper struct {
+	b *strings.Builder
+	// visiting holds the objectIDs of the Objects being dumped.
+	visiting map[*Entry]struct{}
+}
+
+// value writes the rest of the line for v, and then v's children, each line
+// prefixed with indent.
+func (t *treeDumper) value(v interface{}, indent string) {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			break
+		}
+		if key := objectID(v); key != nil {
+			if _, ok := t.visiting[key]; ok {
+				t.b.WriteString("object (cycle)\n")
+				return
+			}
+			t.visiting[key] = struct{}{}
+			defer delete(t.visiting, key)
+		}
+		t.b.WriteString("object (" + strconv.Itoa(len(v.entries)) + ")\n")
+		for i, kv := range v.entries {
+			t.child(strconv.Quote(kv.Key), kv.Value, indent, i == len(v.entries)-1)
+		}
+		return
+	case Object:
+		t.value(&v, indent)
+		return
+	case []interface{}:
+		if v == nil {
+			break
+		}
+		t.b.WriteString("array (" + strconv.Itoa(len(v)) + ")\n")
+		for i, e := range v {
+			t.child(strconv.Itoa(i), e, indent, i == len(v)-1)
+		}
+		return
+	case Value:
+		t.value(v.V, indent)
+		return
+	}
+
+	typ := "number"
+	b, err := MarshalOptions{}.Marshal(v)
+	switch {
+	case err != nil:
+		typ, b = "invalid", []byte(err.Error())
+	case len(b) == 0:
+		typ = "invalid"
+	case b[0] == '"':
+		typ = "string"
+	case b[0] == 'n':
+		typ = "null"
+	case b[0] == 't' || b[0] == 'f':
+		typ = "bool"
+	case b[0] == '{':
+		typ = "object"
+	case b[0] == '[':
+		typ = "array"
+	}
+	t.b.WriteString(typ)
+	if typ != "null" {
+		t.b.WriteString(" ")
+		t.b.WriteString(truncate(string(b), maxTreeValueLen))
+	}
+	t.b.WriteString("\n")
+}
+
+func (t *treeDumper) child(label string, v interface{}, indent string, last bool) {
+	t.b.WriteString(indent)
+	if last {
+		t.b.WriteString("└── ")
+		indent += "    "
+	} else {
+		t.b.WriteString("├── ")
+		indent += "│   "
+	}
+	t.b.WriteString(label)
+	t.b.WriteString(": ")
+	t.value(v, indent)
+}
+
+// truncate shortens s to at most n runes, marking it with an ellipsis if it
+// was shortened.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for j := range s {
+		if i == n-1 {
+			return s[:j] + "…"
+		}
+		i++
+	}
+	return s
+}
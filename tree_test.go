@@ -0,0 +1,45 @@
+package ojson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpTree(tt *testing.T) {
+	tt.Run("document", func(t *testing.T) {
+		require := require.New(t)
+		v := MustNewValueFromJSON(`{"name":"web","ports":[80,{"tls":true}],"empty":{},"note":null,"long":"` + strings.Repeat("x", 50) + `"}`)
+		require.Equal(`object (5)
+├── "name": string "web"
+├── "ports": array (2)
+│   ├── 0: number 80
+│   └── 1: object (1)
+│       └── "tls": bool true
+├── "empty": object (0)
+├── "note": null
+└── "long": string "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx…
+`, DumpTree(v))
+	})
+
+	tt.Run("scalar", func(t *testing.T) {
+		require.Equal(t, "number 1.5\n", DumpTree(MustNewValueFromJSON(`1.5`)))
+	})
+
+	tt.Run("cycle", func(t *testing.T) {
+		o := NewObject()
+		o.Set("self", o)
+		require.Equal(t, "object (1)\n└── \"self\": object (cycle)\n", DumpTree(Value{V: o}))
+
+		// A copy of an Object value shares its storage.
+		o = NewObject()
+		o.Set("self", nil)
+		o.Set("self", *o)
+		require.Equal(t, "object (1)\n└── \"self\": object (cycle)\n", DumpTree(Value{V: o}))
+	})
+
+	tt.Run("invalid raw string", func(t *testing.T) {
+		require.Equal(t, "invalid cannot marshal RawString \"\", which is n…\n", DumpTree(Value{V: RawString{}}))
+	})
+}
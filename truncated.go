@@ -0,0 +1,17 @@
+package ojson
+
+// UnmarshalTruncated decodes b, which may have been cut off part way
+// through, such as a file left behind by a writer that crashed. It decodes
+// as much as it can, closes any open objects and arrays, and reports whether
+// the input was cut off. A string that was cut off is kept as far as it got,
+// while object members without a value, and numbers and literals that were
+// cut off before they became valid, are dropped. Syntax errors other than
+// the input ending early are returned as a *SyntaxError.
+func UnmarshalTruncated(b []byte) (v Value, truncated bool, err error) {
+	p := &lenientParser{data: b, truncate: true}
+	root := p.parse()
+	if len(p.errs) > 0 {
+		return Value{}, p.truncated, p.errs[0]
+	}
+	return Value{V: root}, p.truncated, nil
+}
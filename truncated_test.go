@@ -0,0 +1,68 @@
+package ojson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalTruncated(tt *testing.T) {
+	for _, test := range []struct {
+		in        string
+		out       string
+		truncated bool
+	}{
+		{in: `{"a":[1,2],"b":"x"}`, out: `{"a":[1,2],"b":"x"}`},
+		{in: `{"a":[1,2],"b":"x`, out: `{"a":[1,2],"b":"x"}`, truncated: true},
+		{in: `{"a":[1,2],"b":"x\`, out: `{"a":[1,2],"b":"x"}`, truncated: true},
+		{in: `{"a":[1,2],"b":"x\u00`, out: `{"a":[1,2],"b":"x"}`, truncated: true},
+		{in: `{"a":[1,2],"b":`, out: `{"a":[1,2]}`, truncated: true},
+		{in: `{"a":[1,2],"b"`, out: `{"a":[1,2]}`, truncated: true},
+		{in: `{"a":[1,2],"b`, out: `{"a":[1,2]}`, truncated: true},
+		{in: `{"a":[1,2],`, out: `{"a":[1,2]}`, truncated: true},
+		{in: `{"a":[1,2`, out: `{"a":[1,2]}`, truncated: true},
+		{in: `{"a":[1,2.`, out: `{"a":[1]}`, truncated: true},
+		{in: `{"a":[1,tr`, out: `{"a":[1]}`, truncated: true},
+		{in: `{"a":[{"b":{`, out: `{"a":[{"b":{}}]}`, truncated: true},
+		{in: ``, out: `null`, truncated: true},
+	} {
+		tt.Run(test.in, func(t *testing.T) {
+			require := require.New(t)
+			v, truncated, err := UnmarshalTruncated([]byte(test.in))
+			require.NoError(err)
+			require.Equal(test.truncated, truncated)
+			b, err := json.Marshal(v)
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("syntax errors", func(t *testing.T) {
+		require := require.New(t)
+		for _, in := range []string{`{"a":[1,2]]`, `{"a":x`, `[1,tx`, `[1.x`} {
+			_, _, err := UnmarshalTruncated([]byte(in))
+			require.Error(err, in)
+		}
+	})
+}
+
+func TestUnmarshalTruncatedDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 1 << 20
+	v, truncated, err := UnmarshalTruncated([]byte(strings.Repeat(`{"a":[`, depth) + `"x`))
+	require.NoError(err)
+	require.True(truncated)
+	n := 0
+	for x := v.V; ; n++ {
+		obj, ok := x.(*Object)
+		if !ok {
+			require.Equal("x", x)
+			break
+		}
+		a, _ := obj.Get("a")
+		x = a.([]interface{})[0]
+	}
+	require.Equal(depth, n)
+}
@@ -0,0 +1,183 @@
+package ojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// UnstructuredOptions configures FromUnstructured.
+type UnstructuredOptions struct {
+	// KubernetesOrder orders the keys of every object that has an apiVersion
+	// or kind as in kubectl output: apiVersion, kind, and metadata first,
+	// status last, and everything else sorted in between. Within metadata,
+	// name, generateName, and namespace come first. Otherwise all keys are
+	// sorted.
+	KubernetesOrder bool
+}
+
+// ToUnstructured converts v, which must be an object, to the content of an
+// unstructured.Unstructured, as used by client-go's dynamic client:
+//
+//	u := &unstructured.Unstructured{Object: m}
+//
+// Integral numbers become int64 and other numbers float64, as the
+// Kubernetes JSON decoder produces.
+func ToUnstructured(v Value) (map[string]interface{}, error) {
+	obj, ok := v.V.(*Object)
+	if !ok || obj == nil {
+		return nil, errors.New("unstructured content must be an object")
+	}
+	m, err := toUnstructured(obj, "")
+	if err != nil {
+		return nil, err
+	}
+	return m.(map[string]interface{}), nil
+}
+
+func toUnstructured(v interface{}, path string) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, string, int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), nil
+		}
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("converting %q: %w", path, err)
+		}
+		return f, nil
+	case *big.Int:
+		if v == nil {
+			return nil, nil
+		}
+		if !v.IsInt64() {
+			return nil, fmt.Errorf("converting %q: %s overflows int64", path, v)
+		}
+		return v.Int64(), nil
+	case RawString:
+		var s string
+		if err := json.Unmarshal([]byte(v.Raw), &s); err != nil {
+			return nil, fmt.Errorf("converting %q: %w", path, err)
+		}
+		return s, nil
+	case *Object:
+		if v == nil {
+			return nil, nil
+		}
+		m := make(map[string]interface{}, len(v.entries))
+		for _, kv := range v.entries {
+			e, err := toUnstructured(kv.Value, path+"/"+escapePointerToken(kv.Key))
+			if err != nil {
+				return nil, err
+			}
+			m[kv.Key] = e
+		}
+		return m, nil
+	case []interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			e, err := toUnstructured(e, path+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = e
+		}
+		return arr, nil
+	case Value:
+		return toUnstructured(v.V, path)
+	default:
+		return nil, fmt.Errorf("converting %q: unsupported type %T", path, v)
+	}
+}
+
+// FromUnstructured converts the content of an unstructured.Unstructured, or
+// any other map decoded from JSON, to a Value. Since maps are unordered, keys
+// are ordered according to opts. int64 values are kept as they are.
+func FromUnstructured(m map[string]interface{}, opts UnstructuredOptions) Value {
+	return Value{V: opts.fromUnstructured(m, false)}
+}
+
+func (opts UnstructuredOptions) fromUnstructured(v interface{}, metadata bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		_, hasAPIVersion := v["apiVersion"]
+		_, hasKind := v["kind"]
+		resource := opts.KubernetesOrder && (hasAPIVersion || hasKind)
+		if resource {
+			keys = orderKeys(keys, []string{"apiVersion", "kind", "metadata"}, []string{"status"})
+		} else if metadata {
+			keys = orderKeys(keys, []string{"name", "generateName", "namespace"}, nil)
+		}
+		obj := NewObjectWithCapacity(len(keys))
+		for _, k := range keys {
+			obj.Set(k, opts.fromUnstructured(v[k], resource && k == "metadata"))
+		}
+		return obj
+	case []interface{}:
+		if v == nil {
+			return nil
+		}
+		arr := make([]interface{}, len(v))
+		for i, e := range v {
+			arr[i] = opts.fromUnstructured(e, false)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// orderKeys returns the sorted keys with those in first moved to the front
+// and those in last moved to the end, in the given orders.
+func orderKeys(sorted []string, first, last []string) []string {
+	pinned := make(map[string]bool, len(first)+len(last))
+	present := make(map[string]bool, len(sorted))
+	for _, k := range sorted {
+		present[k] = true
+	}
+	keys := make([]string, 0, len(sorted))
+	for _, k := range first {
+		pinned[k] = true
+		if present[k] {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range last {
+		pinned[k] = true
+	}
+	for _, k := range sorted {
+		if !pinned[k] {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range last {
+		if present[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,81 @@
+package ojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToUnstructured(tt *testing.T) {
+	require := require.New(tt)
+	m, err := ToUnstructured(MustNewValueFromJSON(`{"kind":"Pod","spec":{"replicas":3,"ratio":0.5,"ports":[{"port":80}]},"x":null}`))
+	require.NoError(err)
+	require.Equal(map[string]interface{}{
+		"kind": "Pod",
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"ratio":    0.5,
+			"ports":    []interface{}{map[string]interface{}{"port": int64(80)}},
+		},
+		"x": nil,
+	}, m)
+
+	_, err = ToUnstructured(MustNewValueFromJSON(`[]`))
+	require.Error(err)
+
+	_, err = ToUnstructured(Value{V: NewObject().SetAndReturn("a", struct{}{})})
+	require.EqualError(err, `converting "/a": unsupported type struct {}`)
+}
+
+func TestFromUnstructured(tt *testing.T) {
+	m := map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Running"},
+		"spec": map[string]interface{}{
+			"b": int64(1),
+			"a": []interface{}{"x"},
+		},
+		"metadata": map[string]interface{}{
+			"labels":    map[string]interface{}{"app": "web"},
+			"namespace": "default",
+			"name":      "web",
+		},
+		"kind":       "Deployment",
+		"apiVersion": "apps/v1",
+	}
+	for _, test := range []struct {
+		name string
+		opts UnstructuredOptions
+		out  string
+	}{
+		{
+			name: "sorted",
+			out:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"app":"web"},"name":"web","namespace":"default"},"spec":{"a":["x"],"b":1},"status":{"phase":"Running"}}`,
+		},
+		{
+			name: "kubernetes order",
+			opts: UnstructuredOptions{KubernetesOrder: true},
+			out:  `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default","labels":{"app":"web"}},"spec":{"a":["x"],"b":1},"status":{"phase":"Running"}}`,
+		},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			b, err := json.Marshal(FromUnstructured(m, test.opts))
+			require.NoError(err)
+			require.Equal(test.out, string(b))
+		})
+	}
+
+	tt.Run("list items", func(t *testing.T) {
+		require := require.New(t)
+		list := map[string]interface{}{
+			"kind": "List",
+			"items": []interface{}{
+				map[string]interface{}{"spec": map[string]interface{}{}, "kind": "Pod"},
+			},
+		}
+		b, err := json.Marshal(FromUnstructured(list, UnstructuredOptions{KubernetesOrder: true}))
+		require.NoError(err)
+		require.Equal(`{"kind":"List","items":[{"kind":"Pod","spec":{}}]}`, string(b))
+	})
+}
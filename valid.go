@@ -0,0 +1,141 @@
+package ojson
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// Valid reports whether b is a single valid JSON value, without decoding it.
+func Valid(b []byte) bool {
+	return json.Valid(b)
+}
+
+// ValidStrict is like Valid, but also rejects input that decodes lossily: an
+// object with duplicate keys, of which all but the last would be dropped, or
+// invalid UTF-8, which would be replaced with U+FFFD.
+func ValidStrict(b []byte) bool {
+	return validStrict(b) == nil
+}
+
+func validStrict(b []byte) error {
+	if !utf8.Valid(b) {
+		return syntaxError(b, 0, "invalid UTF-8")
+	}
+	i, err := scanStrict(b, skipSpace(b, 0))
+	if err != nil {
+		return err
+	}
+	if i = skipSpace(b, i); i < len(b) {
+		return syntaxError(b, i, "invalid character after top-level value")
+	}
+	return nil
+}
+
+// strictFrame is an object or array being scanned by scanStrict.
+type strictFrame struct {
+	// start is the offset of the opening delimiter.
+	start int
+	// keys holds the keys seen so far, if the frame is an object.
+	keys map[string]struct{}
+}
+
+// scanStrict scans the value starting at b[i] and returns the index just past
+// its end. Nested objects and arrays are tracked with an explicit stack
+// rather than by recursion, so that deeply nested input can't overflow the
+// goroutine's stack.
+func scanStrict(b []byte, i int) (int, error) {
+	var stack []strictFrame
+	for {
+		if i >= len(b) {
+			return 0, syntaxError(b, i, "unexpected end of input")
+		}
+		var err error
+		switch c := b[i]; {
+		case c == '{' || c == '[':
+			f := strictFrame{start: i}
+			if i = skipSpace(b, i+1); i < len(b) && b[i] == closingDelim(c) {
+				i++
+				break
+			}
+			if c == '{' {
+				f.keys = make(map[string]struct{})
+				if i, err = scanStrictKey(b, i, f); err != nil {
+					return 0, err
+				}
+			}
+			stack = append(stack, f)
+			continue
+		case c == '"':
+			i, err = scanString(b, i)
+		case c == '-' || isDigit(c):
+			i, err = scanNumber(b, i)
+		case c == 't':
+			i, err = scanLiteral(b, i, "true")
+		case c == 'f':
+			i, err = scanLiteral(b, i, "false")
+		case c == 'n':
+			i, err = scanLiteral(b, i, "null")
+		default:
+			return 0, syntaxError(b, i, "invalid character %q", c)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// A value has ended: close the containers it ends, then move on to
+		// the next entry of the innermost one still open.
+		for {
+			if len(stack) == 0 {
+				return i, nil
+			}
+			f := stack[len(stack)-1]
+			closing := closingDelim(b[f.start])
+			i = skipSpace(b, i)
+			if i < len(b) && b[i] == closing {
+				stack = stack[:len(stack)-1]
+				i++
+				continue
+			}
+			if i >= len(b) || b[i] != ',' {
+				if f.keys != nil {
+					return 0, syntaxError(b, i, "expected , or } in object")
+				}
+				return 0, syntaxError(b, i, "expected , or ] in array")
+			}
+			i = skipSpace(b, i+1)
+			if f.keys != nil {
+				if i, err = scanStrictKey(b, i, f); err != nil {
+					return 0, err
+				}
+			}
+			break
+		}
+	}
+}
+
+// scanStrictKey scans the key of a member of the object f starting at b[i],
+// and the ':' after it, and returns the index of the member's value. It
+// fails if f already has the key.
+func scanStrictKey(b []byte, i int, f strictFrame) (int, error) {
+	if i >= len(b) || b[i] != '"' {
+		return 0, syntaxError(b, i, "expected string key in object")
+	}
+	end, err := scanString(b, i)
+	if err != nil {
+		return 0, err
+	}
+	var k string
+	if err := json.Unmarshal(b[i:end], &k); err != nil {
+		return 0, syntaxError(b, i, "invalid string")
+	}
+	if _, ok := f.keys[k]; ok {
+		return 0, syntaxError(b, i, "duplicate key %q in object at offset %d", k, f.start)
+	}
+	f.keys[k] = struct{}{}
+
+	i = skipSpace(b, end)
+	if i >= len(b) || b[i] != ':' {
+		return 0, syntaxError(b, i, "expected : after object key")
+	}
+	return skipSpace(b, i+1), nil
+}
@@ -0,0 +1,60 @@
+package ojson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValid(tt *testing.T) {
+	for _, test := range []struct {
+		in     string
+		valid  bool
+		strict bool
+	}{
+		{in: `{"a":[1,2.5e3,-0,true,false,null,"x"],"b":{}}`, valid: true, strict: true},
+		{in: ` [ ] `, valid: true, strict: true},
+		{in: `"é\n"`, valid: true, strict: true},
+		{in: `{"a":1,"a":2}`, valid: true},
+		{in: `{"a":1,"\u0061":2}`, valid: true},
+		{in: `{"a":{"b":1},"c":{"b":2}}`, valid: true, strict: true},
+		{in: `{"a":{"b":1},"a":2}`, valid: true},
+		{in: `[{"x":[]},{"x":1,"x":2}]`, valid: true},
+		{in: "\"\xff\"", valid: true},
+		{in: ``},
+		{in: `{`},
+		{in: `{"a":1,}`},
+		{in: `[1,]`},
+		{in: `[1 2]`},
+		{in: `{"a" 1}`},
+		{in: `{1:2}`},
+		{in: `01`},
+		{in: `1 2`},
+		{in: `nul`},
+		{in: `"a`},
+	} {
+		tt.Run(test.in, func(t *testing.T) {
+			require := require.New(t)
+			require.Equal(test.valid, Valid([]byte(test.in)))
+			require.Equal(test.strict, ValidStrict([]byte(test.in)))
+		})
+	}
+}
+
+func TestValidStrictError(tt *testing.T) {
+	require := require.New(tt)
+	err := validStrict([]byte("[\n  1,\n  2 3\n]"))
+	var syntaxErr *SyntaxError
+	require.True(errors.As(err, &syntaxErr), "%v", err)
+	require.Equal(Position{Offset: 11, Line: 3, Column: 5}, syntaxErr.Position)
+}
+
+func TestValidStrictDeepNesting(tt *testing.T) {
+	require := require.New(tt)
+	const depth = 1 << 18
+	require.True(ValidStrict([]byte(strings.Repeat(`{"a":[`, depth) + strings.Repeat(`]}`, depth))))
+	require.False(ValidStrict([]byte(strings.Repeat(`{"a":[`, depth))))
+	require.False(ValidStrict([]byte(strings.Repeat(`[{"a":1,"a":2}`, depth))))
+}
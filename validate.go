@@ -0,0 +1,241 @@
+package ojson
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// UnsupportedValueError is returned by Validate and SetValidated when a value
+// can't be represented as JSON.
+type UnsupportedValueError struct {
+	// Path is the JSON Pointer (RFC 6901) of the offending value, relative to
+	// the value being validated.
+	Path string
+	Err  error
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return fmt.Sprintf("unsupported value at %q: %v", e.Path, e.Err)
+}
+
+func (e *UnsupportedValueError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Validate checks that v can be marshaled as JSON, returning an
+// *UnsupportedValueError describing the first value that can't be. Values
+// such as channels, functions, complex numbers, NaN and infinite floats,
+// maps with unsupported key types, and Objects that contain themselves are
+// all rejected.
+//
+// Types implementing json.Marshaler are trusted to marshal successfully.
+func Validate(v interface{}) error {
+	return newValidator().validate(reflect.ValueOf(v), "")
+}
+
+// SetValidated is like Set, but first validates v and returns an error instead
+// of setting k if v can't be represented as JSON, including if v contains o.
+func (o *Object) SetValidated(k string, v interface{}) error {
+	val := newValidator()
+	val.visiting[reflect.ValueOf(o).Pointer()] = struct{}{}
+	if key := objectID(o); key != nil {
+		val.objects[key] = struct{}{}
+	}
+	if err := val.validate(reflect.ValueOf(v), "/"+escapePointerToken(k)); err != nil {
+		return err
+	}
+	o.Set(k, v)
+	return nil
+}
+
+type validator struct {
+	// visiting holds the addresses of the pointers and maps on the path to
+	// the value being validated, and objects the objectIDs of the Objects,
+	// used to detect cycles.
+	visiting map[uintptr]struct{}
+	objects  map[*Entry]struct{}
+}
+
+func newValidator() *validator {
+	return &validator{
+		visiting: make(map[uintptr]struct{}),
+		objects:  make(map[*Entry]struct{}),
+	}
+}
+
+func (val *validator) validate(v reflect.Value, path string) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case *Object:
+			if x == nil {
+				return nil
+			}
+			return val.validateObject(x, path)
+		case Object:
+			return val.validateObject(&x, path)
+		case Value:
+			return val.validate(reflect.ValueOf(x.V), path)
+		case *Value:
+			if x == nil {
+				return nil
+			}
+			return val.validate(reflect.ValueOf(x.V), path)
+		case *big.Float:
+			if x != nil && x.IsInf() {
+				return &UnsupportedValueError{
+					Path: path,
+					Err:  fmt.Errorf("%s is not representable as JSON", x),
+				}
+			}
+			return nil
+		}
+	}
+	if v.Type().Implements(marshalerType) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return &UnsupportedValueError{
+				Path: path,
+				Err:  fmt.Errorf("%s is not representable as JSON", strconv.FormatFloat(f, 'g', -1, 64)),
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		return val.validate(v.Elem(), path)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if err := val.enter(v, path); err != nil {
+			return err
+		}
+		defer val.leave(v)
+		return val.validate(v.Elem(), path)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string.
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := val.validate(v.Index(i), path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		switch kt := v.Type().Key(); kt.Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !kt.Implements(textMarshalerType) {
+				return &UnsupportedValueError{
+					Path: path,
+					Err:  fmt.Errorf("map key type %s is not representable as JSON", kt),
+				}
+			}
+		}
+		if err := val.enter(v, path); err != nil {
+			return err
+		}
+		defer val.leave(v)
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if err := val.validate(iter.Value(), path+"/"+escapePointerToken(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			name := f.Name
+			if tag := f.Tag.Get("json"); tag == "-" {
+				continue
+			} else if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+			if err := val.validate(v.Field(i), path+"/"+escapePointerToken(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return &UnsupportedValueError{
+			Path: path,
+			Err:  fmt.Errorf("%s is not representable as JSON", v.Type()),
+		}
+	}
+}
+
+func (val *validator) validateObject(o *Object, path string) error {
+	// Copies of an Object value have addresses of their own, but share its
+	// storage.
+	if key := objectID(o); key != nil {
+		if _, ok := val.objects[key]; ok {
+			return &UnsupportedValueError{Path: path, Err: ErrCycle}
+		}
+		val.objects[key] = struct{}{}
+		defer delete(val.objects, key)
+	}
+	v := reflect.ValueOf(o)
+	if err := val.enter(v, path); err != nil {
+		return err
+	}
+	defer val.leave(v)
+	for _, kv := range o.entries {
+		if err := val.validate(reflect.ValueOf(kv.Value), path+"/"+escapePointerToken(kv.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (val *validator) enter(v reflect.Value, path string) error {
+	p := v.Pointer()
+	if _, ok := val.visiting[p]; ok {
+		return &UnsupportedValueError{Path: path, Err: ErrCycle}
+	}
+	val.visiting[p] = struct{}{}
+	return nil
+}
+
+func (val *validator) leave(v reflect.Value) {
+	delete(val.visiting, v.Pointer())
+}
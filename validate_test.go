@@ -0,0 +1,91 @@
+package ojson
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(tt *testing.T) {
+	for _, test := range []struct {
+		name    string
+		v       interface{}
+		wantErr bool
+		path    string
+	}{
+		{name: "null", v: nil},
+		{name: "decoded value", v: MustNewValueFromJSON(`{"a":[1,"x",{"b":null}]}`)},
+		{name: "struct", v: struct {
+			A int
+			B chan int `json:"-"`
+			c func()
+		}{}},
+		{name: "map with int keys", v: map[int]string{1: "a"}},
+		{name: "channel", wantErr: true, v: make(chan int)},
+		{name: "func in array", wantErr: true, v: []interface{}{1, func() {}}, path: "/1"},
+		{name: "NaN in object", wantErr: true, v: NewObject().SetAndReturn("a/b", math.NaN()), path: "/a~1b"},
+		{name: "inside Value", wantErr: true, v: Value{V: []interface{}{math.NaN()}}, path: "/0"},
+		{name: "infinity", wantErr: true, v: math.Inf(1)},
+		{name: "unsupported map key", wantErr: true, v: map[bool]int{true: 1}},
+		{name: "nested struct field", wantErr: true, v: struct {
+			Inner struct {
+				C complex128 `json:"c"`
+			}
+		}{}, path: "/Inner/c"},
+	} {
+		tt.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+			err := Validate(test.v)
+			if !test.wantErr {
+				require.NoError(err)
+				return
+			}
+			var uerr *UnsupportedValueError
+			require.True(errors.As(err, &uerr))
+			require.Equal(test.path, uerr.Path)
+		})
+	}
+
+	tt.Run("cycle through an Object value", func(t *testing.T) {
+		require := require.New(t)
+		// A copy of an Object value shares its storage.
+		o := NewObject()
+		o.Set("self", nil)
+		o.Set("self", *o)
+		err := Validate(o)
+		var uerr *UnsupportedValueError
+		require.True(errors.As(err, &uerr))
+		require.Equal("/self", uerr.Path)
+		require.True(errors.Is(err, ErrCycle))
+
+		o = NewObject().SetAndReturn("a", 1)
+		require.True(errors.Is(o.SetValidated("a", *o), ErrCycle))
+		v, _ := o.Get("a")
+		require.Equal(1, v)
+	})
+}
+
+func TestSetValidated(tt *testing.T) {
+	tt.Run("valid", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		require.NoError(o.SetValidated("a", []interface{}{1.0, "x"}))
+		require.Equal([]string{"a"}, o.KeyOrder())
+	})
+	tt.Run("invalid", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		err := o.SetValidated("a", map[string]interface{}{"b": make(chan int)})
+		require.EqualError(err, `unsupported value at "/a/b": chan int is not representable as JSON`)
+		require.Empty(o.KeyOrder())
+	})
+	tt.Run("cycle", func(t *testing.T) {
+		require := require.New(t)
+		o := NewObject()
+		err := o.SetValidated("a", NewObject().SetAndReturn("b", o))
+		require.True(errors.Is(err, ErrCycle))
+		require.Empty(o.KeyOrder())
+	})
+}